@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Alert event types
+const (
+	AlertEventDown      = "down"
+	AlertEventRecovered = "recovered"
+	AlertEventBusOff    = "bus_off"
+	AlertEventSilent    = "silent"  // Fewer frames than -min-rx-rate arrived in a check interval
+	AlertEventTraffic   = "traffic" // A silent interface receives enough frames again
+)
+
+// AlertEvent is the JSON payload posted to the alert webhook
+type AlertEvent struct {
+	Interface        string    `json:"interface"`
+	Event            string    `json:"event"`
+	Timestamp        time.Time `json:"timestamp"`
+	Details          string    `json:"details,omitempty"`
+	RecoveryAttempts int       `json:"recoveryAttempts"`
+}
+
+// AlertNotifier delivers interface state change events to a webhook without blocking the caller
+type AlertNotifier struct {
+	webhookURL  string
+	cooldown    time.Duration
+	maxAttempts int
+	retryDelay  time.Duration
+	client      *http.Client
+	logger      Logger
+	events      chan AlertEvent
+	lastSent    map[string]time.Time
+	mu          sync.Mutex
+	wg          sync.WaitGroup
+	stopOnce    sync.Once
+	ctx         context.Context // Cancelled when Stop gives up waiting, aborting in-flight posts
+	cancel      context.CancelFunc
+}
+
+// NewAlertNotifier creates a new webhook alert notifier
+func NewAlertNotifier(webhookURL string, cooldown time.Duration, logger Logger) *AlertNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AlertNotifier{
+		webhookURL:  webhookURL,
+		cooldown:    cooldown,
+		maxAttempts: 3,
+		retryDelay:  2 * time.Second,
+		client:      &http.Client{Timeout: 5 * time.Second},
+		logger:      logger,
+		events:      make(chan AlertEvent, 64),
+		lastSent:    make(map[string]time.Time),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start starts the delivery goroutine
+func (n *AlertNotifier) Start() {
+	n.wg.Add(1)
+	go n.deliveryLoop(n.events)
+	n.logger.Printf("🔔 Alert webhook enabled: %s (cooldown %v)", n.webhookURL, n.cooldown)
+}
+
+// Stop stops accepting events and waits for pending deliveries to finish. Once ctx is done,
+// the delivery in flight is aborted and the events still queued are dropped.
+func (n *AlertNotifier) Stop(ctx context.Context) {
+	n.stopOnce.Do(func() {
+		n.mu.Lock()
+		close(n.events)
+		n.events = nil
+		n.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		n.cancel()
+		<-done
+	}
+	n.cancel()
+}
+
+// Notify queues an event for delivery. Events for the same interface and type
+// within the cooldown window are suppressed, and the call never blocks.
+func (n *AlertNotifier) Notify(event AlertEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.events == nil {
+		return
+	}
+
+	key := event.Interface + "/" + event.Event
+	if last, ok := n.lastSent[key]; ok && event.Timestamp.Sub(last) < n.cooldown {
+		n.logger.Printf("🔕 Suppressing %s alert for %s (cooldown %v)", event.Event, event.Interface, n.cooldown)
+		return
+	}
+
+	select {
+	case n.events <- event:
+		n.lastSent[key] = event.Timestamp
+	default:
+		n.logger.Printf("⚠️ Alert queue full, dropping %s alert for %s", event.Event, event.Interface)
+	}
+}
+
+// deliveryLoop posts queued events to the webhook. It is handed the channel because Stop
+// clears n.events, possibly before the goroutine first runs.
+func (n *AlertNotifier) deliveryLoop(events <-chan AlertEvent) {
+	defer n.wg.Done()
+
+	dropped := 0
+	for event := range events {
+		if n.ctx.Err() != nil {
+			dropped++
+			continue
+		}
+		if err := n.deliver(event); err != nil {
+			n.logger.Printf("❌ Failed to deliver %s alert for %s: %v", event.Event, event.Interface, err)
+		}
+	}
+	if dropped > 0 {
+		n.logger.Printf("⚠️ Dropping %d undelivered alert(s) on shutdown", dropped)
+	}
+}
+
+// deliver posts a single event, retrying on failure
+func (n *AlertNotifier) deliver(event AlertEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		lastErr = n.post(body)
+		if lastErr == nil {
+			n.logger.Printf("🔔 Delivered %s alert for %s", event.Event, event.Interface)
+			return nil
+		}
+
+		if attempt < n.maxAttempts {
+			select {
+			case <-time.After(n.retryDelay):
+			case <-n.ctx.Done():
+				return fmt.Errorf("shutting down after %d attempts: %w", attempt, lastErr)
+			}
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", n.maxAttempts, lastErr)
+}
+
+// post performs one HTTP POST to the webhook
+func (n *AlertNotifier) post(body []byte) error {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAlertNotifierStopDeadline(t *testing.T) {
+	var posts atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	n := NewAlertNotifier(server.URL, 0, discardLogger{})
+	n.Start()
+	for _, ifName := range []string{"can0", "can1", "can2"} {
+		n.Notify(AlertEvent{Interface: ifName, Event: AlertEventDown})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	n.Stop(ctx)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Stop took %v with a hung webhook", elapsed)
+	}
+	if got := posts.Load(); got != 1 {
+		t.Fatalf("webhook received %d posts, want 1; queued alerts should be dropped", got)
+	}
+}
+
+func TestAlertNotifierStopDelivers(t *testing.T) {
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+	}))
+	defer server.Close()
+
+	n := NewAlertNotifier(server.URL, 0, discardLogger{})
+	n.Start()
+	n.Notify(AlertEvent{Interface: "can0", Event: AlertEventDown})
+	n.Notify(AlertEvent{Interface: "can0", Event: AlertEventRecovered})
+	n.Stop(context.Background())
+
+	if got := posts.Load(); got != 2 {
+		t.Fatalf("webhook received %d posts, want 2", got)
+	}
+}
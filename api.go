@@ -1,12 +1,25 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+	"golang.org/x/sys/unix"
 )
 
 // APIHandler handles HTTP API requests
@@ -15,9 +28,30 @@ type APIHandler struct {
 	monitor         *Monitor
 	setupManager    *InterfaceSetupManager
 	messageListener *CanMessageListener
+	listenHandles   *ListenHandles // The API's own hold on listeners, shared with the service
+	generator       *FrameGenerator
+	configStore     ConfigStore
+	selfTester      SelfTester
+	finder          *Finder
+	bridger         *Bridger
+	watchdog        *Watchdog
+	debug           bool
+	engine          *gin.Engine // Set by SetupRoutes; the OpenAPI document lists its routes
 	logger          Logger
 }
 
+// ConfigStore exports and imports the full service configuration
+type ConfigStore interface {
+	ExportConfig() *FileConfig
+	ImportConfig(data []byte) ([]string, error)
+}
+
+// SelfTester runs the startup self-test and keeps its last report
+type SelfTester interface {
+	SelfTest() SelfTestReport
+	LastSelfTest() *SelfTestReport
+}
+
 // NewAPIHandler creates a new API handler (legacy, without setup manager)
 func NewAPIHandler(messageSender *MessageSender, monitor *Monitor, logger Logger) *APIHandler {
 	return &APIHandler{
@@ -47,77 +81,240 @@ func NewAPIHandlerWithSetupAndListener(messageSender *MessageSender, monitor *Mo
 		monitor:         monitor,
 		setupManager:    setupManager,
 		messageListener: messageListener,
+		listenHandles:   NewListenHandles(messageListener),
 		logger:          logger,
 	}
 }
 
+// SetListenHandles shares a holder's listener handles with the API, so that stopping listening
+// through the API releases what the service started at boot
+func (h *APIHandler) SetListenHandles(handles *ListenHandles) {
+	h.listenHandles = handles
+}
+
+// SetFrameGenerator enables the synthetic traffic endpoints
+func (h *APIHandler) SetFrameGenerator(generator *FrameGenerator) {
+	h.generator = generator
+}
+
+// SetConfigStore enables the configuration export and import endpoints
+func (h *APIHandler) SetConfigStore(store ConfigStore) {
+	h.configStore = store
+}
+
+// SetSelfTester enables the self-test endpoints
+func (h *APIHandler) SetSelfTester(tester SelfTester) {
+	h.selfTester = tester
+}
+
+// SetFinder enables the endpoints that control the node finder
+func (h *APIHandler) SetFinder(finder *Finder) {
+	h.finder = finder
+}
+
+// SetBridger enables the endpoints that forward frames between interfaces
+func (h *APIHandler) SetBridger(bridger *Bridger) {
+	h.bridger = bridger
+}
+
+// SetWatchdog enables the endpoint that includes interfaces in or excludes them from recovery
+func (h *APIHandler) SetWatchdog(watchdog *Watchdog) {
+	h.watchdog = watchdog
+}
+
+// SetDebug enables the diagnostic endpoints
+func (h *APIHandler) SetDebug(enabled bool) {
+	h.debug = enabled
+}
+
+// API versions. Each /api/vN prefix stays pinned to the behavior of that version, while the
+// unversioned /api prefix tracks the latest stable one.
+const (
+	APIVersionV1     = "v1"
+	LatestAPIVersion = APIVersionV1
+)
+
+// apiVersionHeader tells clients which API version served a request, e.g. one sent to /api
+const apiVersionHeader = "X-API-Version"
+
 // SetupRoutes configures all API routes
 func (h *APIHandler) SetupRoutes(r *gin.Engine) {
+	h.engine = r
+
 	// Simple status page
 	r.GET("/", h.handleRoot)
 
-	api := r.Group("/api")
-	{
-		// Message endpoints
-		api.POST("/can", h.handleCanMessage)
-
-		// Status and monitoring endpoints
-		api.GET("/status", h.handleSystemStatus)
-		api.GET("/interfaces", h.handleInterfacesList)
-		api.GET("/interfaces/:name/status", h.handleInterfaceStatus)
-		api.GET("/health", h.handleHealthSummary)
-		api.GET("/metrics", h.handleMetrics)
-
-		// Interface setup endpoints (new)
-		if h.setupManager != nil {
-			setup := api.Group("/setup")
-			{
-				setup.GET("/config", h.handleGetSetupConfig)
-				setup.PUT("/config", h.handleUpdateSetupConfig)
-				setup.GET("/available", h.handleGetAvailableInterfaces)
-				setup.POST("/interfaces/:name", h.handleSetupInterface)
-				setup.DELETE("/interfaces/:name", h.handleTeardownInterface)
-				setup.POST("/interfaces/:name/reset", h.handleResetInterface)
-				setup.GET("/interfaces/:name/state", h.handleGetInterfaceState)
-				setup.POST("/interfaces/setup-all", h.handleSetupAllInterfaces)
-				setup.POST("/interfaces/teardown-all", h.handleTeardownAllInterfaces)
-			}
+	// API description and browser
+	r.GET("/swagger", h.handleSwaggerUI)
+
+	// A future v2 gets its own registerV2Routes under /api/v2, and /api moves to it once it is
+	// stable; /api/v1 keeps serving registerV1Routes.
+	h.registerV1Routes(r.Group("/api/"+APIVersionV1, apiVersion(APIVersionV1)))
+	h.registerV1Routes(r.Group("/api", apiVersion(LatestAPIVersion)))
+}
+
+// apiVersion returns a middleware that reports the API version in the response headers
+func apiVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(apiVersionHeader, version)
+		c.Next()
+	}
+}
+
+// registerV1Routes registers the version 1 endpoints under api
+func (h *APIHandler) registerV1Routes(api *gin.RouterGroup) {
+	// Message endpoints
+	api.POST("/can", h.handleCanMessage)
+	api.POST("/can/request", h.handleCanRequest)
+	api.POST("/isotp/:interface", h.handleISOTP)
+	api.POST("/isotp/:interface/receive", h.handleReceiveISOTP)
+	api.GET("/openapi.json", h.handleOpenAPI)
+
+	// Synthetic traffic for load testing
+	if h.generator != nil {
+		api.POST("/can/generate", h.handleStartGenerator)
+		api.GET("/can/generate", h.handleListGenerators)
+		api.GET("/can/generate/:id", h.handleGetGenerator)
+		api.DELETE("/can/generate/:id", h.handleStopGenerator)
+	}
+
+	// Forwarding between interfaces
+	if h.bridger != nil {
+		api.POST("/bridge", h.handleStartBridge)
+		api.GET("/bridge", h.handleListBridges)
+		api.GET("/bridge/:id", h.handleGetBridge)
+		api.DELETE("/bridge/:id", h.handleStopBridge)
+	}
+
+	// Configuration snapshot and restore
+	if h.configStore != nil {
+		api.GET("/config/export", h.handleExportConfig)
+		api.POST("/config/import", h.handleImportConfig)
+	}
+
+	// Startup self-test report
+	if h.selfTester != nil {
+		api.GET("/selftest", h.handleGetSelfTest)
+		api.POST("/selftest", h.handleRunSelfTest)
+	}
+
+	// Node finder control
+	if h.finder != nil {
+		api.GET("/finder", h.handleGetFinder)
+		api.POST("/finder", h.handleUpdateFinder)
+	}
+
+	// Watchdog recovery per interface
+	if h.watchdog != nil {
+		api.PUT("/watchdog/interfaces/:name", h.handleSetWatchdogInterface)
+	}
+
+	// Status and monitoring endpoints
+	api.GET("/status", h.handleSystemStatus)
+	api.GET("/interfaces", h.handleInterfacesList)
+	api.GET("/interfaces/:name", h.handleInterfaceDetails)
+	api.GET("/interfaces/:name/status", h.handleInterfaceStatus)
+	api.GET("/interfaces/:name/history", h.handleInterfaceHistory)
+	api.POST("/interfaces/:name/metrics/reset", h.handleResetInterfaceMetrics)
+	api.POST("/interfaces/:name/initialize", h.markDryRun, h.handleInitializeInterface)
+	api.POST("/interfaces/:name/online", h.markDryRun, h.handleOnlineInterface)
+	api.POST("/interfaces/:name/shutdown", h.markDryRun, h.handleShutdownInterface)
+	api.GET("/health", h.handleHealthSummary)
+	api.GET("/metrics", h.handleMetrics)
+	api.POST("/metrics/reset", h.handleResetAllMetrics)
+	api.GET("/summary", h.handleSummary)
+	api.GET("/version", h.handleVersion)
+
+	// Diagnostics, only with -debug
+	if h.debug {
+		api.GET("/debug/stats", h.handleDebugStats)
+	}
+
+	// Interface setup endpoints (new)
+	if h.setupManager != nil {
+		setup := api.Group("/setup", h.markDryRun)
+		{
+			setup.GET("/config", h.handleGetSetupConfig)
+			setup.PUT("/config", h.handleUpdateSetupConfig)
+			setup.GET("/available", h.handleGetAvailableInterfaces)
+			setup.POST("/interfaces/:name", h.handleSetupInterface)
+			setup.DELETE("/interfaces/:name", h.handleTeardownInterface)
+			setup.POST("/interfaces/:name/reset", h.handleResetInterface)
+			setup.POST("/interfaces/:name/create", h.handleCreateInterface)
+			setup.GET("/interfaces/:name/state", h.handleGetInterfaceState)
+			setup.GET("/interfaces/:name/applied", h.handleGetAppliedSetup)
+			setup.POST("/interfaces/setup-all", h.handleSetupAllInterfaces)
+			setup.POST("/interfaces/teardown-all", h.handleTeardownAllInterfaces)
 		}
+	}
 
-		// Message listening endpoints (new)
-		if h.messageListener != nil {
-			messages := api.Group("/messages")
-			{
-				// Get messages from specific interface
-				messages.GET("/:interface", h.handleGetMessages)
-				messages.GET("/:interface/recent", h.handleGetRecentMessages)
-				messages.GET("/:interface/statistics", h.handleGetMessageStatistics)
-				messages.DELETE("/:interface", h.handleClearMessages)
-
-				// Global message operations
-				messages.GET("/", h.handleGetAllMessages)
-				messages.GET("/statistics", h.handleGetAllMessageStatistics)
-				messages.DELETE("/", h.handleClearAllMessages)
-
-				// Listener control
-				messages.POST("/:interface/listen/start", h.handleStartListening)
-				messages.POST("/:interface/listen/stop", h.handleStopListening)
-				messages.GET("/:interface/listen/status", h.handleGetListenStatus)
-				messages.GET("/listen/status", h.handleGetAllListenStatus)
-			}
+	// Message listening endpoints (new)
+	if h.messageListener != nil {
+		messages := api.Group("/messages")
+		{
+			// Get messages from specific interface
+			messages.GET("/:interface", h.handleGetMessages)
+			messages.GET("/:interface/recent", h.handleGetRecentMessages)
+			messages.GET("/:interface/statistics", h.handleGetMessageStatistics)
+			messages.GET("/:interface/errors", h.handleGetErrorFrames)
+			messages.GET("/:interface/ids", h.handleGetIDCounts)
+			messages.DELETE("/:interface", h.handleClearMessages)
+			messages.PUT("/:interface/logging", h.handleSetMessageLogging)
+
+			// Global message operations
+			messages.GET("/", h.handleGetAllMessages)
+			messages.GET("/statistics", h.handleGetAllMessageStatistics)
+			messages.GET("/search", h.handleSearchMessages)
+			messages.DELETE("/", h.handleClearAllMessages)
+
+			// Listener control
+			messages.POST("/:interface/listen/start", h.handleStartListening)
+			messages.POST("/:interface/listen/stop", h.handleStopListening)
+			messages.GET("/:interface/listen/status", h.handleGetListenStatus)
+			messages.GET("/listen/status", h.handleGetAllListenStatus)
 		}
 	}
 }
 
 // handleRoot serves the root endpoint
 func (h *APIHandler) handleRoot(c *gin.Context) {
-	c.String(http.StatusOK, "CAN Communication Service is running")
+	c.String(http.StatusOK, "CAN Communication Service is running (version %s)", VERSION)
+}
+
+// bindCanMessage reads a CanMessage from a JSON body, or from form fields where id and dataHex
+// are decoded the way query parameters are
+func bindCanMessage(c *gin.Context) (CanMessage, error) {
+	// JSON is the main format; HTML forms and curl -d post urlencoded or multipart fields
+	var req CanMessage
+	bind := c.ShouldBindJSON
+	switch c.ContentType() {
+	case binding.MIMEPOSTForm, binding.MIMEMultipartPOSTForm:
+		bind = c.ShouldBind
+	}
+	if err := bind(&req); err != nil {
+		return req, err
+	}
+	if req.IDText != "" {
+		id, err := parseCanID(req.IDText)
+		if err != nil {
+			return req, fmt.Errorf("invalid id %q: %w", req.IDText, err)
+		}
+		req.ID = id
+	}
+	if req.DataHex != "" {
+		data, err := hex.DecodeString(strings.ReplaceAll(req.DataHex, " ", ""))
+		if err != nil {
+			return req, fmt.Errorf("invalid dataHex %q: %w", req.DataHex, err)
+		}
+		req.Data = data
+	}
+	return req, nil
 }
 
 // handleCanMessage handles raw CAN message requests
 func (h *APIHandler) handleCanMessage(c *gin.Context) {
-	var req CanMessage
-	if err := c.ShouldBindJSON(&req); err != nil {
+	req, err := bindCanMessage(c)
+	if err != nil {
 		h.respondError(c, http.StatusBadRequest, "Invalid CAN message request", err)
 		return
 	}
@@ -127,22 +324,469 @@ func (h *APIHandler) handleCanMessage(c *gin.Context) {
 		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
 		return
 	}
+	req.Interface, _ = h.messageSender.ResolveInterfaceName(req.Interface) // Validated above
+	req.Source = c.ClientIP()
+
+	// Queue the CAN message when asynchronous sending is enabled
+	if h.messageSender.IsAsync() {
+		if err := h.messageSender.EnqueueCanMessage(req); err != nil {
+			if errors.Is(err, ErrSendQueueFull) {
+				h.respondError(c, http.StatusTooManyRequests, "Send queue is full", err)
+				return
+			}
+			h.respondError(c, http.StatusInternalServerError, "Failed to queue CAN message", err)
+			return
+		}
+
+		h.respondSuccess(c, "CAN message queued successfully", req)
+		return
+	}
 
 	// Send the CAN message
 	if err := h.messageSender.SendCanMessage(req); err != nil {
-		h.respondError(c, http.StatusInternalServerError, "Failed to send CAN message", err)
+		if errors.Is(err, ErrTxQueueFull) {
+			h.respondError(c, http.StatusServiceUnavailable, "CAN TX queue is full", err)
+			return
+		}
+		h.respondError(c, sendErrorStatus(err), "Failed to send CAN message", err)
 		return
 	}
 
 	h.respondSuccess(c, "CAN message sent successfully", req)
 }
 
+// CanRequest represents a request frame and the ID of the expected response
+type CanRequest struct {
+	Message    CanMessage `json:"message" binding:"required"`
+	ResponseID uint32     `json:"responseId"`
+	TimeoutMs  int        `json:"timeoutMs,omitempty"`
+}
+
+// Response timeout bounds for /api/can/request
+const (
+	defaultRequestTimeoutMs = 1000
+	maxRequestTimeoutMs     = 30000
+)
+
+// handleCanRequest sends a CAN message and returns the first matching response frame
+func (h *APIHandler) handleCanRequest(c *gin.Context) {
+	var req CanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid CAN request", err)
+		return
+	}
+
+	if err := h.messageSender.ValidateMessage(req.Message); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+		return
+	}
+	req.Message.Interface, _ = h.messageSender.ResolveInterfaceName(req.Message.Interface) // Validated above
+	req.Message.Source = c.ClientIP()
+
+	timeoutMs := req.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = defaultRequestTimeoutMs
+	}
+	if timeoutMs > maxRequestTimeoutMs {
+		h.respondError(c, http.StatusBadRequest, fmt.Sprintf("timeoutMs must not exceed %d", maxRequestTimeoutMs), nil)
+		return
+	}
+
+	response, err := h.messageSender.SendAndWait(c.Request.Context(), req.Message, req.ResponseID, time.Duration(timeoutMs)*time.Millisecond)
+	if err != nil {
+		if errors.Is(err, ErrResponseTimeout) {
+			h.respondError(c, http.StatusGatewayTimeout, "No response received", err)
+			return
+		}
+		h.respondError(c, sendErrorStatus(err), "Failed to send CAN request", err)
+		return
+	}
+
+	h.respondSuccess(c, "Response received", response)
+}
+
+// handleStartGenerator starts a job that sends synthetic frames at a fixed rate
+func (h *APIHandler) handleStartGenerator(c *gin.Context) {
+	var req GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid generator request", err)
+		return
+	}
+
+	status, err := h.generator.Start(req)
+	if err != nil {
+		if errors.Is(err, ErrTooManyGeneratorJobs) {
+			h.respondError(c, http.StatusTooManyRequests, "Failed to start generator", err)
+			return
+		}
+		h.respondError(c, http.StatusBadRequest, "Failed to start generator", err)
+		return
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("Generator %s started", status.ID), status)
+}
+
+// handleListGenerators returns running and recently finished generator jobs
+func (h *APIHandler) handleListGenerators(c *gin.Context) {
+	jobs := h.generator.List()
+	h.respondSuccess(c, "", map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// handleGetGenerator returns the status of one generator job
+func (h *APIHandler) handleGetGenerator(c *gin.Context) {
+	status, exists := h.generator.Get(c.Param("id"))
+	if !exists {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("Generator job %s not found", c.Param("id")), nil)
+		return
+	}
+	h.respondSuccess(c, "", status)
+}
+
+// handleStopGenerator cancels a generator job
+func (h *APIHandler) handleStopGenerator(c *gin.Context) {
+	status, exists := h.generator.Stop(c.Param("id"))
+	if !exists {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("Generator job %s not found", c.Param("id")), nil)
+		return
+	}
+	h.respondSuccess(c, fmt.Sprintf("Generator %s stopped", status.ID), status)
+}
+
+// stopGenerators cancels generator jobs sending on an interface that is going away
+func (h *APIHandler) stopGenerators(c *gin.Context, ifName string) int {
+	if h.generator == nil {
+		return 0
+	}
+	stopped := h.generator.StopInterface(ifName)
+	if stopped > 0 {
+		h.logf(c, "🎲 Stopped %d generator job(s) on %s", stopped, ifName)
+	}
+	return stopped
+}
+
+// handleStartBridge starts forwarding frames from one interface to another
+func (h *APIHandler) handleStartBridge(c *gin.Context) {
+	var req BridgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid bridge request", err)
+		return
+	}
+
+	status, err := h.bridger.Start(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTooManyBridges):
+			h.respondError(c, http.StatusTooManyRequests, "Failed to start bridge", err)
+		case errors.Is(err, ErrBridgeExists):
+			h.respondError(c, http.StatusConflict, "Failed to start bridge", err)
+		default:
+			h.respondError(c, http.StatusBadRequest, "Failed to start bridge", err)
+		}
+		return
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("Bridge %s started", status.ID), status)
+}
+
+// handleListBridges returns the active bridges
+func (h *APIHandler) handleListBridges(c *gin.Context) {
+	bridges := h.bridger.List()
+	h.respondSuccess(c, "", map[string]interface{}{
+		"bridges": bridges,
+		"count":   len(bridges),
+	})
+}
+
+// handleGetBridge returns the status of one bridge
+func (h *APIHandler) handleGetBridge(c *gin.Context) {
+	status, exists := h.bridger.Get(c.Param("id"))
+	if !exists {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("Bridge %s not found", c.Param("id")), nil)
+		return
+	}
+	h.respondSuccess(c, "", status)
+}
+
+// handleStopBridge removes a bridge
+func (h *APIHandler) handleStopBridge(c *gin.Context) {
+	status, exists := h.bridger.Stop(c.Param("id"))
+	if !exists {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("Bridge %s not found", c.Param("id")), nil)
+		return
+	}
+	h.respondSuccess(c, fmt.Sprintf("Bridge %s removed", status.ID), status)
+}
+
+// stopBridges removes bridges forwarding from or to an interface that is going away
+func (h *APIHandler) stopBridges(c *gin.Context, ifName string) int {
+	if h.bridger == nil {
+		return 0
+	}
+	stopped := h.bridger.StopInterface(ifName)
+	if stopped > 0 {
+		h.logf(c, "🌉 Removed %d bridge(s) on %s", stopped, ifName)
+	}
+	return stopped
+}
+
+// ISOTPRequest represents an ISO-TP message to send, optionally waiting for the response.
+// The IDs are pointers so that a missing ID is rejected while ID 0 is accepted.
+type ISOTPRequest struct {
+	TxID         *uint32 `json:"txId" binding:"required"`
+	RxID         *uint32 `json:"rxId" binding:"required"`
+	Data         []byte  `json:"data" binding:"required"`
+	WaitResponse bool    `json:"waitResponse,omitempty"`
+	TimeoutMs    int     `json:"timeoutMs,omitempty"`
+}
+
+// ISOTPReceiveRequest waits for the next ISO-TP message the peer sends on rxId
+type ISOTPReceiveRequest struct {
+	TxID      *uint32 `json:"txId" binding:"required"` // Flow control for multi-frame messages is sent here
+	RxID      *uint32 `json:"rxId" binding:"required"`
+	TimeoutMs int     `json:"timeoutMs,omitempty"`
+}
+
+// handleISOTP sends an ISO-TP message and optionally returns the reassembled response
+func (h *APIHandler) handleISOTP(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
+		return
+	}
+
+	var req ISOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid ISO-TP request", err)
+		return
+	}
+
+	if !h.messageSender.configProvider.ValidateInterface(ifName) {
+		h.respondError(c, http.StatusBadRequest, fmt.Sprintf("CAN interface %s is not configured", ifName), nil)
+		return
+	}
+
+	if !req.WaitResponse {
+		if err := h.messageSender.SendISOTP(ifName, *req.TxID, *req.RxID, req.Data); err != nil {
+			h.respondISOTPError(c, err)
+			return
+		}
+		h.respondSuccess(c, "ISO-TP message sent", map[string]interface{}{
+			"interface": ifName,
+			"sent":      len(req.Data),
+		})
+		return
+	}
+
+	timeoutMs := req.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = defaultRequestTimeoutMs
+	}
+	if timeoutMs > maxRequestTimeoutMs {
+		h.respondError(c, http.StatusBadRequest, fmt.Sprintf("timeoutMs must not exceed %d", maxRequestTimeoutMs), nil)
+		return
+	}
+
+	response, err := h.messageSender.RequestISOTP(ifName, *req.TxID, *req.RxID, req.Data, time.Duration(timeoutMs)*time.Millisecond)
+	if err != nil {
+		h.respondISOTPError(c, err)
+		return
+	}
+
+	h.respondSuccess(c, "ISO-TP response received", map[string]interface{}{
+		"interface":   ifName,
+		"sent":        len(req.Data),
+		"response":    response,
+		"responseHex": bytesToHexArray(response),
+		"length":      len(response),
+	})
+}
+
+// handleReceiveISOTP waits for the next ISO-TP message on rxId and returns it reassembled
+func (h *APIHandler) handleReceiveISOTP(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
+		return
+	}
+
+	var req ISOTPReceiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid ISO-TP receive request", err)
+		return
+	}
+
+	if !h.messageSender.configProvider.ValidateInterface(ifName) {
+		h.respondError(c, http.StatusBadRequest, fmt.Sprintf("CAN interface %s is not configured", ifName), nil)
+		return
+	}
+
+	timeoutMs := req.TimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = defaultRequestTimeoutMs
+	}
+	if timeoutMs > maxRequestTimeoutMs {
+		h.respondError(c, http.StatusBadRequest, fmt.Sprintf("timeoutMs must not exceed %d", maxRequestTimeoutMs), nil)
+		return
+	}
+
+	message, err := h.messageSender.ReceiveISOTP(ifName, *req.TxID, *req.RxID, time.Duration(timeoutMs)*time.Millisecond)
+	if err != nil {
+		h.respondISOTPError(c, err)
+		return
+	}
+
+	h.respondSuccess(c, "ISO-TP message received", map[string]interface{}{
+		"interface": ifName,
+		"data":      message,
+		"dataHex":   bytesToHexArray(message),
+		"length":    len(message),
+	})
+}
+
+// respondISOTPError maps ISO-TP errors to HTTP status codes
+func (h *APIHandler) respondISOTPError(c *gin.Context, err error) {
+	if errors.Is(err, ErrISOTPTimeout) {
+		h.respondError(c, http.StatusGatewayTimeout, "ISO-TP transfer timed out", err)
+		return
+	}
+	h.respondError(c, http.StatusInternalServerError, "ISO-TP transfer failed", err)
+}
+
 // handleSystemStatus returns complete system status
 func (h *APIHandler) handleSystemStatus(c *gin.Context) {
 	status := h.monitor.GetSystemStatus()
 	h.respondSuccess(c, "", status)
 }
 
+// FinderRequest turns the node finder on or off and optionally changes its interval
+type FinderRequest struct {
+	Enabled         *bool `json:"enabled"`
+	IntervalSeconds *int  `json:"intervalSeconds,omitempty"`
+}
+
+// handleGetFinder returns the node finder state
+func (h *APIHandler) handleGetFinder(c *gin.Context) {
+	h.respondSuccess(c, "", h.finder.GetStatus())
+}
+
+// handleUpdateFinder starts or stops the node finder and adjusts its interval at runtime
+func (h *APIHandler) handleUpdateFinder(c *gin.Context) {
+	var req FinderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid finder request", err)
+		return
+	}
+	if req.Enabled == nil && req.IntervalSeconds == nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid finder request", fmt.Errorf("set enabled and/or intervalSeconds"))
+		return
+	}
+	if req.IntervalSeconds != nil {
+		interval := time.Duration(*req.IntervalSeconds) * time.Second
+		if interval < MinFinderInterval {
+			h.respondError(c, http.StatusBadRequest, "Invalid finder request", fmt.Errorf("finder interval must be at least %v, got %v", MinFinderInterval, interval))
+			return
+		}
+		h.finder.SetInterval(interval)
+		h.logf(c, "🔁 Finder interval set to %v", interval)
+	}
+	if req.Enabled != nil {
+		if *req.Enabled {
+			h.finder.Start()
+		} else {
+			h.finder.Stop()
+		}
+		h.logf(c, "🔁 Finder enabled: %v", *req.Enabled)
+	}
+
+	h.respondSuccess(c, "Finder updated", h.finder.GetStatus())
+}
+
+// WatchdogInterfaceRequest includes an interface in or excludes it from watchdog recovery
+type WatchdogInterfaceRequest struct {
+	Monitored *bool `json:"monitored" binding:"required"`
+}
+
+// handleSetWatchdogInterface includes an interface in or excludes it from watchdog recovery.
+// The change lasts until the service restarts.
+func (h *APIHandler) handleSetWatchdogInterface(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+	if !h.messageSender.configProvider.ValidateInterface(ifName) {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("CAN interface %s is not configured", ifName), nil)
+		return
+	}
+
+	var req WatchdogInterfaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid watchdog request", err)
+		return
+	}
+
+	h.watchdog.SetMonitored(ifName, *req.Monitored)
+	if *req.Monitored {
+		h.logf(c, "🐕 %s included in watchdog recovery", ifName)
+	} else {
+		h.logf(c, "🐕 %s excluded from watchdog recovery", ifName)
+	}
+
+	h.respondSuccess(c, "Watchdog updated", map[string]interface{}{
+		"interface": ifName,
+		"monitored": *req.Monitored,
+		"excluded":  h.watchdog.ExcludedInterfaces(),
+	})
+}
+
+// handleVersion returns build information of the running service
+func (h *APIHandler) handleVersion(c *gin.Context) {
+	h.respondSuccess(c, "", GetVersionInfo())
+}
+
+// handleDebugStats reports goroutine, descriptor and memory usage for spotting leaks
+func (h *APIHandler) handleDebugStats(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	sendSockets, sendQueues := h.messageSender.GetResourceCounts()
+	data := map[string]interface{}{
+		"goroutines":  runtime.NumGoroutine(),
+		"sendSockets": sendSockets,
+		"sendQueues":  sendQueues,
+		"processFDs":  countOpenFDs(),
+		"memory": map[string]interface{}{
+			"allocBytes":      mem.Alloc,
+			"totalAllocBytes": mem.TotalAlloc,
+			"sysBytes":        mem.Sys,
+			"heapInuseBytes":  mem.HeapInuse,
+			"heapObjects":     mem.HeapObjects,
+			"numGC":           mem.NumGC,
+			"gcPauseTotal":    time.Duration(mem.PauseTotalNs).String(),
+		},
+	}
+	if h.messageListener != nil {
+		listeners, listenerFDs, subscriptions := h.messageListener.GetResourceCounts()
+		data["listeners"] = listeners
+		data["listenerFDs"] = listenerFDs
+		data["subscriptions"] = subscriptions
+	}
+	if h.generator != nil {
+		data["generatorJobs"] = h.generator.RunningCount()
+	}
+
+	h.respondSuccess(c, "", data)
+}
+
+// countOpenFDs returns the number of file descriptors open in this process, or -1 if unknown
+func countOpenFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
+
 // handleInterfacesList returns available CAN interfaces
 func (h *APIHandler) handleInterfacesList(c *gin.Context) {
 	status := h.monitor.GetSystemStatus()
@@ -170,36 +814,364 @@ func (h *APIHandler) handleInterfacesList(c *gin.Context) {
 	h.respondSuccess(c, "", data)
 }
 
-// handleInterfaceStatus returns status for a specific interface
-func (h *APIHandler) handleInterfaceStatus(c *gin.Context) {
-	ifName := c.Param("name")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+// handleInterfaceHistory returns the recent up/down transitions of an interface
+func (h *APIHandler) handleInterfaceHistory(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	// Get limit parameter (default: every transition kept)
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 0 {
+			h.respondError(c, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = parsed
+	}
+
+	h.respondSuccess(c, "", h.monitor.GetInterfaceHistory(ifName, limit))
+}
+
+// OperationStep reports the result of one step of a multi-step interface operation
+type OperationStep struct {
+	Step    string `json:"step"`
+	Status  string `json:"status"` // "done", "skipped" or "failed"
+	Details string `json:"details,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// stepReport collects the ordered step results of an online/shutdown operation
+type stepReport struct {
+	steps  []OperationStep
+	failed int
+}
+
+// record adds the result of a step that ran
+func (r *stepReport) record(step string, err error, details string) {
+	result := OperationStep{Step: step, Status: "done", Details: details}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+		r.failed++
+	}
+	r.steps = append(r.steps, result)
+}
+
+// skip adds a step that did not run
+func (r *stepReport) skip(step, reason string) {
+	r.steps = append(r.steps, OperationStep{Step: step, Status: "skipped", Details: reason})
+}
+
+// handleOnlineInterface makes an interface usable in one call: the link is set up (with retry),
+// the send socket opened and listening started. Later steps are skipped if setup fails.
+func (h *APIHandler) handleOnlineInterface(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	report := &stepReport{}
+
+	// 1. Configure and bring the link up
+	switch {
+	case h.setupManager == nil:
+		report.skip("setup", "setup manager not available")
+	case h.setupManager.IsSetupDisabled():
+		report.skip("setup", "interface setup is disabled")
+	default:
+		outcome, err := h.setupManager.SetupInterfaceWithRetry(c.Request.Context(), ifName)
+		details := outcome.Reason
+		if outcome.Warning != "" {
+			details += "; warning: " + outcome.Warning
+		}
+		report.record("setup", err, details)
+	}
+
+	// 2. Open the send socket (only configured ports can send)
+	switch {
+	case report.failed > 0:
+		report.skip("open-send-socket", "setup failed")
+	case !slices.Contains(h.monitor.GetConfiguredPorts(), ifName):
+		report.skip("open-send-socket", "not a configured CAN port")
+	default:
+		opened, err := h.messageSender.OpenInterface(ifName)
+		if err == nil && !opened {
+			report.skip("open-send-socket", "already open")
+		} else {
+			report.record("open-send-socket", err, "")
+		}
+	}
+
+	// 3. Start listening
+	switch {
+	case report.failed > 0:
+		report.skip("start-listening", "an earlier step failed")
+	case h.messageListener == nil:
+		report.skip("start-listening", "message listener not available")
+	default:
+		wasListening := h.messageListener.IsListening(ifName)
+		err := h.listenHandles.Acquire(ifName)
+		if err == nil && wasListening {
+			report.skip("start-listening", "already listening")
+		} else {
+			report.record("start-listening", err, "")
+		}
+	}
+
+	responseData := map[string]interface{}{
+		"interface":  ifName,
+		"steps":      report.steps,
+		"errorCount": report.failed,
+		"online":     report.failed == 0,
+	}
+
+	if report.failed > 0 {
+		h.respondSuccess(c, fmt.Sprintf("Interface %s could not be brought fully online", ifName), responseData)
+	} else {
+		h.respondSuccess(c, fmt.Sprintf("Interface %s is online", ifName), responseData)
+	}
+}
+
+// handleInitializeInterface opens the send socket of a configured interface that failed to
+// initialize, e.g. because it only appeared after the service started
+func (h *APIHandler) handleInitializeInterface(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	if !slices.Contains(h.monitor.GetConfiguredPorts(), ifName) {
+		h.respondError(c, http.StatusBadRequest, "Interface is not a configured CAN port", nil)
+		return
+	}
+
+	exists := h.messageSender.InterfaceExists(ifName)
+	opened, err := h.messageSender.OpenInterface(ifName)
+	if err != nil {
+		if !exists {
+			err = fmt.Errorf("%w: no such interface on this host", err)
+		}
+		h.respondError(c, http.StatusServiceUnavailable, fmt.Sprintf("Failed to initialize interface %s", ifName), err)
+		return
+	}
+
+	responseData := map[string]interface{}{
+		"interface":          ifName,
+		"initialized":        true,
+		"alreadyInitialized": !opened,
+	}
+
+	if opened {
+		h.respondSuccess(c, fmt.Sprintf("Interface %s initialized", ifName), responseData)
+	} else {
+		h.respondSuccess(c, fmt.Sprintf("Interface %s was already initialized", ifName), responseData)
+	}
+}
+
+// handleShutdownInterface cleanly stops everything for one interface: generator jobs and listening first
+// so no frames are lost mid-read, then the send queue is drained, the send socket closed and finally the link taken down.
+// Every step runs even if an earlier one fails; the response lists each result in order.
+func (h *APIHandler) handleShutdownInterface(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	report := &stepReport{}
+
+	// 1. Stop synthetic traffic so nothing new is queued
+	if stopped := h.stopGenerators(c, ifName); stopped > 0 {
+		report.record("stop-generators", nil, fmt.Sprintf("%d generator job(s) stopped", stopped))
+	} else {
+		report.skip("stop-generators", "no generator jobs running")
+	}
+	if stopped := h.stopBridges(c, ifName); stopped > 0 {
+		report.record("stop-bridges", nil, fmt.Sprintf("%d bridge(s) removed", stopped))
+	} else {
+		report.skip("stop-bridges", "no bridges on this interface")
+	}
+
+	// 2. Stop listening
+	switch {
+	case h.messageListener == nil:
+		report.skip("stop-listening", "message listener not available")
+	case !h.messageListener.IsListening(ifName):
+		report.skip("stop-listening", "not listening")
+	default:
+		report.record("stop-listening", h.messageListener.StopListening(ifName), "")
+	}
+
+	// 3. Send whatever is still queued
+	if drained, ok := h.messageSender.CloseQueue(ifName); ok {
+		report.record("drain-send-queue", nil, fmt.Sprintf("%d queued frame(s) flushed", drained))
+	} else {
+		report.skip("drain-send-queue", "no send queue")
+	}
+
+	// 4. Close the send socket
+	if err := h.messageSender.ReleaseInterface(ifName); err != nil {
+		report.skip("close-send-socket", "interface not initialized")
+	} else {
+		report.record("close-send-socket", nil, "")
+	}
+
+	// 5. Bring the link down
+	switch {
+	case h.setupManager == nil:
+		report.skip("link-down", "setup manager not available")
+	case h.setupManager.IsSetupDisabled():
+		report.skip("link-down", "interface setup is disabled")
+	default:
+		report.record("link-down", h.setupManager.TeardownInterface(ifName), "")
+	}
+
+	responseData := map[string]interface{}{
+		"interface":  ifName,
+		"steps":      report.steps,
+		"errorCount": report.failed,
+	}
+
+	if report.failed > 0 {
+		h.respondSuccess(c, fmt.Sprintf("Interface %s shut down with errors", ifName), responseData)
+	} else {
+		h.respondSuccess(c, fmt.Sprintf("Interface %s shut down", ifName), responseData)
+	}
+}
+
+// handleInterfaceStatus returns status for a specific interface
+func (h *APIHandler) handleInterfaceStatus(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	status, err := h.monitor.GetInterfaceStatus(ifName)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Interface not found", err)
+		return
+	}
+
+	// Add listening status if message listener is available
+	if h.messageListener != nil {
+		statusMap := map[string]interface{}{
+			"interfaceStatus": status,
+			"isListening":     h.messageListener.IsListening(ifName),
+		}
+
+		// Add message statistics if available
+		if stats, err := h.messageListener.GetInterfaceStatistics(ifName); err == nil {
+			statusMap["messageStatistics"] = stats
+		}
+
+		h.respondSuccess(c, "", statusMap)
+	} else {
+		h.respondSuccess(c, "", status)
+	}
+}
+
+// handleResetInterfaceMetrics zeroes the send counters and latency history of one interface
+func (h *APIHandler) handleResetInterfaceMetrics(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	resetStartTime, _ := strconv.ParseBool(c.Query("resetStartTime"))
+	if err := h.monitor.ResetInterfaceMetrics(ifName, resetStartTime); err != nil {
+		h.respondError(c, http.StatusNotFound, "Interface not found", err)
+		return
+	}
+
+	h.logf(c, "🧹 Metrics reset for %s", ifName)
+	h.respondSuccess(c, fmt.Sprintf("Metrics reset for %s", ifName), map[string]interface{}{
+		"interface":      ifName,
+		"resetStartTime": resetStartTime,
+	})
+}
+
+// handleResetAllMetrics zeroes the send counters and latency history of every active interface
+func (h *APIHandler) handleResetAllMetrics(c *gin.Context) {
+	resetStartTime, _ := strconv.ParseBool(c.Query("resetStartTime"))
+	reset := h.monitor.ResetAllInterfaceMetrics(resetStartTime)
+
+	h.logf(c, "🧹 Metrics reset for %d interface(s)", len(reset))
+	h.respondSuccess(c, fmt.Sprintf("Metrics reset for %d interface(s)", len(reset)), map[string]interface{}{
+		"interfaces":     reset,
+		"resetStartTime": resetStartTime,
+	})
+}
+
+// recentErrorFrames is how many decoded error frames the interface detail view includes
+const recentErrorFrames = 5
+
+// handleInterfaceDetails returns one snapshot of everything known about an interface: send
+// metrics and health, OS link state, listener status and statistics, and recent errors
+func (h *APIHandler) handleInterfaceDetails(c *gin.Context) {
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
 		return
 	}
 
+	data := map[string]interface{}{
+		"interface": ifName,
+	}
+	found := false
+
+	// Send metrics, health and kernel counters
 	status, err := h.monitor.GetInterfaceStatus(ifName)
-	if err != nil {
-		h.respondError(c, http.StatusNotFound, "Interface not found", err)
+	if err == nil {
+		found = true
+		data["interfaceStatus"] = status
+	}
+
+	// OS link state
+	var state *InterfaceState
+	if h.setupManager != nil {
+		state, err = h.setupManager.GetInterfaceState(ifName)
+		if err == nil {
+			found = true
+			data["state"] = state
+		} else if !errors.Is(err, ErrInterfaceNotFound) {
+			data["stateError"] = err.Error()
+		}
+	}
+
+	if !found {
+		h.respondError(c, http.StatusNotFound, "Interface not found", fmt.Errorf("interface %s is neither configured nor present", ifName))
 		return
 	}
 
-	// Add listening status if message listener is available
+	// Listener status, buffer statistics and decoded error frames
 	if h.messageListener != nil {
-		statusMap := map[string]interface{}{
-			"interfaceStatus": status,
-			"isListening":     h.messageListener.IsListening(ifName),
-		}
-
-		// Add message statistics if available
+		data["listener"] = h.listenStatus(ifName)
 		if stats, err := h.messageListener.GetInterfaceStatistics(ifName); err == nil {
-			statusMap["messageStatistics"] = stats
+			data["messageStatistics"] = stats
+		}
+		if frames, total, err := h.messageListener.GetErrorFrames(ifName, recentErrorFrames); err == nil {
+			data["errorFrames"] = map[string]interface{}{
+				"recent": frames,
+				"total":  total,
+			}
 		}
+	}
 
-		h.respondSuccess(c, "", statusMap)
-	} else {
-		h.respondSuccess(c, "", status)
+	// Most recent send and setup errors
+	recentErrors := map[string]interface{}{}
+	if status.LastErrorMsg != "" {
+		recentErrors["send"] = map[string]interface{}{
+			"message": status.LastErrorMsg,
+			"time":    status.LastErrorTime,
+		}
 	}
+	if state != nil && state.LastError != "" {
+		recentErrors["setup"] = state.LastError
+	}
+	data["recentErrors"] = recentErrors
+
+	h.respondSuccess(c, "", data)
 }
 
 // handleHealthSummary returns system health summary
@@ -208,6 +1180,12 @@ func (h *APIHandler) handleHealthSummary(c *gin.Context) {
 	h.respondSuccess(c, "", summary)
 }
 
+// handleSummary returns aggregate totals across all interfaces
+func (h *APIHandler) handleSummary(c *gin.Context) {
+	summary := h.monitor.GetSummary()
+	h.respondSuccess(c, "", summary)
+}
+
 // handleMetrics returns detailed metrics for monitoring systems
 func (h *APIHandler) handleMetrics(c *gin.Context) {
 	status := h.monitor.GetSystemStatus()
@@ -234,6 +1212,30 @@ func (h *APIHandler) handleMetrics(c *gin.Context) {
 			"health_status":        ifStatus.Health.Status,
 			"health_checks_passed": ifStatus.Health.ChecksPassed,
 			"health_checks_failed": ifStatus.Health.ChecksFailed,
+			"queue_depth":          ifStatus.QueueDepth,
+			"queue_drops":          ifStatus.QueueDrops,
+			"tx_queue_full":        ifStatus.TxQueueFull,
+			"tx_retries":           ifStatus.TxRetries,
+			"errors_by_errno":      ifStatus.ErrorsByErrno,
+			"avg_latency_seconds":  parseLatency(ifStatus.AvgLatency),
+			"p50_latency_seconds":  parseLatency(ifStatus.P50Latency),
+			"p95_latency_seconds":  parseLatency(ifStatus.P95Latency),
+			"p99_latency_seconds":  parseLatency(ifStatus.P99Latency),
+			"max_latency_seconds":  parseLatency(ifStatus.MaxLatency),
+		}
+
+		// Kernel counters from /sys/class/net
+		if ks := ifStatus.KernelStats; ks != nil {
+			kernel := interfaceMetrics[name].(map[string]interface{})
+			kernel["rx_packets"] = ks.RxPackets
+			kernel["tx_packets"] = ks.TxPackets
+			kernel["rx_bytes"] = ks.RxBytes
+			kernel["tx_bytes"] = ks.TxBytes
+			kernel["rx_dropped"] = ks.RxDropped
+			kernel["tx_dropped"] = ks.TxDropped
+			kernel["rx_errors"] = ks.RxErrors
+			kernel["tx_errors"] = ks.TxErrors
+			kernel["can_state"] = ks.CanState
 		}
 
 		// Add message listening metrics if available
@@ -257,8 +1259,21 @@ func (h *APIHandler) handleGetSetupConfig(c *gin.Context) {
 		return
 	}
 
-	config := h.setupManager.GetSetupConfig()
-	h.respondSuccess(c, "", config)
+	h.respondSuccess(c, "", h.setupConfigResponse())
+}
+
+// SetupConfigResponse is the global setup configuration plus the effective configuration of each configured interface
+type SetupConfigResponse struct {
+	InterfaceSetupConfig
+	Interfaces map[string]InterfaceSetupConfig `json:"interfaces"`
+}
+
+// setupConfigResponse builds the setup configuration response
+func (h *APIHandler) setupConfigResponse() SetupConfigResponse {
+	return SetupConfigResponse{
+		InterfaceSetupConfig: h.setupManager.GetSetupConfig(),
+		Interfaces:           h.setupManager.GetInterfaceConfigs(h.monitor.GetConfiguredPorts()),
+	}
 }
 
 // SetupConfigRequest represents a setup configuration update request
@@ -313,7 +1328,57 @@ func (h *APIHandler) handleUpdateSetupConfig(c *gin.Context) {
 		return
 	}
 
-	h.respondSuccess(c, "Setup configuration updated successfully", config)
+	h.respondSuccess(c, "Setup configuration updated successfully", h.setupConfigResponse())
+}
+
+// handleGetSelfTest returns the report of the last self-test run
+func (h *APIHandler) handleGetSelfTest(c *gin.Context) {
+	report := h.selfTester.LastSelfTest()
+	if report == nil {
+		h.respondError(c, http.StatusNotFound, "No self-test has run yet; POST /api/selftest to run one", nil)
+		return
+	}
+	h.respondSuccess(c, selfTestMessage(report), report)
+}
+
+// handleRunSelfTest runs the self-test again and returns its report
+func (h *APIHandler) handleRunSelfTest(c *gin.Context) {
+	report := h.selfTester.SelfTest()
+	h.respondSuccess(c, selfTestMessage(&report), report)
+}
+
+// selfTestMessage summarizes a self-test report in one line
+func selfTestMessage(report *SelfTestReport) string {
+	if report.Passed {
+		return "Self-test passed"
+	}
+	return fmt.Sprintf("Self-test failed: %d of %d checks failed", report.Failed, len(report.Checks))
+}
+
+// handleExportConfig returns the effective runtime configuration as a config file document
+func (h *APIHandler) handleExportConfig(c *gin.Context) {
+	h.respondSuccess(c, "Configuration exported successfully", h.configStore.ExportConfig())
+}
+
+// handleImportConfig applies a configuration document, all or nothing
+func (h *APIHandler) handleImportConfig(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Failed to read configuration", err)
+		return
+	}
+
+	restartRequired, err := h.configStore.ImportConfig(data)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Configuration rejected; nothing was changed", err)
+		return
+	}
+	h.logf(c.Request.Context(), "📥 Configuration imported (%d change(s) need a restart)", len(restartRequired))
+
+	h.respondSuccess(c, "Configuration imported successfully", map[string]interface{}{
+		"config":          h.configStore.ExportConfig(),
+		"restartRequired": append([]string{}, restartRequired...),
+	})
 }
 
 // handleGetAvailableInterfaces returns available CAN interfaces
@@ -323,6 +1388,22 @@ func (h *APIHandler) handleGetAvailableInterfaces(c *gin.Context) {
 		return
 	}
 
+	if detailed, _ := strconv.ParseBool(c.Query("detailed")); detailed {
+		details, err := h.setupManager.GetAvailableInterfacesDetailed()
+		if err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to get available interfaces", err)
+			return
+		}
+
+		data := map[string]interface{}{
+			"interfaces": details,
+			"count":      len(details),
+		}
+
+		h.respondSuccess(c, "", data)
+		return
+	}
+
 	interfaces, err := h.setupManager.GetAvailableInterfaces()
 	if err != nil {
 		h.respondError(c, http.StatusInternalServerError, "Failed to get available interfaces", err)
@@ -352,9 +1433,8 @@ func (h *APIHandler) handleSetupInterface(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
 		return
 	}
 
@@ -364,54 +1444,79 @@ func (h *APIHandler) handleSetupInterface(c *gin.Context) {
 		req = SetupInterfaceRequest{}
 	}
 
-	// If custom parameters provided, temporarily update config
-	originalConfig := h.setupManager.GetSetupConfig()
-	if req.Bitrate != nil || req.SamplePoint != nil || req.RestartMs != nil {
-		tempConfig := originalConfig
-		if req.Bitrate != nil {
-			tempConfig.Bitrate = *req.Bitrate
-		}
-		if req.SamplePoint != nil {
-			tempConfig.SamplePoint = *req.SamplePoint
-		}
-		if req.RestartMs != nil {
-			tempConfig.RestartMs = *req.RestartMs
-		}
-
-		// Temporarily update config
-		h.setupManager.UpdateSetupConfig(tempConfig)
-		defer h.setupManager.UpdateSetupConfig(originalConfig) // Restore original
+	// Apply custom parameters on top of the interface's effective config
+	config := h.setupManager.GetInterfaceConfig(ifName)
+	if req.Bitrate != nil {
+		config.Bitrate = *req.Bitrate
+	}
+	if req.SamplePoint != nil {
+		config.SamplePoint = *req.SamplePoint
+	}
+	if req.RestartMs != nil {
+		config.RestartMs = *req.RestartMs
 	}
 
 	// Setup interface
+	var outcome SetupOutcome
 	var err error
 	withRetry := req.WithRetry != nil && *req.WithRetry
 	if withRetry {
-		err = h.setupManager.SetupInterfaceWithRetry(ifName)
+		outcome, err = h.setupManager.SetupInterfaceWithConfigAndRetry(c.Request.Context(), ifName, config)
 	} else {
-		err = h.setupManager.SetupInterface(ifName)
+		outcome, err = h.setupManager.SetupInterfaceWithConfig(c.Request.Context(), ifName, config)
 	}
 
 	if err != nil {
-		h.respondError(c, http.StatusInternalServerError, "Failed to setup interface", err)
+		h.respondError(c, setupErrorStatus(err), "Failed to setup interface", err)
 		return
 	}
 
 	// Start listening if message listener is available
 	if h.messageListener != nil {
-		if err := h.messageListener.StartListening(ifName); err != nil {
-			h.logger.Printf("Warning: failed to start listening on %s: %v", ifName, err)
+		if err := h.listenHandles.Acquire(ifName); err != nil {
+			h.logf(c, "Warning: failed to start listening on %s: %v", ifName, err)
 		}
 	}
 
 	// Get interface state
 	state, err := h.setupManager.GetInterfaceState(ifName)
 	if err != nil {
-		h.logger.Printf("Warning: could not get interface state after setup: %v", err)
+		h.logf(c, "Warning: could not get interface state after setup: %v", err)
 		state = &InterfaceState{Name: ifName}
 	}
 
-	h.respondSuccess(c, fmt.Sprintf("Interface %s setup successfully", ifName), state)
+	responseData := map[string]interface{}{
+		"state":   state,
+		"changed": outcome.Changed,
+		"reason":  outcome.Reason,
+	}
+
+	message := fmt.Sprintf("Interface %s setup successfully", ifName)
+	if !outcome.Changed {
+		message = fmt.Sprintf("Interface %s already configured", ifName)
+	}
+	if outcome.Warning != "" {
+		responseData["warning"] = outcome.Warning
+		message = fmt.Sprintf("Interface %s is up, but with a warning: %s", ifName, outcome.Warning)
+	}
+	h.respondSuccess(c, message, responseData)
+}
+
+// setupErrorStatus maps interface setup errors to HTTP status codes
+func setupErrorStatus(err error) int {
+	if errors.Is(err, ErrInvalidInterfaceName) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, ErrInterfaceNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, ErrInterfaceBusy) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, ErrSetupDisabled) || errors.Is(err, ErrPermissionDenied) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
 }
 
 // handleTeardownInterface tears down a specific CAN interface
@@ -421,21 +1526,23 @@ func (h *APIHandler) handleTeardownInterface(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
 		return
 	}
 
+	h.stopGenerators(c, ifName)
+	h.stopBridges(c, ifName)
+
 	// Stop listening if message listener is available
 	if h.messageListener != nil {
 		if err := h.messageListener.StopListening(ifName); err != nil {
-			h.logger.Printf("Warning: failed to stop listening on %s: %v", ifName, err)
+			h.logf(c, "Warning: failed to stop listening on %s: %v", ifName, err)
 		}
 	}
 
 	if err := h.setupManager.TeardownInterface(ifName); err != nil {
-		h.respondError(c, http.StatusInternalServerError, "Failed to teardown interface", err)
+		h.respondError(c, setupErrorStatus(err), "Failed to teardown interface", err)
 		return
 	}
 
@@ -447,6 +1554,38 @@ func (h *APIHandler) handleTeardownInterface(c *gin.Context) {
 	h.respondSuccess(c, fmt.Sprintf("Interface %s torn down successfully", ifName), responseData)
 }
 
+// handleCreateInterface creates a virtual CAN interface
+func (h *APIHandler) handleCreateInterface(c *gin.Context) {
+	if h.setupManager == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Setup manager not available", nil)
+		return
+	}
+
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	linkType := c.DefaultQuery("type", "vcan")
+	if linkType != "vcan" {
+		h.respondError(c, http.StatusBadRequest, fmt.Sprintf("Unsupported interface type: %s (supported: vcan)", linkType), nil)
+		return
+	}
+
+	if err := h.setupManager.CreateVirtualInterface(ifName); err != nil {
+		h.respondError(c, setupErrorStatus(err), "Failed to create interface", err)
+		return
+	}
+
+	state, err := h.setupManager.GetInterfaceState(ifName)
+	if err != nil {
+		h.logf(c, "Warning: could not get interface state after create: %v", err)
+		state = &InterfaceState{Name: ifName, Virtual: true}
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("Interface %s created successfully", ifName), state)
+}
+
 // handleResetInterface resets a specific CAN interface
 func (h *APIHandler) handleResetInterface(c *gin.Context) {
 	if h.setupManager == nil {
@@ -454,21 +1593,20 @@ func (h *APIHandler) handleResetInterface(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
 		return
 	}
 
 	if err := h.setupManager.ResetInterface(ifName); err != nil {
-		h.respondError(c, http.StatusInternalServerError, "Failed to reset interface", err)
+		h.respondError(c, setupErrorStatus(err), "Failed to reset interface", err)
 		return
 	}
 
 	// Get interface state after reset
 	state, err := h.setupManager.GetInterfaceState(ifName)
 	if err != nil {
-		h.logger.Printf("Warning: could not get interface state after reset: %v", err)
+		h.logf(c, "Warning: could not get interface state after reset: %v", err)
 		state = &InterfaceState{Name: ifName}
 	}
 
@@ -482,21 +1620,56 @@ func (h *APIHandler) handleGetInterfaceState(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
 		return
 	}
 
 	state, err := h.setupManager.GetInterfaceState(ifName)
 	if err != nil {
-		h.respondError(c, http.StatusNotFound, "Failed to get interface state", err)
+		if errors.Is(err, ErrInterfaceNotFound) {
+			h.respondError(c, http.StatusNotFound, "Interface not found", err)
+			return
+		}
+		h.respondError(c, http.StatusInternalServerError, "Failed to get interface state", err)
 		return
 	}
 
 	h.respondSuccess(c, "", state)
 }
 
+// handleGetAppliedSetup returns the configuration last applied to an interface, the command
+// that applied it and how the live state has drifted from it since
+func (h *APIHandler) handleGetAppliedSetup(c *gin.Context) {
+	if h.setupManager == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Setup manager not available", nil)
+		return
+	}
+
+	ifName, ok := h.interfaceParam(c, "name")
+	if !ok {
+		return
+	}
+
+	applied, ok := h.setupManager.GetAppliedSetup(ifName)
+	if !ok {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("No setup has been applied to %s by this service", ifName), nil)
+		return
+	}
+
+	data := map[string]interface{}{
+		"applied": applied,
+	}
+	if state, err := h.setupManager.GetInterfaceState(ifName); err == nil {
+		data["state"] = state
+		data["drift"] = setupDrift(applied, state)
+	} else {
+		data["stateError"] = err.Error()
+	}
+
+	h.respondSuccess(c, "", data)
+}
+
 // SetupAllInterfacesRequest represents a request to setup all interfaces
 type SetupAllInterfacesRequest struct {
 	Interfaces []string `json:"interfaces,omitempty"` // If empty, use configured interfaces
@@ -504,6 +1677,61 @@ type SetupAllInterfacesRequest struct {
 	Parallel   *bool    `json:"parallel,omitempty"`
 }
 
+// maxSetupWorkers bounds concurrent interface setups in parallel setup-all requests
+const maxSetupWorkers = 4
+
+// setupInterfaceForBatch sets up one interface for setup-all and builds its result entry.
+// A panic during setup is recovered and reported as that interface's error.
+func (h *APIHandler) setupInterfaceForBatch(ctx context.Context, ifName string, withRetry bool) (result map[string]interface{}, outcome SetupOutcome, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.logf(ctx, "❌ Panic while setting up %s: %v", ifName, r)
+			err = fmt.Errorf("panic during setup: %v", r)
+			result = map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			}
+		}
+	}()
+
+	if withRetry {
+		outcome, err = h.setupManager.SetupInterfaceWithRetry(ctx, ifName)
+	} else {
+		outcome, err = h.setupManager.SetupInterface(ctx, ifName)
+	}
+
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		}, outcome, err
+	}
+
+	// Start listening if message listener is available
+	if h.messageListener != nil {
+		if err := h.listenHandles.Acquire(ifName); err != nil {
+			h.logf(ctx, "Warning: failed to start listening on %s: %v", ifName, err)
+		}
+	}
+
+	result = map[string]interface{}{
+		"success": true,
+		"changed": outcome.Changed,
+		"reason":  outcome.Reason,
+	}
+	if outcome.Warning != "" {
+		result["warning"] = outcome.Warning
+	}
+
+	// Get interface state
+	if state, err := h.setupManager.GetInterfaceState(ifName); err == nil {
+		result["state"] = state
+	} else if outcome.Warning == "" {
+		result["warning"] = "could not get state after setup"
+	}
+	return result, outcome, nil
+}
+
 // handleSetupAllInterfaces sets up all or specified interfaces
 func (h *APIHandler) handleSetupAllInterfaces(c *gin.Context) {
 	if h.setupManager == nil {
@@ -528,43 +1756,52 @@ func (h *APIHandler) handleSetupAllInterfaces(c *gin.Context) {
 	}
 
 	withRetry := req.WithRetry != nil && *req.WithRetry
+	parallel := req.Parallel != nil && *req.Parallel
 	results := make(map[string]interface{})
 	var setupErrors []string
+	var resultsMutex sync.Mutex
 
-	for _, ifName := range interfaces {
-		var err error
-		if withRetry {
-			err = h.setupManager.SetupInterfaceWithRetry(ifName)
-		} else {
-			err = h.setupManager.SetupInterface(ifName)
-		}
+	changedCount := 0
+
+	setupOne := func(ifName string) {
+		result, outcome, err := h.setupInterfaceForBatch(c.Request.Context(), ifName, withRetry)
 
+		resultsMutex.Lock()
+		defer resultsMutex.Unlock()
+		results[ifName] = result
 		if err != nil {
 			setupErrors = append(setupErrors, fmt.Sprintf("%s: %v", ifName, err))
-			results[ifName] = map[string]interface{}{
-				"success": false,
-				"error":   err.Error(),
-			}
-		} else {
-			// Start listening if message listener is available
-			if h.messageListener != nil {
-				if err := h.messageListener.StartListening(ifName); err != nil {
-					h.logger.Printf("Warning: failed to start listening on %s: %v", ifName, err)
-				}
-			}
+		} else if outcome.Changed {
+			changedCount++
+		}
+	}
 
-			// Get interface state
-			if state, err := h.setupManager.GetInterfaceState(ifName); err == nil {
-				results[ifName] = map[string]interface{}{
-					"success": true,
-					"state":   state,
-				}
-			} else {
-				results[ifName] = map[string]interface{}{
-					"success": true,
-					"warning": "could not get state after setup",
+	if parallel {
+		jobs := make(chan string)
+		var wg sync.WaitGroup
+
+		workers := min(maxSetupWorkers, len(interfaces))
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ifName := range jobs {
+					setupOne(ifName)
 				}
-			}
+			}()
+		}
+
+		for _, ifName := range interfaces {
+			jobs <- ifName
+		}
+		close(jobs)
+		wg.Wait()
+
+		// Workers finish in any order; keep the error list stable for clients
+		sort.Strings(setupErrors)
+	} else {
+		for _, ifName := range interfaces {
+			setupOne(ifName)
 		}
 	}
 
@@ -573,6 +1810,7 @@ func (h *APIHandler) handleSetupAllInterfaces(c *gin.Context) {
 		"totalCount":   len(interfaces),
 		"successCount": len(interfaces) - len(setupErrors),
 		"errorCount":   len(setupErrors),
+		"changedCount": changedCount,
 	}
 
 	if len(setupErrors) > 0 {
@@ -594,111 +1832,233 @@ func (h *APIHandler) handleTeardownAllInterfaces(c *gin.Context) {
 	status := h.monitor.GetSystemStatus()
 	interfaces := status.ConfiguredPorts
 
-	results := make(map[string]interface{})
-	var teardownErrors []string
+	results := make(map[string]interface{})
+	var teardownErrors []string
+
+	for _, ifName := range interfaces {
+		h.stopGenerators(c, ifName)
+		h.stopBridges(c, ifName)
+
+		// Stop listening if message listener is available
+		if h.messageListener != nil {
+			if err := h.messageListener.StopListening(ifName); err != nil {
+				h.logf(c, "Warning: failed to stop listening on %s: %v", ifName, err)
+			}
+		}
+
+		if err := h.setupManager.TeardownInterface(ifName); err != nil {
+			teardownErrors = append(teardownErrors, fmt.Sprintf("%s: %v", ifName, err))
+			results[ifName] = map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			}
+		} else {
+			results[ifName] = map[string]interface{}{
+				"success": true,
+				"status":  "torn_down",
+			}
+		}
+	}
+
+	responseData := map[string]interface{}{
+		"results":      results,
+		"totalCount":   len(interfaces),
+		"successCount": len(interfaces) - len(teardownErrors),
+		"errorCount":   len(teardownErrors),
+	}
+
+	if len(teardownErrors) > 0 {
+		responseData["errors"] = teardownErrors
+		h.respondSuccess(c, "Partial teardown completed with errors", responseData)
+	} else {
+		h.respondSuccess(c, "All interfaces torn down successfully", responseData)
+	}
+}
+
+// ====== Message Listening Handlers (New) ======
+
+// handleGetMessages returns all messages for a specific interface
+func (h *APIHandler) handleGetMessages(c *gin.Context) {
+	if h.messageListener == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
+		return
+	}
+
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
+		return
+	}
+
+	opts, err := parseQueryOptions(c)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid message query", err)
+		return
+	}
+
+	result, err := h.messageListener.QueryMessages(ifName, opts)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to get messages", err)
+		return
+	}
+
+	nextOffset := opts.Offset + len(result.Messages)
+	data := map[string]interface{}{
+		"interface":    ifName,
+		"messages":     result.Messages,
+		"count":        len(result.Messages),
+		"matchedCount": result.Matched,
+		"totalCount":   result.Total,
+		"offset":       opts.Offset,
+		"limit":        opts.Limit,
+		"nextOffset":   nextOffset,
+		"hasMore":      nextOffset < result.Matched,
+		"isListening":  h.messageListener.IsListening(ifName),
+	}
+	if n := len(result.Messages); n > 0 {
+		data["lastSeq"] = result.Messages[n-1].Seq
+	}
+
+	h.respondSuccess(c, "", data)
+}
+
+// Message paging defaults
+const (
+	defaultMessagePageLimit = 100
+	maxMessagePageLimit     = 1000
+)
+
+// parseQueryOptions builds message filters and paging from the id, idMin, idMax, include,
+// exclude, frameType, since, until, afterSeq, offset and limit query parameters
+func parseQueryOptions(c *gin.Context) (QueryOptions, error) {
+	opts := QueryOptions{Limit: defaultMessagePageLimit}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return opts, fmt.Errorf("invalid limit %q: must be a positive integer", limitStr)
+		}
+		if limit > maxMessagePageLimit {
+			limit = maxMessagePageLimit
+		}
+		opts.Limit = limit
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("invalid offset %q: must be a non-negative integer", offsetStr)
+		}
+		opts.Offset = offset
+	}
 
-	for _, ifName := range interfaces {
-		// Stop listening if message listener is available
-		if h.messageListener != nil {
-			if err := h.messageListener.StopListening(ifName); err != nil {
-				h.logger.Printf("Warning: failed to stop listening on %s: %v", ifName, err)
-			}
+	if afterSeqStr := c.Query("afterSeq"); afterSeqStr != "" {
+		afterSeq, err := strconv.ParseUint(afterSeqStr, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid afterSeq %q: %w", afterSeqStr, err)
 		}
+		opts.AfterSeq = afterSeq
+	}
 
-		if err := h.setupManager.TeardownInterface(ifName); err != nil {
-			teardownErrors = append(teardownErrors, fmt.Sprintf("%s: %v", ifName, err))
-			results[ifName] = map[string]interface{}{
-				"success": false,
-				"error":   err.Error(),
-			}
-		} else {
-			results[ifName] = map[string]interface{}{
-				"success": true,
-				"status":  "torn_down",
-			}
+	if idStr := c.Query("id"); idStr != "" {
+		id, err := parseCanID(idStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid id %q: %w", idStr, err)
 		}
+		opts.ID = &id
 	}
 
-	responseData := map[string]interface{}{
-		"results":      results,
-		"totalCount":   len(interfaces),
-		"successCount": len(interfaces) - len(teardownErrors),
-		"errorCount":   len(teardownErrors),
+	if idMinStr := c.Query("idMin"); idMinStr != "" {
+		idMin, err := parseHexID(idMinStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid idMin %q: %w", idMinStr, err)
+		}
+		opts.IDMin = &idMin
 	}
 
-	if len(teardownErrors) > 0 {
-		responseData["errors"] = teardownErrors
-		h.respondSuccess(c, "Partial teardown completed with errors", responseData)
-	} else {
-		h.respondSuccess(c, "All interfaces torn down successfully", responseData)
+	if idMaxStr := c.Query("idMax"); idMaxStr != "" {
+		idMax, err := parseHexID(idMaxStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid idMax %q: %w", idMaxStr, err)
+		}
+		opts.IDMax = &idMax
 	}
-}
 
-// ====== Message Listening Handlers (New) ======
+	if opts.IDMin != nil && opts.IDMax != nil && *opts.IDMin > *opts.IDMax {
+		return opts, fmt.Errorf("idMin 0x%X is greater than idMax 0x%X", *opts.IDMin, *opts.IDMax)
+	}
 
-// 判断用户传入的 hex string 是否匹配数据中的 id
-func MatchID(userHex string, id uint32) bool {
-	var parsedID uint64
-	var err error
+	filter, err := parseFilterSpec(c)
+	if err != nil {
+		return opts, err
+	}
+	opts.Filter = filter
 
-	// 如果用户传入的 hex 字符串以 "0x" 开头，先去掉前缀并转换为小写
-	if strings.HasPrefix(userHex, "0x") {
-		userHex = strings.TrimPrefix(strings.ToLower(userHex), "0x")
-		parsedID, err = strconv.ParseUint(userHex, 16, 32)
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
 		if err != nil {
-			fmt.Println("❌ 无法解析 hex 参数:", err)
-			return false
+			return opts, fmt.Errorf("invalid since %q: %w", sinceStr, err)
 		}
+		opts.Since = since
+	}
 
-	} else {
-		// 如果没有 "0x" 前缀，直接尝试解析为十进制
-		parsedID, err = strconv.ParseUint(userHex, 10, 32)
+	if untilStr := c.Query("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
 		if err != nil {
-			fmt.Println("❌ 无法解析十进制参数:", err)
-			return false
+			return opts, fmt.Errorf("invalid until %q: %w", untilStr, err)
 		}
+		opts.Until = until
 	}
-	return uint32(parsedID) == id
+
+	return opts, nil
 }
 
-// handleGetMessages returns all messages for a specific interface
-func (h *APIHandler) handleGetMessages(c *gin.Context) {
-	if h.messageListener == nil {
-		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
-		return
-	}
+// parseFilterSpec builds a FilterSpec from the include and exclude lists of IDs and ID ranges
+// (e.g. "0x100,0x200-0x2FF") and frameType ("standard" or "extended"). It returns nil when
+// none is given.
+func parseFilterSpec(c *gin.Context) (*FilterSpec, error) {
+	var spec FilterSpec
+	var err error
 
-	ifName := c.Param("interface")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
-		return
+	if spec.Include, err = parseIDRanges(c.Query("include")); err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+	if spec.Exclude, err = parseIDRanges(c.Query("exclude")); err != nil {
+		return nil, fmt.Errorf("invalid exclude: %w", err)
 	}
 
-	messages, err := h.messageListener.GetMessages(ifName)
-	if err != nil {
-		h.respondError(c, http.StatusNotFound, "Failed to get messages", err)
-		return
+	switch frameType := c.Query("frameType"); frameType {
+	case "":
+	case "standard":
+		spec.StandardOnly = true
+	case "extended":
+		spec.ExtendedOnly = true
+	default:
+		return nil, fmt.Errorf("invalid frameType %q: must be standard or extended", frameType)
 	}
 
-	userId := c.Query("id")
-	if userId != "" {
-		var filteredMessages []CanMessageLog
-		for _, msg := range messages {
-			if MatchID(userId, msg.ID) {
-				filteredMessages = append(filteredMessages, msg)
-			}
-		}
-		messages = filteredMessages
+	if err := spec.Validate(); err != nil {
+		return nil, err
 	}
+	if spec.IsEmpty() {
+		return nil, nil
+	}
+	return &spec, nil
+}
 
-	data := map[string]interface{}{
-		"interface":   ifName,
-		"messages":    messages,
-		"count":       len(messages),
-		"isListening": h.messageListener.IsListening(ifName),
+// parseCanID parses an ID as hex with a "0x" prefix or decimal otherwise
+func parseCanID(s string) (uint32, error) {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "0x") {
+		return parseHexID(lower)
 	}
+	id, err := strconv.ParseUint(s, 10, 32)
+	return uint32(id), err
+}
 
-	h.respondSuccess(c, "", data)
+// parseHexID parses a hexadecimal ID with or without a "0x" prefix
+func parseHexID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 32)
+	return uint32(id), err
 }
 
 // handleGetRecentMessages returns recent messages for a specific interface
@@ -708,9 +2068,8 @@ func (h *APIHandler) handleGetRecentMessages(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
 		return
 	}
 
@@ -738,6 +2097,70 @@ func (h *APIHandler) handleGetRecentMessages(c *gin.Context) {
 	h.respondSuccess(c, "", data)
 }
 
+// handleGetErrorFrames returns decoded CAN error frames received on an interface
+func (h *APIHandler) handleGetErrorFrames(c *gin.Context) {
+	if h.messageListener == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
+		return
+	}
+
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
+		return
+	}
+
+	// Get limit parameter (default: every error frame kept)
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 0 {
+			h.respondError(c, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = parsed
+	}
+
+	errorFrames, total, err := h.messageListener.GetErrorFrames(ifName, limit)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to get error frames", err)
+		return
+	}
+
+	h.respondSuccess(c, "", map[string]interface{}{
+		"interface":   ifName,
+		"errors":      errorFrames,
+		"count":       len(errorFrames),
+		"totalErrors": total,
+		"enabled":     h.messageListener.GetErrorMask() != 0,
+	})
+}
+
+// handleGetIDCounts returns how often each CAN ID has been seen on an interface
+func (h *APIHandler) handleGetIDCounts(c *gin.Context) {
+	if h.messageListener == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
+		return
+	}
+
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
+		return
+	}
+
+	ids, untracked, err := h.messageListener.GetIDCounts(ifName)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to get ID counts", err)
+		return
+	}
+
+	h.respondSuccess(c, "", map[string]interface{}{
+		"interface":       ifName,
+		"ids":             ids,
+		"count":           len(ids),
+		"untrackedFrames": untracked,
+	})
+}
+
 // handleGetMessageStatistics returns message statistics for a specific interface
 func (h *APIHandler) handleGetMessageStatistics(c *gin.Context) {
 	if h.messageListener == nil {
@@ -745,9 +2168,8 @@ func (h *APIHandler) handleGetMessageStatistics(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
 		return
 	}
 
@@ -762,16 +2184,36 @@ func (h *APIHandler) handleGetMessageStatistics(c *gin.Context) {
 	h.respondSuccess(c, "", stats)
 }
 
-// handleClearMessages clears message buffer for a specific interface
+// handleClearMessages clears message buffer for a specific interface, or only the frames with ?id=
 func (h *APIHandler) handleClearMessages(c *gin.Context) {
 	if h.messageListener == nil {
 		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
 		return
 	}
 
-	ifName := c.Param("interface")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
+		return
+	}
+
+	if idStr := c.Query("id"); idStr != "" {
+		id, err := parseCanID(idStr)
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid id", fmt.Errorf("invalid id %q: %w", idStr, err))
+			return
+		}
+		removed, err := h.messageListener.ClearMessagesByID(ifName, id)
+		if err != nil {
+			h.respondError(c, http.StatusNotFound, "Failed to clear messages", err)
+			return
+		}
+		h.respondSuccess(c, fmt.Sprintf("Removed %d message(s) with ID 0x%X from %s", removed, id, ifName), map[string]interface{}{
+			"interface": ifName,
+			"status":    "cleared",
+			"id":        id,
+			"hex_id":    fmt.Sprintf("%08x", id),
+			"removed":   removed,
+		})
 		return
 	}
 
@@ -788,6 +2230,42 @@ func (h *APIHandler) handleClearMessages(c *gin.Context) {
 	h.respondSuccess(c, fmt.Sprintf("Message buffer cleared for %s", ifName), data)
 }
 
+// MessageLoggingRequest turns buffering of received frames on or off
+type MessageLoggingRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// handleSetMessageLogging enables or disables buffering of received frames for an interface
+func (h *APIHandler) handleSetMessageLogging(c *gin.Context) {
+	if h.messageListener == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
+		return
+	}
+
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
+		return
+	}
+
+	var req MessageLoggingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid logging request", err)
+		return
+	}
+
+	h.messageListener.SetLogging(ifName, *req.Enabled)
+
+	data := map[string]interface{}{
+		"interface": ifName,
+		"logging":   *req.Enabled,
+	}
+	if *req.Enabled {
+		h.respondSuccess(c, fmt.Sprintf("Message logging enabled for %s", ifName), data)
+		return
+	}
+	h.respondSuccess(c, fmt.Sprintf("Message logging disabled for %s", ifName), data)
+}
+
 // handleGetAllMessages returns messages for all interfaces
 func (h *APIHandler) handleGetAllMessages(c *gin.Context) {
 	if h.messageListener == nil {
@@ -806,6 +2284,52 @@ func (h *APIHandler) handleGetAllMessages(c *gin.Context) {
 	h.respondSuccess(c, "", data)
 }
 
+// handleSearchMessages looks for messages matching an ID filter on every interface, for when
+// it is not known which bus a device is on. It takes the same parameters as handleGetMessages;
+// limit and offset apply per interface.
+func (h *APIHandler) handleSearchMessages(c *gin.Context) {
+	if h.messageListener == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
+		return
+	}
+
+	opts, err := parseQueryOptions(c)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid message query", err)
+		return
+	}
+	if opts.ID == nil && opts.IDMin == nil && opts.IDMax == nil && (opts.Filter == nil || len(opts.Filter.Include) == 0) {
+		h.respondError(c, http.StatusBadRequest, "Invalid message search", fmt.Errorf("id, idMin, idMax or include is required"))
+		return
+	}
+
+	results := h.messageListener.SearchMessages(opts)
+
+	interfaces := make(map[string]interface{}, len(results))
+	totalMatched := 0
+	for ifName, result := range results {
+		nextOffset := opts.Offset + len(result.Messages)
+		interfaces[ifName] = map[string]interface{}{
+			"messages":     result.Messages,
+			"count":        len(result.Messages),
+			"matchedCount": result.Matched,
+			"nextOffset":   nextOffset,
+			"hasMore":      nextOffset < result.Matched,
+		}
+		totalMatched += result.Matched
+	}
+
+	data := map[string]interface{}{
+		"interfaces":     interfaces,
+		"interfaceCount": len(interfaces),
+		"matchedCount":   totalMatched,
+		"offset":         opts.Offset,
+		"limit":          opts.Limit,
+	}
+
+	h.respondSuccess(c, "", data)
+}
+
 // handleGetAllMessageStatistics returns message statistics for all interfaces
 func (h *APIHandler) handleGetAllMessageStatistics(c *gin.Context) {
 	if h.messageListener == nil {
@@ -848,11 +2372,15 @@ func (h *APIHandler) handleGetAllListenStatus(c *gin.Context) {
 
 	listeningInterfaces := h.messageListener.GetListeningInterfaces()
 	allStats := h.messageListener.GetStatistics()
+	running, maxListeners := h.messageListener.GetListenerCount()
 
 	data := map[string]interface{}{
 		"listeningInterfaces": listeningInterfaces,
 		"listeningCount":      len(listeningInterfaces),
+		"runningListeners":    running,
+		"maxListeners":        maxListeners,
 		"allStatistics":       allStats,
+		"subscriptions":       h.messageListener.GetSubscriptions(),
 	}
 
 	h.respondSuccess(c, "", data)
@@ -860,31 +2388,142 @@ func (h *APIHandler) handleGetAllListenStatus(c *gin.Context) {
 
 // ====== Helper methods for consistent response formatting ======
 
-// respondSuccess sends a successful JSON response
+// interfaceParam reads an interface name path parameter, responding 400 if it is not a valid name
+func (h *APIHandler) interfaceParam(c *gin.Context, key string) (string, bool) {
+	ifName := c.Param(key)
+	if err := validateInterfaceName(ifName); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid interface name", err)
+		return "", false
+	}
+	return ifName, true
+}
+
+// respondSuccess sends a successful response
 func (h *APIHandler) respondSuccess(c *gin.Context, message string, data interface{}) {
 	response := ApiResponse{
-		Status: "success",
-		Data:   data,
+		Status:    "success",
+		Data:      data,
+		RequestID: requestIDFrom(c),
+		DryRun:    c.GetBool(dryRunKey),
 	}
 	if message != "" {
 		response.Message = message
 	}
-	c.JSON(http.StatusOK, response)
+	respond(c, http.StatusOK, response)
 }
 
-// respondError sends an error JSON response
+// respondError sends an error response
 func (h *APIHandler) respondError(c *gin.Context, statusCode int, message string, err error) {
 	response := ApiResponse{
-		Status: "error",
-		Error:  message,
+		Status:    "error",
+		Error:     message,
+		RequestID: requestIDFrom(c),
+		DryRun:    c.GetBool(dryRunKey),
 	}
 
 	if err != nil {
 		response.Error = message + ": " + err.Error()
-		h.logger.Printf("API Error: %s - %v", message, err)
+		h.logf(c, "API Error: %s - %v", message, err)
+		if details := h.commandDetails(c, err); details != nil {
+			response.Data = details
+		} else if details := sendErrorDetails(err); details != nil {
+			response.Data = details
+		}
 	}
 
-	c.JSON(statusCode, response)
+	respond(c, statusCode, response)
+}
+
+// msgpackHandle encodes responses with the current MessagePack spec: byte slices as bin and
+// times as the standard timestamp extension. Field names follow the json tags.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// respond writes the response envelope as MessagePack when the client's Accept header
+// prefers it, and as JSON otherwise
+func respond(c *gin.Context, statusCode int, response ApiResponse) {
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK) {
+	case binding.MIMEMSGPACK2, binding.MIMEMSGPACK:
+		var body []byte
+		if err := codec.NewEncoderBytes(&body, msgpackHandle).Encode(response); err == nil {
+			c.Data(statusCode, binding.MIMEMSGPACK2, body)
+			return
+		}
+		c.JSON(statusCode, response) // Not representable in MessagePack; JSON still is
+	default:
+		c.JSON(statusCode, response)
+	}
+}
+
+// commandDetails returns the failed command behind err and its raw output, but only
+// when the client asks with ?verbose=true or the service runs with -debug
+func (h *APIHandler) commandDetails(c *gin.Context, err error) map[string]interface{} {
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return nil
+	}
+	if verbose, _ := strconv.ParseBool(c.Query("verbose")); !verbose && !h.debug {
+		return nil
+	}
+	return map[string]interface{}{
+		"command": cmdErr.Command,
+		"output":  cmdErr.Output,
+	}
+}
+
+// sendErrnoHints tell a client what to do about the errno of a failed send
+var sendErrnoHints = map[unix.Errno]string{
+	unix.ENETDOWN: "the interface is down; bring it up (POST /api/setup/interfaces/:name) instead of retrying",
+	unix.ENXIO:    "the interface no longer exists on this host",
+	unix.ENODEV:   "the interface no longer exists on this host",
+	unix.ENOBUFS:  "the interface TX queue is full; retry after a short backoff",
+	unix.EAGAIN:   "the interface TX queue is full; retry after a short backoff",
+	unix.EMSGSIZE: "the frame is larger than the interface MTU allows",
+	unix.EINVAL:   "the kernel rejected the frame as invalid",
+	unix.EPERM:    "the service is not allowed to send on this interface",
+	unix.EACCES:   "the service is not allowed to send on this interface",
+}
+
+// sendErrorStatus maps a failed send to an HTTP status by its errno: conditions of the interface
+// that can be fixed or waited out are 503, frames the interface cannot carry are 400
+func sendErrorStatus(err error) int {
+	var errno unix.Errno
+	if !errors.As(err, &errno) {
+		return http.StatusInternalServerError
+	}
+	switch errno {
+	case unix.ENETDOWN, unix.ENXIO, unix.ENODEV, unix.ENOBUFS, unix.EAGAIN:
+		return http.StatusServiceUnavailable
+	case unix.EMSGSIZE, unix.EINVAL:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// sendErrorDetails returns the errno behind a failed send, with a hint for known errnos
+func sendErrorDetails(err error) map[string]interface{} {
+	var errno unix.Errno
+	if !errors.As(err, &errno) {
+		return nil
+	}
+	details := map[string]interface{}{
+		"errno":     errnoName(errno),
+		"errnoCode": int(errno),
+	}
+	if hint, ok := sendErrnoHints[errno]; ok {
+		details["hint"] = hint
+	}
+	return details
+}
+
+// dryRunKey is the gin context key that flags responses of setup endpoints in dry-run mode
+const dryRunKey = "dryRun"
+
+// markDryRun flags the response when interface setup only logs its commands (-dry-run)
+func (h *APIHandler) markDryRun(c *gin.Context) {
+	if h.setupManager != nil && h.setupManager.IsDryRun() {
+		c.Set(dryRunKey, true)
+	}
+	c.Next()
 }
 
 // parseSuccessRate converts success rate string to float
@@ -897,14 +2536,88 @@ func parseSuccessRate(rateStr string) float64 {
 	return 0.0
 }
 
+// parseLatency converts a duration string from InterfaceStatus to seconds
+func parseLatency(latencyStr string) float64 {
+	if d, err := time.ParseDuration(latencyStr); err == nil {
+		return d.Seconds()
+	}
+	return 0.0
+}
+
 // ====== Middleware functions ======
 
+// Request correlation: the ID is taken from or generated for X-Request-ID
+const (
+	requestIDHeader    = "X-Request-ID"
+	requestIDKey       = "requestID" // gin context key
+	maxRequestIDLength = 128
+)
+
+// requestIDContextKey stores the request ID in the request's context.Context
+type requestIDContextKey struct{}
+
+// RequestIDMiddleware honors a client-supplied X-Request-ID or generates one, stores it
+// for handlers and logging, and echoes it in the response header
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDContextKey{}, id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII, so they can be logged as-is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 16-character hex ID
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// requestIDFrom returns the request ID carried by a gin or request context, if any
+func requestIDFrom(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c.GetString(requestIDKey)
+	}
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// logf logs on behalf of a request, prefixing the line with its request ID
+func (h *APIHandler) logf(ctx context.Context, format string, v ...interface{}) {
+	if id := requestIDFrom(ctx); id != "" {
+		h.logger.Printf("[%s] "+format, append([]interface{}{id}, v...)...)
+		return
+	}
+	h.logger.Printf(format, v...)
+}
+
 // LoggingMiddleware provides request logging
 func LoggingMiddleware(logger Logger) gin.HandlerFunc {
 	return gin.LoggerWithConfig(gin.LoggerConfig{
-		SkipPaths: []string{"/api/status", "/api/health"}, // Skip status check logging
+		SkipPaths: []string{"/api/status", "/api/health", "/api/" + APIVersionV1 + "/status", "/api/" + APIVersionV1 + "/health"}, // Skip status check logging
 		Formatter: func(param gin.LogFormatterParams) string {
-			return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
+			return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\" request_id=%v\n",
 				param.ClientIP,
 				param.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"),
 				param.Method,
@@ -914,6 +2627,7 @@ func LoggingMiddleware(logger Logger) gin.HandlerFunc {
 				param.Latency,
 				param.Request.UserAgent(),
 				param.ErrorMessage,
+				param.Keys[requestIDKey],
 			)
 		},
 	})
@@ -924,7 +2638,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
+		c.Header("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token, "+requestIDHeader)
+		c.Header("Access-Control-Expose-Headers", requestIDHeader+", "+apiVersionHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -935,34 +2650,79 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// BodyLimitMiddleware rejects request bodies larger than maxBytes (0 disables the limit)
+func BodyLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ApiResponse{
+				Status:    "error",
+				Error:     fmt.Sprintf("Request body exceeds %d bytes", maxBytes),
+				RequestID: requestIDFrom(c),
+			})
+			return
+		}
+
+		// Bodies without a declared length are cut off while reading
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
 // RecoveryMiddleware provides panic recovery
 func RecoveryMiddleware(logger Logger) gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		logger.Printf("Panic recovered: %v", recovered)
-		c.JSON(http.StatusInternalServerError, ApiResponse{
-			Status: "error",
-			Error:  "Internal server error",
+		logger.Printf("[%s] Panic recovered: %v", requestIDFrom(c), recovered)
+		respond(c, http.StatusInternalServerError, ApiResponse{
+			Status:    "error",
+			Error:     "Internal server error",
+			RequestID: requestIDFrom(c),
 		})
 	})
 }
 
-// handleStopListening stops message listening on a specific interface
+// handleStopListening releases the API's hold on the listener of an interface. Listening only
+// stops once no other holder, such as a bridge, needs it.
 func (h *APIHandler) handleStopListening(c *gin.Context) {
 	if h.messageListener == nil {
 		h.respondError(c, http.StatusServiceUnavailable, "Message listener not available", nil)
 		return
 	}
 
-	ifName := c.Param("interface")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
 		return
 	}
 
-	if err := h.messageListener.StopListening(ifName); err != nil {
+	released, err := h.listenHandles.Release(ifName)
+	if err != nil {
 		h.respondError(c, http.StatusInternalServerError, "Failed to stop listening", err)
 		return
 	}
+	holders := h.messageListener.GetListenerHolders(ifName)
+	if !released && holders == 0 {
+		// Nobody holds it, so there is no one to cut off
+		if err := h.messageListener.StopListening(ifName); err != nil {
+			h.respondError(c, http.StatusInternalServerError, "Failed to stop listening", err)
+			return
+		}
+	}
+
+	// Other holders keep the listener running
+	if holders > 0 && h.messageListener.IsListening(ifName) {
+		h.respondSuccess(c, fmt.Sprintf("Listening on %s continues for %d other holder(s)", ifName, holders), map[string]interface{}{
+			"interface":   ifName,
+			"status":      "held",
+			"isListening": true,
+			"holders":     holders,
+			"released":    released,
+		})
+		return
+	}
 
 	data := map[string]interface{}{
 		"interface":   ifName,
@@ -980,23 +2740,48 @@ func (h *APIHandler) handleGetListenStatus(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
 		return
 	}
 
+	h.respondSuccess(c, "", h.listenStatus(ifName))
+}
+
+// listenStatus describes the listener of one interface, with statistics while it is listening
+func (h *APIHandler) listenStatus(ifName string) map[string]interface{} {
 	isListening := h.messageListener.IsListening(ifName)
 
+	// A running listener only receives while it is bound and the interface is up
+	status := "not_listening"
+	if isListening {
+		status = "listening"
+	}
+	interfaceUp := true
+	if h.setupManager != nil {
+		if state, err := h.setupManager.GetInterfaceState(ifName); err == nil {
+			interfaceUp = state.IsUp
+		}
+	}
+	if h.messageListener.IsWaitingForInterface(ifName) {
+		status = "waiting_for_interface"
+	} else if isListening && !interfaceUp {
+		status = "bound_interface_down"
+	}
+
 	data := map[string]interface{}{
 		"interface":   ifName,
 		"isListening": isListening,
-		"status": func() string {
-			if isListening {
-				return "listening"
-			}
-			return "not_listening"
-		}(),
+		"interfaceUp": interfaceUp,
+		"holders":     h.messageListener.GetListenerHolders(ifName),
+		"logging":     h.messageListener.IsLogging(ifName),
+		"status":      status,
+	}
+
+	// Kept after the listener stops, since a read error is often why it did
+	if lastError, lastErrorTime := h.messageListener.GetLastError(ifName); lastError != "" {
+		data["lastError"] = lastError
+		data["lastErrorTime"] = lastErrorTime
 	}
 
 	// Add statistics if listening
@@ -1004,9 +2789,12 @@ func (h *APIHandler) handleGetListenStatus(c *gin.Context) {
 		if stats, err := h.messageListener.GetInterfaceStatistics(ifName); err == nil {
 			data["statistics"] = stats
 		}
+		if rxBufferBytes, ok := h.messageListener.GetReceiveBufferSize(ifName); ok {
+			data["rxBufferBytes"] = rxBufferBytes
+		}
 	}
 
-	h.respondSuccess(c, "", data)
+	return data
 }
 
 // handleStartListening starts message listening on a specific interface
@@ -1016,22 +2804,39 @@ func (h *APIHandler) handleStartListening(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+	ifName, ok := h.interfaceParam(c, "interface")
+	if !ok {
 		return
 	}
 
-	if err := h.messageListener.StartListening(ifName); err != nil {
+	// Messages captured before a previous stop are kept unless clear=true
+	clearBuffer := c.Query("clear") == "true"
+	if clearBuffer {
+		if err := h.messageListener.ClearMessages(ifName); err != nil {
+			h.logf(c, "Warning: no buffer to clear for %s: %v", ifName, err)
+		}
+	}
+
+	if err := h.listenHandles.Acquire(ifName); err != nil {
+		if errors.Is(err, ErrListenerLimit) {
+			h.respondError(c, http.StatusTooManyRequests, "Listener limit reached", err)
+			return
+		}
 		h.respondError(c, http.StatusInternalServerError, "Failed to start listening", err)
 		return
 	}
 
+	status, message := "listening", fmt.Sprintf("Started listening on %s", ifName)
+	if h.messageListener.IsWaitingForInterface(ifName) {
+		status, message = "waiting_for_interface", fmt.Sprintf("%s is down; listening will start once it comes up", ifName)
+	}
+
 	data := map[string]interface{}{
 		"interface":   ifName,
-		"status":      "listening",
+		"status":      status,
 		"isListening": true,
+		"cleared":     clearBuffer,
 	}
 
-	h.respondSuccess(c, fmt.Sprintf("Started listening on %s", ifName), data)
+	h.respondSuccess(c, message, data)
 }
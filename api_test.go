@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestISOTPRequestAcceptsIDZero(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{name: "txId 0", body: `{"txId": 0, "rxId": 2024, "data": "AQI="}`, valid: true},
+		{name: "rxId 0", body: `{"txId": 2016, "rxId": 0, "data": "AQI="}`, valid: true},
+		{name: "txId missing", body: `{"rxId": 2024, "data": "AQI="}`},
+		{name: "rxId missing", body: `{"txId": 2016, "data": "AQI="}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req ISOTPRequest
+			err := binding.JSON.BindBody([]byte(tt.body), &req)
+			if tt.valid && err != nil {
+				t.Fatalf("rejected: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("accepted a request without both IDs")
+			}
+		})
+	}
+}
+
+func TestParseQueryOptionsIDBounds(t *testing.T) {
+	tests := []struct {
+		query   string
+		id      uint32
+		idMin   uint32
+		idMax   uint32
+		invalid bool
+	}{
+		{query: "id=0x100&idMin=0x100&idMax=0x1FF", id: 0x100, idMin: 0x100, idMax: 0x1FF},
+		{query: "id=256&idMin=100&idMax=1FF", id: 256, idMin: 0x100, idMax: 0x1FF},
+		{query: "id=0X7ff&idMin=0&idMax=0X7FF", id: 0x7FF, idMin: 0, idMax: 0x7FF},
+		{query: "id=1FF", invalid: true},
+		{query: "idMin=0x", invalid: true},
+		{query: "idMax=800G", invalid: true},
+		{query: "idMin=0x200&idMax=0x100", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/api/messages/can0?"+tt.query, nil)
+
+			opts, err := parseQueryOptions(c)
+			if tt.invalid {
+				if err == nil {
+					t.Fatal("accepted an invalid query")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseQueryOptions: %v", err)
+			}
+			if *opts.ID != tt.id || *opts.IDMin != tt.idMin || *opts.IDMax != tt.idMax {
+				t.Fatalf("id %d idMin %d idMax %d, want %d %d %d", *opts.ID, *opts.IDMin, *opts.IDMax, tt.id, tt.idMin, tt.idMax)
+			}
+		})
+	}
+}
+
+func TestBindCanMessageFormID(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		id          uint32
+		invalid     bool
+	}{
+		{name: "form hex", contentType: binding.MIMEPOSTForm, body: "interface=can0&id=0x123&dataHex=0102", id: 0x123},
+		{name: "form decimal", contentType: binding.MIMEPOSTForm, body: "interface=can0&id=291&dataHex=0102", id: 291},
+		{name: "form missing id", contentType: binding.MIMEPOSTForm, body: "interface=can0&dataHex=0102", invalid: true},
+		{name: "form bad id", contentType: binding.MIMEPOSTForm, body: "interface=can0&id=0xZZ&dataHex=0102", invalid: true},
+		{name: "json", contentType: binding.MIMEJSON, body: `{"interface": "can0", "id": 291, "data": "AQI="}`, id: 291},
+		{name: "json missing id", contentType: binding.MIMEJSON, body: `{"interface": "can0", "data": "AQI="}`, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/api/can", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			req, err := bindCanMessage(c)
+			if tt.invalid {
+				if err == nil {
+					t.Fatalf("accepted %+v", req)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("bindCanMessage: %v", err)
+			}
+			if req.ID != tt.id || !bytes.Equal(req.Data, []byte{1, 2}) {
+				t.Fatalf("id 0x%X data %v, want 0x%X [1 2]", req.ID, req.Data, tt.id)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	maxBridges             = 16
+	bridgeSubscriberBuffer = 1024                   // Frames waiting to be forwarded before the bridge drops them
+	bridgeEchoWindow       = 500 * time.Millisecond // How long a forwarded frame is remembered for loop detection
+	maxBridgeEchoes        = 256                    // Remembered forwarded frames per interface
+	bridgeSourcePrefix     = "bridge:"              // Source recorded on forwarded frames, followed by the bridge ID
+)
+
+// ErrTooManyBridges is returned when the bridge limit is reached
+var ErrTooManyBridges = errors.New("too many bridges")
+
+// ErrBridgeExists is returned when a bridge already forwards between the same interfaces
+var ErrBridgeExists = errors.New("bridge already exists")
+
+// BridgeRequest describes frames to forward from one interface to another
+type BridgeRequest struct {
+	From          string      `json:"from" binding:"required"`
+	To            string      `json:"to" binding:"required"`
+	Filter        *FilterSpec `json:"filter,omitempty"`        // Frames received on From to forward; empty forwards all
+	IDOffset      int32       `json:"idOffset,omitempty"`      // Added to the ID of forwarded frames (subtracted on the way back)
+	Bidirectional bool        `json:"bidirectional,omitempty"` // Also forward To back to From, for frames the filter selects after the offset
+}
+
+// BridgeDirectionStatus counts the frames forwarded in one direction of a bridge
+type BridgeDirectionStatus struct {
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Forwarded      uint64 `json:"forwarded"`
+	Errors         uint64 `json:"errors"`         // Frames that could not be sent or remapped
+	LoopSuppressed uint64 `json:"loopSuppressed"` // Frames dropped because they were our own forwarded frames coming back
+	LastError      string `json:"lastError,omitempty"`
+}
+
+// BridgeStatus is a snapshot of a bridge
+type BridgeStatus struct {
+	ID            string                  `json:"id"`
+	From          string                  `json:"from"`
+	To            string                  `json:"to"`
+	Filter        *FilterSpec             `json:"filter,omitempty"`
+	IDOffset      int32                   `json:"idOffset"`
+	Bidirectional bool                    `json:"bidirectional"`
+	Directions    []BridgeDirectionStatus `json:"directions"`
+	StartTime     time.Time               `json:"startTime"`
+}
+
+// bridgeDirection forwards the frames of one subscription to the other interface
+type bridgeDirection struct {
+	from, to string
+	offset   int64                // Added to the ID before sending
+	filter   *FilterSpec          // Applied at fan-out
+	accepts  func(id uint32) bool // Further selects received frames to forward; nil accepts all
+	frames   <-chan CanMessageLog
+	cancel   func()
+	handle   *ListenHandle
+	status   BridgeDirectionStatus
+}
+
+// bridge is one active bridge with one or two directions
+type bridge struct {
+	status     BridgeStatus
+	directions []*bridgeDirection
+	done       chan struct{} // Closed once every direction's goroutine exited
+	mu         sync.Mutex    // Guards the direction counters
+}
+
+// bridgeEcho remembers a frame a bridge sent, so it is not forwarded again when it comes back
+type bridgeEcho struct {
+	id     uint32
+	length uint8
+	data   [8]byte
+	sentAt time.Time
+}
+
+// Bridger forwards frames received on one interface out of another. It is fed by the
+// listener's subscriber fan-out and sends through the normal send path.
+//
+// Forwarding loops are guarded twice: bridges subscribe to received frames only, so frames
+// this host sent (including forwarded ones) never re-enter a bridge; and a frame arriving
+// on an interface shortly after a bridge sent the same ID and payload there, as happens when
+// two bridged interfaces share a bus, is dropped as an echo.
+type Bridger struct {
+	messageSender   *MessageSender
+	messageListener *CanMessageListener
+	logger          Logger
+	bridges         map[string]*bridge
+	nextID          uint64
+	mu              sync.Mutex
+
+	echoes    map[string][]bridgeEcho // Recently forwarded frames, per destination interface
+	echoMutex sync.Mutex
+}
+
+// NewBridger creates a bridger fed by messageListener that sends through messageSender
+func NewBridger(messageSender *MessageSender, messageListener *CanMessageListener, logger Logger) *Bridger {
+	return &Bridger{
+		messageSender:   messageSender,
+		messageListener: messageListener,
+		logger:          logger,
+		bridges:         make(map[string]*bridge),
+		echoes:          make(map[string][]bridgeEcho),
+	}
+}
+
+// validateBridgeRequest checks bridge parameters
+func (b *Bridger) validateBridgeRequest(req *BridgeRequest) error {
+	if req.From == req.To {
+		return fmt.Errorf("from and to must be different interfaces, got %s twice", req.From)
+	}
+	if req.Filter != nil {
+		if err := req.Filter.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, ifName := range []string{req.From, req.To} {
+		if err := b.messageSender.ValidateMessage(CanMessage{Interface: ifName, Data: []byte{0}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start validates the request, starts listening on the source interface(s) and begins forwarding
+func (b *Bridger) Start(req BridgeRequest) (BridgeStatus, error) {
+	if err := b.validateBridgeRequest(&req); err != nil {
+		return BridgeStatus{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.bridges) >= maxBridges {
+		return BridgeStatus{}, fmt.Errorf("%w (limit %d)", ErrTooManyBridges, maxBridges)
+	}
+	for _, existing := range b.bridges {
+		if existing.forwards(req.From, req.To) || (req.Bidirectional && existing.forwards(req.To, req.From)) {
+			return BridgeStatus{}, fmt.Errorf("%w: %s already forwards between %s and %s", ErrBridgeExists, existing.status.ID, req.From, req.To)
+		}
+	}
+
+	b.nextID++
+	br := &bridge{
+		status: BridgeStatus{
+			ID:            fmt.Sprintf("bridge-%d", b.nextID),
+			From:          req.From,
+			To:            req.To,
+			Filter:        req.Filter,
+			IDOffset:      req.IDOffset,
+			Bidirectional: req.Bidirectional,
+			StartTime:     time.Now(),
+		},
+		done: make(chan struct{}),
+	}
+
+	offset := int64(req.IDOffset)
+	br.directions = append(br.directions, &bridgeDirection{
+		from:   req.From,
+		to:     req.To,
+		offset: offset,
+		filter: req.Filter,
+	})
+	if req.Bidirectional {
+		// The way back undoes the offset and carries the frames the filter would select on From
+		br.directions = append(br.directions, &bridgeDirection{
+			from:   req.To,
+			to:     req.From,
+			offset: -offset,
+			accepts: func(id uint32) bool {
+				original, ok := remapCanID(id, -offset)
+				return ok && req.Filter.Matches(original)
+			},
+		})
+	}
+
+	for i, dir := range br.directions {
+		handle, err := b.messageListener.StartListening(dir.from)
+		if err != nil {
+			for _, started := range br.directions[:i] {
+				started.cancel()
+				started.handle.Release()
+			}
+			return BridgeStatus{}, fmt.Errorf("failed to listen on %s: %w", dir.from, err)
+		}
+		dir.handle = handle
+		dir.frames, dir.cancel = b.messageListener.SubscribeRX(dir.from, dir.filter, bridgeSubscriberBuffer)
+		dir.status = BridgeDirectionStatus{From: dir.from, To: dir.to}
+	}
+
+	var wg sync.WaitGroup
+	for _, dir := range br.directions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.forward(br, dir)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(br.done)
+	}()
+
+	b.bridges[br.status.ID] = br
+	b.logger.Printf("🌉 Bridge %s started: %s → %s (%s, ID offset %+d, bidirectional %t)",
+		br.status.ID, req.From, req.To, req.Filter, req.IDOffset, req.Bidirectional)
+	return br.snapshot(), nil
+}
+
+// forwards reports whether the bridge sends frames from one interface to the other
+func (br *bridge) forwards(from, to string) bool {
+	for _, dir := range br.directions {
+		if dir.from == from && dir.to == to {
+			return true
+		}
+	}
+	return false
+}
+
+// forward sends the frames of one direction until its subscription is canceled
+func (b *Bridger) forward(br *bridge, dir *bridgeDirection) {
+	source := bridgeSourcePrefix + br.status.ID
+	for frame := range dir.frames {
+		if dir.accepts != nil && !dir.accepts(frame.ID) {
+			continue
+		}
+		if b.takeEcho(dir.from, &frame) {
+			br.mu.Lock()
+			dir.status.LoopSuppressed++
+			br.mu.Unlock()
+			continue
+		}
+
+		err := b.send(dir, &frame, source)
+
+		br.mu.Lock()
+		if err != nil {
+			dir.status.Errors++
+			dir.status.LastError = err.Error()
+		} else {
+			dir.status.Forwarded++
+		}
+		br.mu.Unlock()
+	}
+}
+
+// send remaps a received frame and sends it out of the direction's destination interface
+func (b *Bridger) send(dir *bridgeDirection, frame *CanMessageLog, source string) error {
+	if frame.FD {
+		return fmt.Errorf("CAN FD frame 0x%X cannot be forwarded, only classic frames can be sent", frame.ID)
+	}
+	id, ok := remapCanID(frame.ID, dir.offset)
+	if !ok {
+		return fmt.Errorf("ID 0x%X with offset %+d is out of range", frame.ID&unix.CAN_EFF_MASK, dir.offset)
+	}
+
+	msg := CanMessage{Interface: dir.to, ID: id, Data: frame.Data, Length: frame.Length, Source: source}
+	if id&unix.CAN_RTR_FLAG != 0 {
+		msg.Data = nil // Remote frames carry only their DLC
+	}
+
+	b.rememberEcho(dir.to, id, frame)
+	if b.messageSender.IsAsync() {
+		return b.messageSender.EnqueueCanMessage(msg)
+	}
+	return b.messageSender.SendCanMessage(msg)
+}
+
+// remapCanID adds offset to the identifier of a raw can_id, keeping its flag bits.
+// It reports false when the result does not fit the frame's identifier width.
+func remapCanID(canID uint32, offset int64) (uint32, bool) {
+	flags := canID &^ unix.CAN_EFF_MASK
+	limit := int64(maxStandardCanID)
+	if canID&unix.CAN_EFF_FLAG != 0 {
+		limit = unix.CAN_EFF_MASK
+	}
+	id := int64(canID&unix.CAN_EFF_MASK) + offset
+	if id < 0 || id > limit {
+		return 0, false
+	}
+	return flags | uint32(id), true
+}
+
+// rememberEcho notes a frame about to be forwarded onto an interface
+func (b *Bridger) rememberEcho(interfaceName string, id uint32, frame *CanMessageLog) {
+	entry := bridgeEcho{id: id, sentAt: time.Now()}
+	entry.length = uint8(copy(entry.data[:], frame.Data))
+
+	b.echoMutex.Lock()
+	defer b.echoMutex.Unlock()
+
+	echoes := append(b.echoes[interfaceName], entry)
+	if len(echoes) > maxBridgeEchoes {
+		echoes = echoes[len(echoes)-maxBridgeEchoes:]
+	}
+	b.echoes[interfaceName] = echoes
+}
+
+// takeEcho reports whether a received frame is one a bridge recently forwarded onto the
+// same interface, and forgets it. Entries older than bridgeEchoWindow are discarded.
+func (b *Bridger) takeEcho(interfaceName string, frame *CanMessageLog) bool {
+	b.echoMutex.Lock()
+	defer b.echoMutex.Unlock()
+
+	echoes := b.echoes[interfaceName]
+	cutoff := frame.Timestamp.Add(-bridgeEchoWindow)
+	expired := 0
+	for expired < len(echoes) && echoes[expired].sentAt.Before(cutoff) {
+		expired++
+	}
+	echoes = echoes[expired:]
+
+	var data [8]byte
+	length := uint8(copy(data[:], frame.Data))
+	found := false
+	for i := range echoes {
+		if echoes[i].id == frame.ID && echoes[i].length == length && echoes[i].data == data {
+			echoes = append(echoes[:i], echoes[i+1:]...)
+			found = true
+			break
+		}
+	}
+	b.echoes[interfaceName] = echoes
+	return found
+}
+
+// snapshot returns a copy of the bridge status with current counters
+func (br *bridge) snapshot() BridgeStatus {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	status := br.status
+	status.Directions = make([]BridgeDirectionStatus, len(br.directions))
+	for i, dir := range br.directions {
+		status.Directions[i] = dir.status
+	}
+	return status
+}
+
+// stop ends forwarding, releases the listeners and waits for the forwarders to exit
+func (br *bridge) stop() {
+	for _, dir := range br.directions {
+		dir.cancel()
+	}
+	<-br.done
+	for _, dir := range br.directions {
+		dir.handle.Release()
+	}
+}
+
+// Get returns the status of a bridge
+func (b *Bridger) Get(id string) (BridgeStatus, bool) {
+	b.mu.Lock()
+	br, exists := b.bridges[id]
+	b.mu.Unlock()
+
+	if !exists {
+		return BridgeStatus{}, false
+	}
+	return br.snapshot(), true
+}
+
+// List returns the status of all active bridges, oldest first
+func (b *Bridger) List() []BridgeStatus {
+	b.mu.Lock()
+	result := make([]BridgeStatus, 0, len(b.bridges))
+	for _, br := range b.bridges {
+		result = append(result, br.snapshot())
+	}
+	b.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].StartTime.Before(result[j].StartTime) })
+	return result
+}
+
+// Stop removes a bridge and returns its final status
+func (b *Bridger) Stop(id string) (BridgeStatus, bool) {
+	b.mu.Lock()
+	br, exists := b.bridges[id]
+	delete(b.bridges, id)
+	b.mu.Unlock()
+
+	if !exists {
+		return BridgeStatus{}, false
+	}
+	br.stop()
+	status := br.snapshot()
+	b.logger.Printf("🌉 Bridge %s removed: %s", id, formatBridgeTotals(status))
+	return status, true
+}
+
+// StopInterface removes every bridge forwarding from or to an interface and returns how many were removed
+func (b *Bridger) StopInterface(ifName string) int {
+	return b.stopMatching(func(br *bridge) bool {
+		return br.status.From == ifName || br.status.To == ifName
+	})
+}
+
+// StopAll removes every bridge
+func (b *Bridger) StopAll() int {
+	return b.stopMatching(func(*bridge) bool { return true })
+}
+
+// stopMatching removes the bridges selected by match
+func (b *Bridger) stopMatching(match func(*bridge) bool) int {
+	b.mu.Lock()
+	var bridges []*bridge
+	for id, br := range b.bridges {
+		if match(br) {
+			bridges = append(bridges, br)
+			delete(b.bridges, id)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, br := range bridges {
+		br.stop()
+		b.logger.Printf("🌉 Bridge %s removed: %s", br.status.ID, formatBridgeTotals(br.snapshot()))
+	}
+	return len(bridges)
+}
+
+// formatBridgeTotals summarizes the counters of each direction, for logs
+func formatBridgeTotals(status BridgeStatus) string {
+	summary := ""
+	for i, dir := range status.Directions {
+		if i > 0 {
+			summary += "; "
+		}
+		summary += fmt.Sprintf("%s → %s %d forwarded, %d error(s), %d loop(s) suppressed",
+			dir.From, dir.To, dir.Forwarded, dir.Errors, dir.LoopSuppressed)
+	}
+	return summary
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Exit codes of the send and dump subcommands
+const (
+	cliExitOK    = 0
+	cliExitError = 1 // The frame could not be sent, or the interface could not be opened
+	cliExitUsage = 2 // Invalid arguments
+)
+
+// runSubcommand runs a one-shot subcommand named by args[0] without starting the service.
+// It reports false if args do not name a subcommand.
+func runSubcommand(args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch args[0] {
+	case "send":
+		return runSendCommand(args[1:]), true
+	case "dump":
+		return runDumpCommand(args[1:]), true
+	}
+	return 0, false
+}
+
+// cliLogger returns the logger for the service components a subcommand uses: their usual
+// messages go to stderr with -v and are discarded otherwise
+func cliLogger(verbose bool) Logger {
+	if verbose {
+		return &DefaultLogger{}
+	}
+	return log.New(io.Discard, "", 0)
+}
+
+// cliConfigProvider is the configuration of a subcommand working on a single interface
+func cliConfigProvider(ifName string) ConfigProvider {
+	return NewDefaultConfigProvider(&Config{
+		CanPorts:         []string{ifName},
+		LatencyWindow:    1,
+		SendRetries:      3,
+		SendRetryBackoff: time.Millisecond,
+	})
+}
+
+// runSendCommand sends one classic frame, e.g. `can-bridge send -interface can0 -id 0x123 -data DEADBEEF`
+func runSendCommand(args []string) int {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	ifName := fs.String("interface", "can0", "CAN interface to send on")
+	idStr := fs.String("id", "", "CAN ID, decimal or 0x-prefixed hex; set bit 31 (0x80000000) for a 29-bit ID")
+	dataStr := fs.String("data", "", "Payload as hex, e.g. DEADBEEF or \"DE AD BE EF\" (up to 8 bytes)")
+	verbose := fs.Bool("v", false, "Log what the sender does to stderr")
+	if err := fs.Parse(args); err != nil {
+		return cliExitUsage
+	}
+
+	if *idStr == "" {
+		fmt.Fprintln(os.Stderr, "send: -id is required")
+		return cliExitUsage
+	}
+	id, err := strconv.ParseUint(*idStr, 0, 32)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "send: invalid -id %q: %v\n", *idStr, err)
+		return cliExitUsage
+	}
+	data, err := hex.DecodeString(strings.ReplaceAll(*dataStr, " ", ""))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "send: invalid -data %q: %v\n", *dataStr, err)
+		return cliExitUsage
+	}
+
+	logger := cliLogger(*verbose)
+	socketProvider := NewUnixSocketProvider()
+	configProvider := cliConfigProvider(*ifName)
+	interfaceManager := NewInterfaceManager(configProvider, socketProvider, logger)
+	sender := NewMessageSender(interfaceManager, configProvider, socketProvider, logger)
+
+	msg := CanMessage{Interface: *ifName, ID: uint32(id), Data: data, Source: "cli"}
+	if err := sender.ValidateMessage(msg); err != nil {
+		fmt.Fprintf(os.Stderr, "send: %v\n", err)
+		return cliExitUsage
+	}
+
+	// One attempt: a script wants to know now, not after the service's initialization retries
+	canIf, err := interfaceManager.createInterface(*ifName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "send: failed to open %s: %v\n", *ifName, err)
+		return cliExitError
+	}
+	defer socketProvider.Close(canIf.FD)
+
+	if err := sender.sendMessage(canIf, msg); err != nil {
+		fmt.Fprintf(os.Stderr, "send: %v\n", err)
+		return cliExitError
+	}
+	return cliExitOK
+}
+
+// runDumpCommand prints received frames to stdout in candump's format until interrupted,
+// e.g. `can-bridge dump -interface can0`
+func runDumpCommand(args []string) int {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	ifName := fs.String("interface", "can0", "CAN interface to listen on")
+	count := fs.Int("n", 0, "Exit after this many frames (0 runs until interrupted)")
+	verbose := fs.Bool("v", false, "Log what the listener does to stderr")
+	if err := fs.Parse(args); err != nil {
+		return cliExitUsage
+	}
+	if *count < 0 {
+		fmt.Fprintf(os.Stderr, "dump: -n must not be negative, got %d\n", *count)
+		return cliExitUsage
+	}
+
+	listener := NewCanMessageListener(1, cliLogger(*verbose))
+	listener.SetLogging(*ifName, false) // Frames go to stdout, not into a buffer
+
+	frames, unsubscribe := listener.Subscribe(*ifName, nil)
+	defer unsubscribe()
+
+	handle, err := listener.StartListening(*ifName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dump: failed to listen on %s: %v\n", *ifName, err)
+		return cliExitError
+	}
+	defer handle.Release()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	for printed := 0; *count == 0 || printed < *count; printed++ {
+		select {
+		case msg := <-frames:
+			fmt.Println(formatCandump(msg))
+		case <-sigChan:
+			return cliExitOK
+		}
+	}
+	return cliExitOK
+}
+
+// formatCandump formats a frame the way candump prints it, e.g. "  can0  123   [4]  DE AD BE EF"
+func formatCandump(msg CanMessageLog) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "  %s  ", msg.Interface)
+	if msg.ID&unix.CAN_EFF_FLAG != 0 {
+		fmt.Fprintf(&sb, "%08X", msg.ID&unix.CAN_EFF_MASK)
+	} else {
+		fmt.Fprintf(&sb, "%03X", msg.ID&unix.CAN_SFF_MASK)
+	}
+	fmt.Fprintf(&sb, "   [%d] ", msg.Length)
+	if msg.ID&unix.CAN_RTR_FLAG != 0 {
+		sb.WriteString(" remote request")
+		return sb.String()
+	}
+	for _, b := range msg.Data {
+		fmt.Fprintf(&sb, " %02X", b)
+	}
+	return sb.String()
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/sys/unix"
 )
 
 // Configuration structure
@@ -22,6 +30,66 @@ type Config struct {
 	EnableFinder        bool          // Enable service finder
 	SetupFinderInterval time.Duration // Interval for service finder
 	EnableHealthCheck   bool          // Enable health check endpoint
+	AsyncSend           bool          // Queue outgoing frames per interface instead of sending inline
+	SendQueueSize       int           // Capacity of each per-interface send queue
+	ActiveHealthProbe   bool          // Allow health checks to send a probe frame on the bus
+	AlertWebhookURL     string        // Webhook notified on interface state changes (empty disables)
+	AlertCooldown       time.Duration // Minimum time between identical alerts for an interface
+	MaxMessages         int           // Received messages buffered per interface
+	MaxListeners        int           // Interface listeners allowed to run at once (0 is unlimited)
+	WatchdogInterval    time.Duration // Interval between watchdog sweeps
+	WatchdogMaxRecovery int           // Recovery attempts before the watchdog gives up on an interface
+	WatchdogExclude     []string      // Interfaces the watchdog reports on but never recovers
+	AllowVirtual        bool          // Allow vcan/vxcan interfaces (no bitrate configuration)
+	NoSetup             bool          // Skip all ip link mutation (unprivileged, OS-managed interfaces)
+	HTTPReadTimeout     time.Duration // HTTP server read timeout (0 disables)
+	HTTPWriteTimeout    time.Duration // HTTP server write timeout (0 disables)
+	HTTPIdleTimeout     time.Duration // HTTP keep-alive idle timeout (0 disables)
+	HTTPMaxBodyBytes    int64         // Maximum request body size (0 disables)
+	ListenAddr          string        // IP address the HTTP server binds to
+	RxBufferBytes       int           // Requested SO_RCVBUF for listening sockets (0 keeps the kernel default)
+	FrameSocket         string        // Unix socket path for the local frame stream (empty disables)
+	FrameSocketFormat   string        // "json" or "raw"
+	HealthProbeID       uint32        // ID of the active health probe frame
+	HealthProbeData     []byte        // Payload of the active health probe frame
+	LatencyWindow       int           // Send latency samples kept per interface
+	CollapseDuplicates  bool          // Fold identical consecutive frames into one buffer entry
+	ErrorMask           uint32        // CAN_RAW_ERR_FILTER mask for listening sockets; 0 disables error frames
+	SendRetries         int           // Retries when the TX queue is full
+	SendRetryBackoff    time.Duration // Delay before the first TX queue full retry
+	TxPadToDLC8         bool          // Pad sent classic data frames to 8 bytes (DLC 8)
+	TxPadByte           uint8         // Fill byte used by TxPadToDLC8
+	Debug               bool          // Expose diagnostic endpoints (/api/debug/stats)
+	PprofAddr           string        // Listen address of the pprof server (empty disables)
+	DryRun              bool          // Only log the ip commands that would change interfaces
+	RxStaleAfter        time.Duration // Receive silence after which an interface counts as unhealthy (0 disables)
+	MinRxRate           int           // Frames each interface must receive per watchdog check (0 disables)
+	TrustedProxies      []string      // Proxies whose forwarding headers are trusted for the client IP (empty trusts none)
+	StateCacheTTL       time.Duration // How long a parsed interface state is reused (0 disables)
+	FinderBroadcastAddr string        // UDP address the finder broadcasts to
+	FinderInterface     string        // Network interface the finder broadcasts on (empty picks one)
+	DeviceID            string        // Unique ID announced by the finder (empty derives it from the MAC)
+	DeviceName          string        // Device name announced by the finder
+	DeviceModel         string        // Device model announced by the finder
+	DeviceLocation      string        // Free-form location announced by the finder
+	DeviceTags          []string      // Free-form tags announced by the finder
+	InfluxURL           string        // InfluxDB server that receives periodic counters (empty disables)
+	InfluxBucket        string        // Bucket the points are written to
+	InfluxOrg           string        // Organization for the InfluxDB 2.x write API (optional)
+	InfluxToken         string        // API token for the InfluxDB write API (optional)
+	InfluxInterval      time.Duration // Interval between InfluxDB writes
+	DefaultInterface    string        // Interface for messages that leave it empty (empty: the only configured port)
+	SnapshotOnExit      string        // File the message buffers are saved to on shutdown (empty disables)
+	RestoreSnapshot     bool          // Reload the message buffers from SnapshotOnExit at startup
+
+	// Where each setting not left at its default came from, keyed by flag name
+	Sources map[string]ConfigSource
+
+	// Resolved setup configuration for interfaces with overrides, keyed by interface name
+	Interfaces map[string]InterfaceSetupConfig
+
+	// Per-interface overrides of MinRxRate, keyed by interface name
+	MinRxRates map[string]int
 }
 
 // ConfigProvider interface for dependency injection
@@ -35,31 +103,66 @@ type ConfigProvider interface {
 	GetDefaultRestartMs() int
 	GetSetupRetry() int
 	GetSetupDelay() time.Duration
+	GetAsyncSend() bool
+	GetSendQueueSize() int
+	GetLatencyWindow() int
+	GetSendRetry() (int, time.Duration)
+	GetTxPadding() (bool, byte)
+	GetActiveHealthProbe() bool
+	GetHealthProbeFrame() (uint32, []byte)
+	GetNoSetup() bool
+	GetRxStaleAfter() time.Duration
+	GetDefaultInterface() string
 }
 
 // DefaultConfigProvider implements ConfigProvider
 type DefaultConfigProvider struct {
-	config *Config
+	config atomic.Pointer[Config] // Never modified in place; reloads store a new Config
 }
 
 // NewDefaultConfigProvider creates a new default config provider
 func NewDefaultConfigProvider(config *Config) *DefaultConfigProvider {
-	return &DefaultConfigProvider{config: config}
+	p := &DefaultConfigProvider{}
+	p.config.Store(config)
+	return p
+}
+
+// Config returns the configuration in effect. Callers must not modify it.
+func (p *DefaultConfigProvider) Config() *Config {
+	return p.config.Load()
+}
+
+// SetConfig replaces the configuration in effect; readers see either the old or the new one whole
+func (p *DefaultConfigProvider) SetConfig(config *Config) {
+	p.config.Store(config)
 }
 
 // GetCanPorts returns configured CAN ports
 func (p *DefaultConfigProvider) GetCanPorts() []string {
-	return p.config.CanPorts
+	return p.config.Load().CanPorts
 }
 
 // GetServerPort returns server port
 func (p *DefaultConfigProvider) GetServerPort() string {
-	return p.config.Port
+	return p.config.Load().Port
+}
+
+// GetDefaultInterface returns the interface for messages that do not name one: -default-interface,
+// or the only configured port. It is empty when several ports are configured without a default.
+func (p *DefaultConfigProvider) GetDefaultInterface() string {
+	config := p.config.Load()
+	if config.DefaultInterface != "" {
+		return config.DefaultInterface
+	}
+	if len(config.CanPorts) == 1 {
+		return config.CanPorts[0]
+	}
+	return ""
 }
 
 // ValidateInterface checks if interface is in configured ports
 func (p *DefaultConfigProvider) ValidateInterface(ifName string) bool {
-	for _, port := range p.config.CanPorts {
+	for _, port := range p.config.Load().CanPorts {
 		if port == ifName {
 			return true
 		}
@@ -69,49 +172,149 @@ func (p *DefaultConfigProvider) ValidateInterface(ifName string) bool {
 
 // GetAutoSetup returns auto setup configuration
 func (p *DefaultConfigProvider) GetAutoSetup() bool {
-	return p.config.AutoSetup
+	return p.config.Load().AutoSetup
+}
+
+// GetNoSetup returns whether interface setup (ip link) is disabled
+func (p *DefaultConfigProvider) GetNoSetup() bool {
+	return p.config.Load().NoSetup
 }
 
 // GetDefaultBitrate returns default bitrate
 func (p *DefaultConfigProvider) GetDefaultBitrate() int {
-	return p.config.Bitrate
+	return p.config.Load().Bitrate
 }
 
 // GetDefaultSamplePoint returns default sample point
 func (p *DefaultConfigProvider) GetDefaultSamplePoint() string {
-	return p.config.SamplePoint
+	return p.config.Load().SamplePoint
 }
 
 // GetDefaultRestartMs returns default restart timeout
 func (p *DefaultConfigProvider) GetDefaultRestartMs() int {
-	return p.config.RestartMs
+	return p.config.Load().RestartMs
 }
 
 // GetSetupRetry returns setup retry count
 func (p *DefaultConfigProvider) GetSetupRetry() int {
-	return p.config.SetupRetry
+	return p.config.Load().SetupRetry
 }
 
 // GetSetupDelay returns setup retry delay
 func (p *DefaultConfigProvider) GetSetupDelay() time.Duration {
-	return p.config.SetupDelay
+	return p.config.Load().SetupDelay
+}
+
+// GetAsyncSend returns whether asynchronous queued sending is enabled
+func (p *DefaultConfigProvider) GetAsyncSend() bool {
+	return p.config.Load().AsyncSend
+}
+
+// GetSendQueueSize returns the per-interface send queue capacity
+func (p *DefaultConfigProvider) GetSendQueueSize() int {
+	return p.config.Load().SendQueueSize
+}
+
+// GetSendRetry returns how often and after what initial backoff a send is retried while the TX queue is full
+func (p *DefaultConfigProvider) GetSendRetry() (int, time.Duration) {
+	config := p.config.Load()
+	return config.SendRetries, config.SendRetryBackoff
+}
+
+// GetTxPadding returns whether sent frames are padded to 8 bytes by default, and the fill byte
+func (p *DefaultConfigProvider) GetTxPadding() (bool, byte) {
+	config := p.config.Load()
+	return config.TxPadToDLC8, config.TxPadByte
+}
+
+// GetLatencyWindow returns how many send latency samples are kept per interface
+func (p *DefaultConfigProvider) GetLatencyWindow() int {
+	return p.config.Load().LatencyWindow
+}
+
+// GetRxStaleAfter returns how long an interface may go without receiving before its health is downgraded
+func (p *DefaultConfigProvider) GetRxStaleAfter() time.Duration {
+	return p.config.Load().RxStaleAfter
+}
+
+// GetHealthProbeFrame returns the ID and payload of the active health probe frame
+func (p *DefaultConfigProvider) GetHealthProbeFrame() (uint32, []byte) {
+	config := p.config.Load()
+	return config.HealthProbeID, config.HealthProbeData
+}
+
+// GetActiveHealthProbe returns whether health checks may send probe frames
+func (p *DefaultConfigProvider) GetActiveHealthProbe() bool {
+	return p.config.Load().ActiveHealthProbe
 }
 
 func (p *DefaultConfigProvider) GetEnableFinder() bool {
-	return p.config.EnableFinder
+	return p.config.Load().EnableFinder
 }
 
 func (p *DefaultConfigProvider) GetSetupFinderInterval() time.Duration {
-	return p.config.SetupFinderInterval
+	return p.config.Load().SetupFinderInterval
 }
 
 func (p *DefaultConfigProvider) GetEnableHealthCheck() bool {
-	return p.config.EnableHealthCheck
+	return p.config.Load().EnableHealthCheck
 }
 
 // ConfigParser handles parsing configuration from various sources
 type ConfigParser struct{}
 
+// ConfigSource names where a setting's value came from
+type ConfigSource string
+
+// Configuration sources, from highest to lowest precedence
+const (
+	ConfigSourceFlag ConfigSource = "flag"
+	ConfigSourceEnv  ConfigSource = "env"
+	ConfigSourceFile ConfigSource = "file"
+)
+
+// maxSendRetries caps -send-retries; with the linear backoff a send may already wait 210 times the
+// initial backoff before it fails
+const maxSendRetries = 20
+
+// envNameExceptions are the environment variables that predate the CAN_<FLAG> convention
+var envNameExceptions = map[string]string{
+	"can-ports": "CAN_PORTS",
+	"port":      "SERVER_PORT",
+	"config":    "CAN_CONFIG_FILE",
+}
+
+// envName returns the environment variable that sets a flag: CAN_ followed by the flag name in
+// upper case with dashes as underscores, e.g. CAN_FINDER_INTERVAL for -finder-interval
+func envName(flagName string) string {
+	if name, ok := envNameExceptions[flagName]; ok {
+		return name
+	}
+	return "CAN_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// SourceSummary lists the settings taken from each source, one line per source, e.g.
+// "env: -bitrate (CAN_BITRATE), -debug (CAN_DEBUG)". Settings at their defaults are left out.
+func (c *Config) SourceSummary() []string {
+	bySource := make(map[ConfigSource][]string)
+	for _, name := range slices.Sorted(maps.Keys(c.Sources)) {
+		source := c.Sources[name]
+		entry := "-" + name
+		if source == ConfigSourceEnv {
+			entry += " (" + envName(name) + ")"
+		}
+		bySource[source] = append(bySource[source], entry)
+	}
+
+	var lines []string
+	for _, source := range []ConfigSource{ConfigSourceFlag, ConfigSourceEnv, ConfigSourceFile} {
+		if entries := bySource[source]; len(entries) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", source, strings.Join(entries, ", ")))
+		}
+	}
+	return lines
+}
+
 // NewConfigParser creates a new config parser
 func NewConfigParser() *ConfigParser {
 	return &ConfigParser{}
@@ -119,9 +322,22 @@ func NewConfigParser() *ConfigParser {
 
 // ParseConfig parses configuration from command line and environment variables
 func (cp *ConfigParser) ParseConfig() (*Config, error) {
+	return cp.parse(os.Args[1:], os.Getenv, nil)
+}
+
+// ParseFileConfig builds a configuration from a file document alone, ignoring
+// command line flags and environment variables. Unset keys take the flag defaults.
+func (cp *ConfigParser) ParseFileConfig(fc *FileConfig) (*Config, error) {
+	return cp.parse(nil, func(string) string { return "" }, fc)
+}
+
+// parse resolves args, getenv and the config file into a Config. A non-nil
+// fileConfig is used in place of the file named by -config.
+func (cp *ConfigParser) parse(args []string, getenv func(string) string, fileConfig *FileConfig) (*Config, error) {
 	config := &Config{}
 
-	// Command line flags
+	// Command line flags. A fresh FlagSet is used so configuration can be re-parsed on reload.
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
 	var canPortsFlag string
 	var serverPort string
 	var autoSetup bool
@@ -133,59 +349,372 @@ func (cp *ConfigParser) ParseConfig() (*Config, error) {
 	var setupFinderEnabled bool
 	var setupFinderInterval int
 	var setupHealthCheck bool
+	var asyncSend bool
+	var sendQueueSize int
+	var activeHealthProbe bool
+	var alertWebhookURL string
+	var alertCooldownSeconds int
+	var configFile string
+	var maxMessages int
+	var maxListeners int
+	var watchdogIntervalSeconds int
+	var watchdogMaxRecovery int
+	var watchdogExcludeFlag string
+	var allowVirtual bool
+	var noSetup bool
+	var httpReadTimeoutSeconds int
+	var httpWriteTimeoutSeconds int
+	var httpIdleTimeoutSeconds int
+	var httpMaxBodyBytes int64
+	var listenAddr string
+	var rxBufferBytes int
+	var frameSocket string
+	var frameSocketFormat string
+	var healthProbeID string
+	var healthProbeData string
+	var latencyWindow int
+	var collapseDuplicates bool
+	var errorMask string
+	var sendRetries int
+	var sendRetryBackoff int
+	var txPadToDLC8 bool
+	var txPadByte string
+	var debug bool
+	var pprofAddr string
+	var dryRun bool
+	var rxStaleAfterSeconds int
+	var minRxRate int
+	var trustedProxiesFlag string
+	var stateCacheTTLMs int
+	var finderBroadcastAddr string
+	var finderInterface string
+	var deviceID string
+	var deviceName string
+	var deviceModel string
+	var deviceLocation string
+	var deviceTagsFlag string
+	var influxURL string
+	var influxBucket string
+	var influxOrg string
+	var influxToken string
+	var influxIntervalSeconds int
+	var defaultInterface string
+	var snapshotOnExit string
+	var restoreSnapshot bool
+
+	fs.StringVar(&canPortsFlag, "can-ports", "", "Comma-separated list of CAN interfaces, optionally with name@bitrate[:sample-point] (e.g., can0,can1@250000)")
+	fs.StringVar(&serverPort, "port", "5260", "HTTP server port")
+	fs.BoolVar(&autoSetup, "auto-setup", true, "Automatically setup CAN interfaces on startup")
+	fs.IntVar(&bitrate, "bitrate", 1000000, "Default CAN bitrate (bps)")
+	fs.StringVar(&samplePoint, "sample-point", "0.75", "Default CAN sample point")
+	fs.IntVar(&restartMs, "restart-ms", 100, "Default CAN restart timeout (ms)")
+	fs.IntVar(&setupRetry, "setup-retry", 3, "Number of setup retry attempts")
+	fs.IntVar(&setupDelaySeconds, "setup-delay", 2, "Delay between setup retries (seconds)")
+	fs.BoolVar(&setupFinderEnabled, "enable-finder", true, "Enable service finder")
+	fs.IntVar(&setupFinderInterval, "finder-interval", 5, "Interval for service finder in seconds")
+	fs.BoolVar(&setupHealthCheck, "enable-healthcheck", true, "Enable health check endpoint")
+	fs.BoolVar(&asyncSend, "async-send", false, "Queue outgoing CAN frames per interface and send them asynchronously")
+	fs.IntVar(&sendQueueSize, "send-queue-size", 256, "Capacity of each per-interface send queue (async send only)")
+	fs.BoolVar(&activeHealthProbe, "active-health-probe", false, "Fall back to sending a probe frame when passive health checks are unavailable")
+	fs.StringVar(&alertWebhookURL, "alert-webhook-url", "", "Webhook URL to POST interface state change alerts to")
+	fs.IntVar(&alertCooldownSeconds, "alert-cooldown", 60, "Minimum seconds between identical alerts for an interface")
+	fs.IntVar(&maxMessages, "max-messages", 100, "Maximum number of received messages buffered per interface")
+	fs.IntVar(&maxListeners, "max-listeners", 64, "Maximum number of interface listeners running at once, each holding a socket and a goroutine (0 is unlimited)")
+	fs.IntVar(&watchdogIntervalSeconds, "watchdog-interval", 10, "Watchdog check interval in seconds")
+	fs.IntVar(&watchdogMaxRecovery, "watchdog-max-recovery", 3, "Maximum watchdog recovery attempts per interface")
+	fs.StringVar(&watchdogExcludeFlag, "watchdog-exclude", "", "Comma-separated interfaces the watchdog reports on but never recovers (e.g., a manually managed listen-only port)")
+	fs.BoolVar(&allowVirtual, "allow-virtual", false, "Allow virtual CAN interfaces (vcan/vxcan), which are brought up without bitrate configuration")
+	fs.BoolVar(&noSetup, "no-setup", false, "Never run ip link commands; only bind to interfaces already configured by the OS")
+	fs.IntVar(&httpReadTimeoutSeconds, "http-read-timeout", 5, "HTTP server read timeout in seconds (0 disables)")
+	fs.IntVar(&httpWriteTimeoutSeconds, "http-write-timeout", 10, "HTTP server write timeout in seconds (0 disables)")
+	fs.IntVar(&httpIdleTimeoutSeconds, "http-idle-timeout", 120, "HTTP server keep-alive idle timeout in seconds (0 disables)")
+	fs.Int64Var(&httpMaxBodyBytes, "http-max-body-bytes", 1048576, "Maximum HTTP request body size in bytes (0 disables the limit)")
+	fs.StringVar(&listenAddr, "listen-addr", "0.0.0.0", "IP address the HTTP server binds to (e.g., 127.0.0.1 for local access only)")
+	fs.IntVar(&rxBufferBytes, "rx-buffer-bytes", 0, "Requested SO_RCVBUF size for listening sockets in bytes (0 keeps the kernel default)")
+	fs.StringVar(&frameSocket, "frame-socket", "", "Unix domain socket path that streams every received frame to local clients (empty disables)")
+	fs.StringVar(&frameSocketFormat, "frame-socket-format", "json", "Frame socket encoding: json (4-byte big-endian length + JSON) or raw (struct can_frame/canfd_frame)")
+	fs.StringVar(&healthProbeID, "health-probe-id", "0x7FF", "CAN ID of the active health probe frame, decimal or 0x-prefixed hex (default is the lowest-priority standard ID)")
+	fs.StringVar(&healthProbeData, "health-probe-data", "", "Hex payload of the active health probe frame, up to 8 bytes (default: empty)")
+	fs.IntVar(&latencyWindow, "latency-window", 100, "Number of recent send latencies kept per interface for average and percentiles")
+	fs.BoolVar(&collapseDuplicates, "collapse-duplicates", false, "Store identical consecutive frames (same ID and data) as one buffer entry with a repeat count")
+	fs.StringVar(&errorMask, "error-mask", "0", "CAN error classes to receive on listening sockets (CAN_RAW_ERR_FILTER), decimal, 0x-prefixed hex or \"all\"; 0 disables error frames")
+	fs.IntVar(&sendRetries, "send-retries", 3, "Retries of a send that failed because the TX queue was full (ENOBUFS/EAGAIN), at most 20; 0 fails immediately")
+	fs.IntVar(&sendRetryBackoff, "send-retry-backoff", 1, "Backoff in milliseconds before the first TX queue full retry, growing linearly with each retry")
+	fs.BoolVar(&txPadToDLC8, "tx-pad-to-dlc8", false, "Pad the data of sent classic frames to 8 bytes (DLC 8) with -tx-pad-byte, unless a message sets a shorter length")
+	fs.StringVar(&txPadByte, "tx-pad-byte", "0x00", "Fill byte for -tx-pad-to-dlc8, decimal or 0x-prefixed hex, e.g. 0xFF")
+	fs.BoolVar(&debug, "debug", false, "Enable diagnostic endpoints such as /api/debug/stats")
+	fs.StringVar(&pprofAddr, "pprof-addr", "", "Address for a separate net/http/pprof server, e.g. 127.0.0.1:6060 (empty disables)")
+	fs.BoolVar(&dryRun, "dry-run", false, "Log the ip commands that setup, reset and teardown would run instead of running them")
+	fs.IntVar(&rxStaleAfterSeconds, "rx-stale-after", 0, "Downgrade an interface's health when no frame has been received for this many seconds (0 disables)")
+	fs.IntVar(&minRxRate, "min-rx-rate", 0, "Minimum frames each interface must receive per watchdog check interval before it is flagged silent (0 disables)")
+	fs.StringVar(&trustedProxiesFlag, "trusted-proxies", "", "Comma-separated proxy IPs or CIDRs whose X-Forwarded-For/X-Real-IP headers are trusted for the client IP (empty trusts none)")
+	fs.IntVar(&stateCacheTTLMs, "state-cache-ttl", 1000, "How long interface state read with ip is reused by status queries, in milliseconds (0 disables caching)")
+	fs.StringVar(&finderBroadcastAddr, "finder-broadcast-addr", DefaultFinderBroadcastAddr, "UDP address the service finder broadcasts to (host:port)")
+	fs.StringVar(&finderInterface, "finder-interface", "", "Network interface the service finder broadcasts on and advertises (default: first non-loopback interface that is up)")
+	fs.StringVar(&deviceID, "device-id", "", "Unique device ID announced by the service finder (default: derived from the MAC address)")
+	fs.StringVar(&deviceName, "device-name", DefaultDeviceName, "Device name announced by the service finder")
+	fs.StringVar(&deviceModel, "device-model", DefaultDeviceModel, "Device model announced by the service finder")
+	fs.StringVar(&deviceLocation, "device-location", "", "Free-form device location announced by the service finder")
+	fs.StringVar(&deviceTagsFlag, "device-tags", "", "Comma-separated free-form tags announced by the service finder")
+	fs.StringVar(&influxURL, "influx-url", "", "InfluxDB base URL to write interface counters to in line protocol (empty disables)")
+	fs.StringVar(&influxBucket, "influx-bucket", "", "InfluxDB bucket (or database/retention policy) to write to")
+	fs.StringVar(&influxOrg, "influx-org", "", "InfluxDB organization (InfluxDB 2.x; may be empty for 1.8 and 3.x)")
+	fs.StringVar(&influxToken, "influx-token", "", "InfluxDB API token sent as \"Authorization: Token ...\"")
+	fs.IntVar(&influxIntervalSeconds, "influx-interval", 10, "Seconds between InfluxDB writes")
+	fs.StringVar(&defaultInterface, "default-interface", "", "Interface used for messages that do not name one (defaults to the only configured port)")
+	fs.StringVar(&snapshotOnExit, "snapshot-on-exit", "", "File the buffered messages of every interface are saved to as JSON on a clean shutdown (empty disables)")
+	fs.BoolVar(&restoreSnapshot, "restore-snapshot", false, "Reload the message buffers from the -snapshot-on-exit file at startup")
+	fs.StringVar(&configFile, "config", "", "Path to a YAML or JSON configuration file")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
 
-	flag.StringVar(&canPortsFlag, "can-ports", "", "Comma-separated list of CAN interfaces (e.g., can0,can1)")
-	flag.StringVar(&serverPort, "port", "5260", "HTTP server port")
-	flag.BoolVar(&autoSetup, "auto-setup", true, "Automatically setup CAN interfaces on startup")
-	flag.IntVar(&bitrate, "bitrate", 1000000, "Default CAN bitrate (bps)")
-	flag.StringVar(&samplePoint, "sample-point", "0.75", "Default CAN sample point")
-	flag.IntVar(&restartMs, "restart-ms", 100, "Default CAN restart timeout (ms)")
-	flag.IntVar(&setupRetry, "setup-retry", 3, "Number of setup retry attempts")
-	flag.IntVar(&setupDelaySeconds, "setup-delay", 2, "Delay between setup retries (seconds)")
-	flag.BoolVar(&setupFinderEnabled, "enable-finder", true, "Enable service finder")
-	flag.IntVar(&setupFinderInterval, "finder-interval", 5, "Interval for service finder in seconds")
-	flag.BoolVar(&setupHealthCheck, "enable-healthcheck", true, "Enable health check endpoint")
-	flag.Parse()
+	// Precedence: explicitly set flags > environment variables > config file > flag defaults
+	explicit := make(map[string]bool)
+	config.Sources = make(map[string]ConfigSource)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+		config.Sources[f.Name] = ConfigSourceFlag
+	})
 
-	// Environment variables (override command line)
-	if envPorts := os.Getenv("CAN_PORTS"); envPorts != "" {
-		canPortsFlag = envPorts
+	// useFile reports whether the config file may set a flag's value, recording the file as its source
+	useFile := func(name string) bool {
+		if explicit[name] {
+			return false
+		}
+		config.Sources[name] = ConfigSourceFile
+		return true
 	}
-	if envPort := os.Getenv("SERVER_PORT"); envPort != "" {
-		serverPort = envPort
+
+	if envConfigFile := getenv(envName("config")); envConfigFile != "" && !explicit["config"] {
+		configFile = envConfigFile
+		config.Sources["config"] = ConfigSourceEnv
 	}
-	if envAutoSetup := os.Getenv("CAN_AUTO_SETUP"); envAutoSetup != "" {
-		if val, err := strconv.ParseBool(envAutoSetup); err == nil {
-			autoSetup = val
+
+	if fileConfig == nil && configFile != "" {
+		fc, err := LoadConfigFile(configFile)
+		if err != nil {
+			return nil, err
 		}
+		fileConfig = fc
 	}
-	if envBitrate := os.Getenv("CAN_BITRATE"); envBitrate != "" {
-		if val, err := strconv.Atoi(envBitrate); err == nil {
-			bitrate = val
+	if fc := fileConfig; fc != nil {
+		if len(fc.CanPorts) > 0 && useFile("can-ports") {
+			canPortsFlag = strings.Join(fc.CanPorts, ",")
 		}
-	}
-	if envSamplePoint := os.Getenv("CAN_SAMPLE_POINT"); envSamplePoint != "" {
-		samplePoint = envSamplePoint
-	}
-	if envRestartMs := os.Getenv("CAN_RESTART_MS"); envRestartMs != "" {
-		if val, err := strconv.Atoi(envRestartMs); err == nil {
-			restartMs = val
+		if fc.Port != nil && useFile("port") {
+			serverPort = *fc.Port
 		}
-	}
-	if envSetupRetry := os.Getenv("CAN_SETUP_RETRY"); envSetupRetry != "" {
-		if val, err := strconv.Atoi(envSetupRetry); err == nil {
-			setupRetry = val
+		if fc.AutoSetup != nil && useFile("auto-setup") {
+			autoSetup = *fc.AutoSetup
+		}
+		if fc.Bitrate != nil && useFile("bitrate") {
+			bitrate = *fc.Bitrate
+		}
+		if fc.SamplePoint != nil && useFile("sample-point") {
+			samplePoint = *fc.SamplePoint
+		}
+		if fc.RestartMs != nil && useFile("restart-ms") {
+			restartMs = *fc.RestartMs
+		}
+		if fc.SetupRetry != nil && useFile("setup-retry") {
+			setupRetry = *fc.SetupRetry
+		}
+		if fc.SetupDelay != nil && useFile("setup-delay") {
+			setupDelaySeconds = *fc.SetupDelay
+		}
+		if fc.EnableFinder != nil && useFile("enable-finder") {
+			setupFinderEnabled = *fc.EnableFinder
+		}
+		if fc.FinderInterval != nil && useFile("finder-interval") {
+			setupFinderInterval = *fc.FinderInterval
+		}
+		if fc.EnableHealthCheck != nil && useFile("enable-healthcheck") {
+			setupHealthCheck = *fc.EnableHealthCheck
+		}
+		if fc.AsyncSend != nil && useFile("async-send") {
+			asyncSend = *fc.AsyncSend
+		}
+		if fc.SendQueueSize != nil && useFile("send-queue-size") {
+			sendQueueSize = *fc.SendQueueSize
+		}
+		if fc.ActiveHealthProbe != nil && useFile("active-health-probe") {
+			activeHealthProbe = *fc.ActiveHealthProbe
+		}
+		if fc.AlertWebhookURL != nil && useFile("alert-webhook-url") {
+			alertWebhookURL = *fc.AlertWebhookURL
+		}
+		if fc.AlertCooldown != nil && useFile("alert-cooldown") {
+			alertCooldownSeconds = *fc.AlertCooldown
+		}
+		if fc.MaxMessages != nil && useFile("max-messages") {
+			maxMessages = *fc.MaxMessages
+		}
+		if fc.MaxListeners != nil && useFile("max-listeners") {
+			maxListeners = *fc.MaxListeners
+		}
+		if fc.WatchdogInterval != nil && useFile("watchdog-interval") {
+			watchdogIntervalSeconds = *fc.WatchdogInterval
+		}
+		if fc.WatchdogMaxRecovery != nil && useFile("watchdog-max-recovery") {
+			watchdogMaxRecovery = *fc.WatchdogMaxRecovery
+		}
+		if fc.WatchdogExclude != nil && useFile("watchdog-exclude") {
+			watchdogExcludeFlag = strings.Join(fc.WatchdogExclude, ",")
+		}
+		if fc.AllowVirtual != nil && useFile("allow-virtual") {
+			allowVirtual = *fc.AllowVirtual
+		}
+		if fc.NoSetup != nil && useFile("no-setup") {
+			noSetup = *fc.NoSetup
+		}
+		if fc.HTTPReadTimeout != nil && useFile("http-read-timeout") {
+			httpReadTimeoutSeconds = *fc.HTTPReadTimeout
+		}
+		if fc.HTTPWriteTimeout != nil && useFile("http-write-timeout") {
+			httpWriteTimeoutSeconds = *fc.HTTPWriteTimeout
+		}
+		if fc.HTTPIdleTimeout != nil && useFile("http-idle-timeout") {
+			httpIdleTimeoutSeconds = *fc.HTTPIdleTimeout
+		}
+		if fc.HTTPMaxBodyBytes != nil && useFile("http-max-body-bytes") {
+			httpMaxBodyBytes = *fc.HTTPMaxBodyBytes
+		}
+		if fc.ListenAddr != nil && useFile("listen-addr") {
+			listenAddr = *fc.ListenAddr
+		}
+		if fc.RxBufferBytes != nil && useFile("rx-buffer-bytes") {
+			rxBufferBytes = *fc.RxBufferBytes
+		}
+		if fc.FrameSocket != nil && useFile("frame-socket") {
+			frameSocket = *fc.FrameSocket
+		}
+		if fc.FrameSocketFormat != nil && useFile("frame-socket-format") {
+			frameSocketFormat = *fc.FrameSocketFormat
+		}
+		if fc.HealthProbeID != nil && useFile("health-probe-id") {
+			healthProbeID = *fc.HealthProbeID
+		}
+		if fc.HealthProbeData != nil && useFile("health-probe-data") {
+			healthProbeData = *fc.HealthProbeData
+		}
+		if fc.LatencyWindow != nil && useFile("latency-window") {
+			latencyWindow = *fc.LatencyWindow
+		}
+		if fc.CollapseDuplicates != nil && useFile("collapse-duplicates") {
+			collapseDuplicates = *fc.CollapseDuplicates
+		}
+		if fc.ErrorMask != nil && useFile("error-mask") {
+			errorMask = *fc.ErrorMask
+		}
+		if fc.SendRetries != nil && useFile("send-retries") {
+			sendRetries = *fc.SendRetries
+		}
+		if fc.SendRetryBackoff != nil && useFile("send-retry-backoff") {
+			sendRetryBackoff = *fc.SendRetryBackoff
+		}
+		if fc.TxPadToDLC8 != nil && useFile("tx-pad-to-dlc8") {
+			txPadToDLC8 = *fc.TxPadToDLC8
+		}
+		if fc.TxPadByte != nil && useFile("tx-pad-byte") {
+			txPadByte = *fc.TxPadByte
+		}
+		if fc.Debug != nil && useFile("debug") {
+			debug = *fc.Debug
+		}
+		if fc.PprofAddr != nil && useFile("pprof-addr") {
+			pprofAddr = *fc.PprofAddr
+		}
+		if fc.DryRun != nil && useFile("dry-run") {
+			dryRun = *fc.DryRun
+		}
+		if fc.RxStaleAfter != nil && useFile("rx-stale-after") {
+			rxStaleAfterSeconds = *fc.RxStaleAfter
+		}
+		if fc.MinRxRate != nil && useFile("min-rx-rate") {
+			minRxRate = *fc.MinRxRate
+		}
+		if fc.TrustedProxies != nil && useFile("trusted-proxies") {
+			trustedProxiesFlag = strings.Join(fc.TrustedProxies, ",")
+		}
+		if fc.StateCacheTTL != nil && useFile("state-cache-ttl") {
+			stateCacheTTLMs = *fc.StateCacheTTL
+		}
+		if fc.FinderBroadcastAddr != nil && useFile("finder-broadcast-addr") {
+			finderBroadcastAddr = *fc.FinderBroadcastAddr
+		}
+		if fc.FinderInterface != nil && useFile("finder-interface") {
+			finderInterface = *fc.FinderInterface
+		}
+		if fc.DeviceID != nil && useFile("device-id") {
+			deviceID = *fc.DeviceID
+		}
+		if fc.DeviceName != nil && useFile("device-name") {
+			deviceName = *fc.DeviceName
+		}
+		if fc.DeviceModel != nil && useFile("device-model") {
+			deviceModel = *fc.DeviceModel
+		}
+		if fc.DeviceLocation != nil && useFile("device-location") {
+			deviceLocation = *fc.DeviceLocation
+		}
+		if fc.DeviceTags != nil && useFile("device-tags") {
+			deviceTagsFlag = strings.Join(fc.DeviceTags, ",")
+		}
+		if fc.InfluxURL != nil && useFile("influx-url") {
+			influxURL = *fc.InfluxURL
+		}
+		if fc.InfluxBucket != nil && useFile("influx-bucket") {
+			influxBucket = *fc.InfluxBucket
+		}
+		if fc.InfluxOrg != nil && useFile("influx-org") {
+			influxOrg = *fc.InfluxOrg
+		}
+		if fc.InfluxToken != nil && useFile("influx-token") {
+			influxToken = *fc.InfluxToken
+		}
+		if fc.InfluxInterval != nil && useFile("influx-interval") {
+			influxIntervalSeconds = *fc.InfluxInterval
+		}
+		if fc.DefaultInterface != nil && useFile("default-interface") {
+			defaultInterface = *fc.DefaultInterface
+		}
+		if fc.SnapshotOnExit != nil && useFile("snapshot-on-exit") {
+			snapshotOnExit = *fc.SnapshotOnExit
+		}
+		if fc.RestoreSnapshot != nil && useFile("restore-snapshot") {
+			restoreSnapshot = *fc.RestoreSnapshot
 		}
 	}
-	if envSetupDelay := os.Getenv("CAN_SETUP_DELAY"); envSetupDelay != "" {
-		if val, err := strconv.Atoi(envSetupDelay); err == nil {
-			setupDelaySeconds = val
+
+	// Environment variables (override config file, but not explicitly set flags). Every flag
+	// has one, named by envName, and a value that does not parse is an error rather than ignored.
+	var envErrors []string
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		value := getenv(name)
+		if value == "" || explicit[f.Name] || f.Name == "config" {
+			return
+		}
+		if err := f.Value.Set(value); err != nil {
+			envErrors = append(envErrors, fmt.Sprintf("%s=%q: %v", name, value, err))
+			return
 		}
+		config.Sources[f.Name] = ConfigSourceEnv
+	})
+	if len(envErrors) > 0 {
+		return nil, fmt.Errorf("invalid environment variable(s): %s", strings.Join(envErrors, "; "))
 	}
 
 	// Parse CAN ports
+	var portOverrides map[string]InterfaceFileConfig
 	if canPortsFlag != "" {
-		config.CanPorts = cp.parseCanPorts(canPortsFlag)
+		ports, overrides, err := cp.parseCanPorts(canPortsFlag)
+		if err != nil {
+			return nil, err
+		}
+		config.CanPorts = ports
+		portOverrides = overrides
 	} else {
 		// Default to can0 if no ports specified
 		config.CanPorts = []string{"can0"}
@@ -196,12 +725,6 @@ func (cp *ConfigParser) ParseConfig() (*Config, error) {
 		return nil, fmt.Errorf("server port cannot be empty")
 	}
 
-	if setupFinderEnabled {
-		if setupFinderInterval <= 0 {
-			return nil, fmt.Errorf("finder interval must be positive, got %d", config.SetupFinderInterval)
-		}
-	}
-
 	if setupHealthCheck {
 		config.EnableHealthCheck = true
 	} else {
@@ -217,18 +740,182 @@ func (cp *ConfigParser) ParseConfig() (*Config, error) {
 	config.SetupDelay = time.Duration(setupDelaySeconds) * time.Second
 	config.EnableFinder = setupFinderEnabled
 	config.SetupFinderInterval = time.Duration(setupFinderInterval) * time.Second
+	config.AsyncSend = asyncSend
+	config.SendQueueSize = sendQueueSize
+	config.ActiveHealthProbe = activeHealthProbe
+	config.AlertWebhookURL = alertWebhookURL
+	config.AlertCooldown = time.Duration(alertCooldownSeconds) * time.Second
+	config.MaxMessages = maxMessages
+	config.MaxListeners = maxListeners
+	config.WatchdogInterval = time.Duration(watchdogIntervalSeconds) * time.Second
+	config.WatchdogMaxRecovery = watchdogMaxRecovery
+	config.WatchdogExclude = splitList(watchdogExcludeFlag)
+	config.AllowVirtual = allowVirtual
+	config.NoSetup = noSetup
+	config.HTTPReadTimeout = time.Duration(httpReadTimeoutSeconds) * time.Second
+	config.HTTPWriteTimeout = time.Duration(httpWriteTimeoutSeconds) * time.Second
+	config.HTTPIdleTimeout = time.Duration(httpIdleTimeoutSeconds) * time.Second
+	config.HTTPMaxBodyBytes = httpMaxBodyBytes
+	config.ListenAddr = listenAddr
+	config.RxBufferBytes = rxBufferBytes
+	config.FrameSocket = frameSocket
+	config.FrameSocketFormat = frameSocketFormat
+	probeID, err := strconv.ParseUint(healthProbeID, 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid health probe ID %q: %w", healthProbeID, err)
+	}
+	config.HealthProbeID = uint32(probeID)
+	probeData, err := hex.DecodeString(strings.ReplaceAll(healthProbeData, " ", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid health probe data %q: %w", healthProbeData, err)
+	}
+	if len(probeData) > 8 {
+		return nil, fmt.Errorf("health probe data cannot exceed 8 bytes, got %d", len(probeData))
+	}
+	config.HealthProbeData = probeData
+	config.LatencyWindow = latencyWindow
+	config.CollapseDuplicates = collapseDuplicates
+	if errorMask == "all" {
+		config.ErrorMask = unix.CAN_ERR_MASK
+	} else {
+		mask, err := strconv.ParseUint(errorMask, 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error mask %q: %w", errorMask, err)
+		}
+		config.ErrorMask = uint32(mask) & unix.CAN_ERR_MASK
+	}
+	config.SendRetries = sendRetries
+	config.SendRetryBackoff = time.Duration(sendRetryBackoff) * time.Millisecond
+	config.TxPadToDLC8 = txPadToDLC8
+	padByte, err := strconv.ParseUint(txPadByte, 0, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx pad byte %q: %w", txPadByte, err)
+	}
+	config.TxPadByte = uint8(padByte)
+	config.Debug = debug
+	config.PprofAddr = pprofAddr
+	config.DryRun = dryRun
+	config.RxStaleAfter = time.Duration(rxStaleAfterSeconds) * time.Second
+	config.MinRxRate = minRxRate
+	config.TrustedProxies = splitList(trustedProxiesFlag)
+	config.StateCacheTTL = time.Duration(stateCacheTTLMs) * time.Millisecond
+	config.FinderBroadcastAddr = finderBroadcastAddr
+	config.FinderInterface = finderInterface
+	config.DeviceID = deviceID
+	config.DeviceName = deviceName
+	config.DeviceModel = deviceModel
+	config.DeviceLocation = deviceLocation
+	config.DeviceTags = splitList(deviceTagsFlag)
+	config.InfluxURL = influxURL
+	config.InfluxBucket = influxBucket
+	config.InfluxOrg = influxOrg
+	config.InfluxToken = influxToken
+	config.InfluxInterval = time.Duration(influxIntervalSeconds) * time.Second
+	config.DefaultInterface = defaultInterface
+	config.SnapshotOnExit = snapshotOnExit
+	config.RestoreSnapshot = restoreSnapshot
+
+	// Resolve per-interface setup overrides against the global settings.
+	// Overrides given inline in the port list take precedence over the config file.
+	config.Interfaces = make(map[string]InterfaceSetupConfig)
+	config.MinRxRates = make(map[string]int)
+	if fileConfig != nil {
+		for ifName, override := range fileConfig.Interfaces {
+			if override.MinRxRate != nil {
+				config.MinRxRates[ifName] = *override.MinRxRate
+			}
+			if override.hasSetupOverride() {
+				config.Interfaces[ifName] = override.applyTo(config.SetupConfig())
+			}
+		}
+	}
+	for ifName, override := range portOverrides {
+		base, ok := config.Interfaces[ifName]
+		if !ok {
+			base = config.SetupConfig()
+		}
+		config.Interfaces[ifName] = override.applyTo(base)
+	}
 
 	return config, nil
 }
 
-// parseCanPorts parses comma-separated CAN ports string
-func (cp *ConfigParser) parseCanPorts(portsStr string) []string {
-	ports := strings.Split(portsStr, ",")
-	// Trim whitespace from each port
-	for i, port := range ports {
-		ports[i] = strings.TrimSpace(port)
+// WatchdogConfig returns the watchdog configuration derived from this config
+func (c *Config) WatchdogConfig() WatchdogConfig {
+	watchdogConfig := DefaultWatchdogConfig()
+	watchdogConfig.CheckInterval = c.WatchdogInterval
+	watchdogConfig.MaxRecoveryAttempts = c.WatchdogMaxRecovery
+	watchdogConfig.Exclude = slices.Clone(c.WatchdogExclude)
+	watchdogConfig.MinRxRate = c.MinRxRate
+	watchdogConfig.MinRxRates = maps.Clone(c.MinRxRates)
+	return watchdogConfig
+}
+
+// DeviceIdentity returns the device identity the finder announces
+func (c *Config) DeviceIdentity() DeviceIdentity {
+	return DeviceIdentity{
+		ID:       c.DeviceID,
+		Name:     c.DeviceName,
+		Model:    c.DeviceModel,
+		Location: c.DeviceLocation,
+		Tags:     slices.Clone(c.DeviceTags),
+	}
+}
+
+// SetupConfig returns the global interface setup configuration derived from this config
+func (c *Config) SetupConfig() InterfaceSetupConfig {
+	setupConfig := DefaultInterfaceSetupConfig()
+	setupConfig.Bitrate = c.Bitrate
+	setupConfig.SamplePoint = c.SamplePoint
+	setupConfig.RestartMs = c.RestartMs
+	setupConfig.RetryAttempts = c.SetupRetry
+	setupConfig.RetryDelay = c.SetupDelay
+	return setupConfig
+}
+
+// splitList splits a comma-separated list, dropping blank entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseCanPorts parses comma-separated CAN ports string.
+// Each entry may carry inline overrides as name@bitrate or name@bitrate:samplePoint.
+func (cp *ConfigParser) parseCanPorts(portsStr string) ([]string, map[string]InterfaceFileConfig, error) {
+	entries := strings.Split(portsStr, ",")
+	ports := make([]string, 0, len(entries))
+	overrides := make(map[string]InterfaceFileConfig)
+
+	for _, entry := range entries {
+		// Trim whitespace from each port
+		entry = strings.TrimSpace(entry)
+
+		name, spec, hasSpec := strings.Cut(entry, "@")
+		name = strings.TrimSpace(name)
+		ports = append(ports, name)
+		if !hasSpec {
+			continue
+		}
+
+		bitrateStr, samplePoint, hasSamplePoint := strings.Cut(strings.TrimSpace(spec), ":")
+		bitrate, err := strconv.Atoi(bitrateStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid bitrate in CAN port %q: %s", entry, bitrateStr)
+		}
+
+		override := InterfaceFileConfig{Bitrate: &bitrate}
+		if hasSamplePoint {
+			override.SamplePoint = &samplePoint
+		}
+		overrides[name] = override
 	}
-	return ports
+
+	return ports, overrides, nil
 }
 
 // ValidateConfig validates the configuration
@@ -241,6 +928,9 @@ func (cp *ConfigParser) ValidateConfig(config *Config) error {
 		if strings.TrimSpace(port) == "" {
 			return fmt.Errorf("CAN port name cannot be empty")
 		}
+		if err := validateInterfaceName(port); err != nil {
+			return fmt.Errorf("invalid CAN port: %w", err)
+		}
 	}
 
 	if config.Port == "" {
@@ -248,67 +938,288 @@ func (cp *ConfigParser) ValidateConfig(config *Config) error {
 	}
 
 	// Validate CAN-specific settings
-	if config.Bitrate <= 0 {
-		return fmt.Errorf("bitrate must be positive, got %d", config.Bitrate)
+	if err := validateBitrate(config.Bitrate); err != nil {
+		return err
 	}
 
-	// Common CAN bitrates validation
-	validBitrates := []int{
-		10000,   // 10 kbps
-		20000,   // 20 kbps
-		50000,   // 50 kbps
-		100000,  // 100 kbps
-		125000,  // 125 kbps
-		250000,  // 250 kbps
-		500000,  // 500 kbps
-		1000000, // 1 Mbps
+	if err := validateSamplePoint(config.SamplePoint); err != nil {
+		return err
 	}
 
-	validBitrate := false
-	for _, valid := range validBitrates {
-		if config.Bitrate == valid {
-			validBitrate = true
-			break
+	if config.RestartMs < 0 {
+		return fmt.Errorf("restart timeout cannot be negative, got %d", config.RestartMs)
+	}
+
+	if config.SetupRetry <= 0 {
+		return fmt.Errorf("setup retry count must be positive, got %d", config.SetupRetry)
+	}
+
+	if config.SetupDelay < 0 {
+		return fmt.Errorf("setup delay cannot be negative, got %v", config.SetupDelay)
+	}
+
+	if config.DefaultInterface != "" && !slices.Contains(config.CanPorts, config.DefaultInterface) {
+		return fmt.Errorf("default interface %s is not one of the configured CAN ports %v", config.DefaultInterface, config.CanPorts)
+	}
+
+	if config.RestoreSnapshot && config.SnapshotOnExit == "" {
+		return fmt.Errorf("restore-snapshot needs the snapshot file set with snapshot-on-exit")
+	}
+
+	for ifName, ifConfig := range config.Interfaces {
+		if !slices.Contains(config.CanPorts, ifName) {
+			return fmt.Errorf("interface override for %s, which is not one of the configured CAN ports %v", ifName, config.CanPorts)
+		}
+		if err := validateBitrate(ifConfig.Bitrate); err != nil {
+			return fmt.Errorf("%s: %w", ifName, err)
+		}
+		if err := validateSamplePoint(ifConfig.SamplePoint); err != nil {
+			return fmt.Errorf("%s: %w", ifName, err)
+		}
+		if ifConfig.RestartMs < 0 {
+			return fmt.Errorf("%s: restart timeout cannot be negative, got %d", ifName, ifConfig.RestartMs)
 		}
 	}
-	if !validBitrate {
-		return fmt.Errorf("bitrate %d is not a standard CAN bitrate. Valid options: %v", config.Bitrate, validBitrates)
+
+	if config.AlertWebhookURL != "" {
+		if u, err := url.Parse(config.AlertWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid alert webhook URL: %s", config.AlertWebhookURL)
+		}
 	}
 
-	if config.SamplePoint != "" {
-		if point, err := strconv.ParseFloat(config.SamplePoint, 64); err != nil {
-			return fmt.Errorf("invalid sample point format: %s", config.SamplePoint)
-		} else if point <= 0 || point >= 1 {
-			return fmt.Errorf("sample point must be between 0 and 1, got %f", point)
+	if config.InfluxURL != "" {
+		if u, err := url.Parse(config.InfluxURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid InfluxDB URL: %s", config.InfluxURL)
 		}
+		if config.InfluxBucket == "" {
+			return fmt.Errorf("an InfluxDB bucket is required when an InfluxDB URL is set")
+		}
+	}
+	if config.InfluxInterval < time.Second {
+		return fmt.Errorf("InfluxDB write interval must be at least 1 second, got %v", config.InfluxInterval)
 	}
 
-	if config.RestartMs < 0 {
-		return fmt.Errorf("restart timeout cannot be negative, got %d", config.RestartMs)
+	if config.AlertCooldown < 0 {
+		return fmt.Errorf("alert cooldown cannot be negative, got %v", config.AlertCooldown)
 	}
 
-	if config.SetupRetry <= 0 {
-		return fmt.Errorf("setup retry count must be positive, got %d", config.SetupRetry)
+	if config.MaxMessages <= 0 {
+		return fmt.Errorf("max messages must be positive, got %d", config.MaxMessages)
 	}
 
-	if config.SetupDelay < 0 {
-		return fmt.Errorf("setup delay cannot be negative, got %v", config.SetupDelay)
+	if config.MaxListeners < 0 {
+		return fmt.Errorf("max listeners cannot be negative, got %d", config.MaxListeners)
+	}
+	if config.MaxListeners > 0 && config.MaxListeners < len(config.CanPorts) {
+		return fmt.Errorf("max listeners (%d) is lower than the number of CAN ports (%d)", config.MaxListeners, len(config.CanPorts))
+	}
+
+	if config.SendRetries < 0 || config.SendRetries > maxSendRetries {
+		return fmt.Errorf("send retries must be between 0 and %d, got %d", maxSendRetries, config.SendRetries)
+	}
+
+	if config.SendRetryBackoff < 0 {
+		return fmt.Errorf("send retry backoff cannot be negative, got %v", config.SendRetryBackoff)
+	}
+
+	if config.LatencyWindow <= 0 {
+		return fmt.Errorf("latency window must be positive, got %d", config.LatencyWindow)
+	}
+
+	if config.WatchdogInterval <= 0 {
+		return fmt.Errorf("watchdog interval must be positive, got %v", config.WatchdogInterval)
+	}
+
+	if config.WatchdogMaxRecovery < 0 {
+		return fmt.Errorf("watchdog max recovery attempts cannot be negative, got %d", config.WatchdogMaxRecovery)
+	}
+	for _, ifName := range config.WatchdogExclude {
+		if !slices.Contains(config.CanPorts, ifName) {
+			return fmt.Errorf("watchdog exclusion %s is not a configured CAN port %v", ifName, config.CanPorts)
+		}
+	}
+
+	if config.AsyncSend && config.SendQueueSize <= 0 {
+		return fmt.Errorf("send queue size must be positive, got %d", config.SendQueueSize)
+	}
+
+	if net.ParseIP(config.ListenAddr) == nil {
+		return fmt.Errorf("invalid listen address %q: must be an IP address", config.ListenAddr)
+	}
+
+	if config.PprofAddr != "" {
+		if _, _, err := net.SplitHostPort(config.PprofAddr); err != nil {
+			return fmt.Errorf("invalid pprof address %q: %w", config.PprofAddr, err)
+		}
+	}
+
+	if config.HTTPReadTimeout < 0 || config.HTTPWriteTimeout < 0 || config.HTTPIdleTimeout < 0 {
+		return fmt.Errorf("HTTP timeouts cannot be negative")
+	}
+
+	if config.RxBufferBytes < 0 {
+		return fmt.Errorf("rx buffer bytes cannot be negative, got %d", config.RxBufferBytes)
+	}
+
+	if config.HTTPMaxBodyBytes < 0 {
+		return fmt.Errorf("HTTP max body bytes cannot be negative, got %d", config.HTTPMaxBodyBytes)
+	}
+
+	for _, proxy := range config.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("invalid trusted proxy %q: must be an IP address or CIDR", proxy)
+			}
+		}
+	}
+
+	if config.MinRxRate < 0 {
+		return fmt.Errorf("minimum receive rate cannot be negative, got %d", config.MinRxRate)
+	}
+	for ifName, rate := range config.MinRxRates {
+		if rate < 0 {
+			return fmt.Errorf("%s: minimum receive rate cannot be negative, got %d", ifName, rate)
+		}
+	}
+
+	if config.StateCacheTTL < 0 {
+		return fmt.Errorf("state cache TTL cannot be negative, got %v", config.StateCacheTTL)
+	}
+
+	if config.RxStaleAfter < 0 {
+		return fmt.Errorf("rx stale window cannot be negative, got %v", config.RxStaleAfter)
+	}
+
+	// Checked even with the finder disabled, since it can be turned on at runtime
+	if config.SetupFinderInterval < MinFinderInterval {
+		return fmt.Errorf("finder interval must be at least %v, got %v", MinFinderInterval, config.SetupFinderInterval)
+	}
+
+	if err := validateBroadcastAddr(config.FinderBroadcastAddr); err != nil {
+		return err
+	}
+
+	if config.DeviceName == "" {
+		return fmt.Errorf("device name cannot be empty")
+	}
+
+	if config.FinderInterface != "" {
+		if err := validateInterfaceName(config.FinderInterface); err != nil {
+			return fmt.Errorf("invalid finder interface: %w", err)
+		}
+	}
+
+	if config.FrameSocketFormat != FrameSocketFormatJSON && config.FrameSocketFormat != FrameSocketFormatRaw {
+		return fmt.Errorf("invalid frame socket format %q: must be %q or %q",
+			config.FrameSocketFormat, FrameSocketFormatJSON, FrameSocketFormatRaw)
 	}
 
 	return nil
 }
 
+// validBitrates lists the common CAN bitrates accepted by the service
+var validBitrates = []int{
+	10000,   // 10 kbps
+	20000,   // 20 kbps
+	50000,   // 50 kbps
+	100000,  // 100 kbps
+	125000,  // 125 kbps
+	250000,  // 250 kbps
+	500000,  // 500 kbps
+	1000000, // 1 Mbps
+}
+
+// validateBitrate checks that a bitrate is positive and a standard CAN bitrate
+func validateBitrate(bitrate int) error {
+	if bitrate <= 0 {
+		return fmt.Errorf("bitrate must be positive, got %d", bitrate)
+	}
+
+	if !slices.Contains(validBitrates, bitrate) {
+		return fmt.Errorf("bitrate %d is not a standard CAN bitrate. Valid options: %v", bitrate, validBitrates)
+	}
+	return nil
+}
+
+// validateSamplePoint checks that an optional sample point lies strictly between 0 and 1
+func validateSamplePoint(samplePoint string) error {
+	if samplePoint == "" {
+		return nil
+	}
+
+	point, err := strconv.ParseFloat(samplePoint, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sample point format: %s", samplePoint)
+	}
+	if point <= 0 || point >= 1 {
+		return fmt.Errorf("sample point must be between 0 and 1, got %f", point)
+	}
+	return nil
+}
+
 // GetConfigSummary returns a summary of the current configuration
 func (cp *ConfigParser) GetConfigSummary(config *Config) map[string]interface{} {
 	return map[string]interface{}{
-		"canPorts":    config.CanPorts,
-		"serverPort":  config.Port,
-		"autoSetup":   config.AutoSetup,
-		"bitrate":     config.Bitrate,
-		"samplePoint": config.SamplePoint,
-		"restartMs":   config.RestartMs,
-		"setupRetry":  config.SetupRetry,
-		"setupDelay":  config.SetupDelay.String(),
+		"canPorts":            config.CanPorts,
+		"serverPort":          config.Port,
+		"autoSetup":           config.AutoSetup,
+		"bitrate":             config.Bitrate,
+		"samplePoint":         config.SamplePoint,
+		"restartMs":           config.RestartMs,
+		"setupRetry":          config.SetupRetry,
+		"setupDelay":          config.SetupDelay.String(),
+		"asyncSend":           config.AsyncSend,
+		"sendQueueSize":       config.SendQueueSize,
+		"activeHealthProbe":   config.ActiveHealthProbe,
+		"alertWebhook":        config.AlertWebhookURL != "",
+		"alertCooldown":       config.AlertCooldown.String(),
+		"interfaces":          config.Interfaces,
+		"maxMessages":         config.MaxMessages,
+		"maxListeners":        config.MaxListeners,
+		"watchdogInterval":    config.WatchdogInterval.String(),
+		"watchdogMaxRecovery": config.WatchdogMaxRecovery,
+		"watchdogExclude":     config.WatchdogExclude,
+		"allowVirtual":        config.AllowVirtual,
+		"noSetup":             config.NoSetup,
+		"httpReadTimeout":     config.HTTPReadTimeout.String(),
+		"httpWriteTimeout":    config.HTTPWriteTimeout.String(),
+		"httpIdleTimeout":     config.HTTPIdleTimeout.String(),
+		"httpMaxBodyBytes":    config.HTTPMaxBodyBytes,
+		"listenAddr":          config.ListenAddr,
+		"rxBufferBytes":       config.RxBufferBytes,
+		"frameSocket":         config.FrameSocket,
+		"frameSocketFormat":   config.FrameSocketFormat,
+		"healthProbeId":       fmt.Sprintf("0x%X", config.HealthProbeID),
+		"healthProbeData":     hex.EncodeToString(config.HealthProbeData),
+		"latencyWindow":       config.LatencyWindow,
+		"collapseDuplicates":  config.CollapseDuplicates,
+		"errorMask":           fmt.Sprintf("0x%X", config.ErrorMask),
+		"sendRetries":         config.SendRetries,
+		"sendRetryBackoff":    config.SendRetryBackoff.String(),
+		"txPadToDlc8":         config.TxPadToDLC8,
+		"txPadByte":           fmt.Sprintf("0x%02X", config.TxPadByte),
+		"debug":               config.Debug,
+		"pprofAddr":           config.PprofAddr,
+		"dryRun":              config.DryRun,
+		"rxStaleAfter":        config.RxStaleAfter.String(),
+		"minRxRate":           config.MinRxRate,
+		"trustedProxies":      config.TrustedProxies,
+		"stateCacheTTL":       config.StateCacheTTL.String(),
+		"finderBroadcastAddr": config.FinderBroadcastAddr,
+		"finderInterface":     config.FinderInterface,
+		"deviceId":            config.DeviceID,
+		"deviceName":          config.DeviceName,
+		"deviceModel":         config.DeviceModel,
+		"deviceLocation":      config.DeviceLocation,
+		"deviceTags":          config.DeviceTags,
+		"influx":              config.InfluxURL != "",
+		"influxBucket":        config.InfluxBucket,
+		"influxOrg":           config.InfluxOrg,
+		"influxToken":         config.InfluxToken != "",
+		"influxInterval":      config.InfluxInterval.String(),
+		"defaultInterface":    config.DefaultInterface,
+		"snapshotOnExit":      config.SnapshotOnExit,
+		"restoreSnapshot":     config.RestoreSnapshot,
 	}
 }
 
@@ -316,7 +1227,9 @@ func (cp *ConfigParser) GetConfigSummary(config *Config) map[string]interface{}
 func PrintUsage() {
 	fmt.Println("CAN Communication Service")
 	fmt.Println("Usage:")
-	fmt.Println("  -can-ports string       Comma-separated list of CAN interfaces (default: can0)")
+	fmt.Println("  -config string          Path to a YAML or JSON configuration file")
+	fmt.Println("  -can-ports string       Comma-separated list of CAN interfaces, optionally name@bitrate[:sample-point] (default: can0)")
+	fmt.Println("  -default-interface string  Interface for messages without one (default: the only configured port)")
 	fmt.Println("  -port string            HTTP server port (default: 5260)")
 	fmt.Println("  -auto-setup             Automatically setup CAN interfaces on startup (default: true)")
 	fmt.Println("  -bitrate int            Default CAN bitrate in bps (default: 1000000)")
@@ -326,10 +1239,65 @@ func PrintUsage() {
 	fmt.Println("  -setup-delay int        Delay between setup retries in seconds (default: 2)")
 	fmt.Println("  -enable-finder          Enable service finder (default: true)")
 	fmt.Println("  -finder-interval int    Interval for service finder in seconds (default: 5)")
+	fmt.Println("  -finder-broadcast-addr string  UDP address the service finder broadcasts to (default: 255.255.255.255:9999)")
+	fmt.Println("  -finder-interface string  Network interface the service finder broadcasts on, e.g. eth0 (default: auto)")
+	fmt.Println("  -device-id string       Unique device ID announced by the service finder (default: derived from the MAC)")
+	fmt.Println("  -device-name string     Device name announced by the service finder (default: Can-Bridge)")
+	fmt.Println("  -device-model string    Device model announced by the service finder (default: LinkerHand OSS)")
+	fmt.Println("  -device-location string  Free-form device location announced by the service finder (default: none)")
+	fmt.Println("  -device-tags string     Comma-separated tags announced by the service finder (default: none)")
 	fmt.Println("  -enable-healthcheck     Enable health check endpoint (default: true)")
+	fmt.Println("  -async-send             Queue outgoing frames per interface and send asynchronously (default: false)")
+	fmt.Println("  -send-queue-size int    Capacity of each per-interface send queue (default: 256)")
+	fmt.Println("  -active-health-probe    Send a probe frame when passive health checks are unavailable (default: false)")
+	fmt.Println("  -alert-webhook-url string  Webhook URL for interface down/recovered/bus_off alerts (default: disabled)")
+	fmt.Println("  -alert-cooldown int     Minimum seconds between identical alerts per interface (default: 60)")
+	fmt.Println("  -influx-url string      InfluxDB base URL for periodic interface counters (default: disabled)")
+	fmt.Println("  -influx-bucket string   InfluxDB bucket to write to (required with -influx-url)")
+	fmt.Println("  -influx-org string      InfluxDB organization (default: none)")
+	fmt.Println("  -influx-token string    InfluxDB API token (default: none)")
+	fmt.Println("  -influx-interval int    Seconds between InfluxDB writes (default: 10)")
+	fmt.Println("  -max-messages int       Received messages buffered per interface (default: 100)")
+	fmt.Println("  -max-listeners int      Interface listeners running at once, 0 for no limit (default: 64)")
+	fmt.Println("  -watchdog-interval int  Watchdog check interval in seconds (default: 10)")
+	fmt.Println("  -watchdog-max-recovery int  Watchdog recovery attempts per interface (default: 3)")
+	fmt.Println("  -watchdog-exclude string  Comma-separated interfaces the watchdog reports on but never recovers (default: none)")
+	fmt.Println("  -allow-virtual          Allow vcan/vxcan interfaces for testing (default: false)")
+	fmt.Println("  -no-setup               Never run ip link; use interfaces already configured by the OS (default: false)")
+	fmt.Println("  -http-read-timeout int  HTTP read timeout in seconds, 0 disables (default: 5)")
+	fmt.Println("  -http-write-timeout int HTTP write timeout in seconds, 0 disables (default: 10)")
+	fmt.Println("  -http-idle-timeout int  HTTP keep-alive idle timeout in seconds, 0 disables (default: 120)")
+	fmt.Println("  -http-max-body-bytes int Maximum request body size in bytes, 0 disables (default: 1048576)")
+	fmt.Println("  -listen-addr string     IP address the HTTP server binds to (default: 0.0.0.0)")
+	fmt.Println("  -rx-buffer-bytes int    SO_RCVBUF for listening sockets in bytes, 0 keeps kernel default (default: 0)")
+	fmt.Println("  -frame-socket string    Unix socket path streaming received frames to local clients (default: disabled)")
+	fmt.Println("  -frame-socket-format string  Frame socket encoding: json or raw (default: json)")
+	fmt.Println("  -health-probe-id string Active health probe CAN ID (default: 0x7FF)")
+	fmt.Println("  -health-probe-data string  Active health probe payload in hex, up to 8 bytes (default: empty)")
+	fmt.Println("  -latency-window int     Send latency samples kept per interface for percentiles (default: 100)")
+	fmt.Println("  -collapse-duplicates    Store identical consecutive frames as one entry with a repeat count (default: false)")
+	fmt.Println("  -error-mask string      Error classes captured by listeners, e.g. 0x1FF or all (default: 0, off)")
+	fmt.Println("  -send-retries int       Retries when the TX queue is full (ENOBUFS/EAGAIN) (default: 3)")
+	fmt.Println("  -send-retry-backoff int Milliseconds before the first TX queue full retry (default: 1)")
+	fmt.Println("  -tx-pad-to-dlc8         Pad sent classic frames to 8 bytes unless a message sets a shorter length (default: false)")
+	fmt.Println("  -tx-pad-byte string     Fill byte for -tx-pad-to-dlc8, e.g. 0xFF (default: 0x00)")
+	fmt.Println("  -debug                  Enable diagnostic endpoints such as /api/debug/stats (default: false)")
+	fmt.Println("  -pprof-addr             Address for a separate pprof server, e.g. 127.0.0.1:6060 (default: disabled)")
+	fmt.Println("  -dry-run                Log the ip commands setup/reset/teardown would run without running them (default: false)")
+	fmt.Println("  -rx-stale-after         Seconds without a received frame before an interface's health is downgraded (default: 0, disabled)")
+	fmt.Println("  -min-rx-rate            Minimum frames received per watchdog check before an interface is flagged silent (default: 0, disabled)")
+	fmt.Println("  -trusted-proxies        Comma-separated proxy IPs/CIDRs trusted for X-Forwarded-For (default: none, use the connection address)")
+	fmt.Println("  -state-cache-ttl        Milliseconds interface state from ip is reused by status queries (default: 1000, 0 disables)")
+	fmt.Println("  -snapshot-on-exit string  File the message buffers are saved to on shutdown (default: disabled)")
+	fmt.Println("  -restore-snapshot       Reload the message buffers from the -snapshot-on-exit file at startup (default: false)")
 	fmt.Println("")
 	fmt.Println("Environment Variables:")
+	fmt.Println("  Every flag can be set with CAN_<FLAG>: the flag name in upper case with dashes as")
+	fmt.Println("  underscores, e.g. CAN_FINDER_INTERVAL=10 for -finder-interval 10. Exceptions: CAN_PORTS,")
+	fmt.Println("  SERVER_PORT and CAN_CONFIG_FILE. A value that does not parse is a startup error.")
+	fmt.Println("  CAN_CONFIG_FILE        Path to a YAML or JSON configuration file")
 	fmt.Println("  CAN_PORTS              Comma-separated list of CAN interfaces")
+	fmt.Println("  CAN_DEFAULT_INTERFACE  Interface for messages that do not name one")
 	fmt.Println("  SERVER_PORT            HTTP server port")
 	fmt.Println("  CAN_AUTO_SETUP         Automatically setup CAN interfaces (true/false)")
 	fmt.Println("  CAN_BITRATE            Default CAN bitrate in bps")
@@ -337,6 +1305,59 @@ func PrintUsage() {
 	fmt.Println("  CAN_RESTART_MS         Default CAN restart timeout in ms")
 	fmt.Println("  CAN_SETUP_RETRY        Number of setup retry attempts")
 	fmt.Println("  CAN_SETUP_DELAY        Delay between setup retries in seconds")
+	fmt.Println("  CAN_ENABLE_FINDER      Enable service finder (true/false)")
+	fmt.Println("  CAN_FINDER_INTERVAL    Interval for service finder in seconds")
+	fmt.Println("  CAN_ENABLE_HEALTHCHECK Enable health check endpoint (true/false)")
+	fmt.Println("  CAN_ASYNC_SEND         Queue outgoing frames and send asynchronously (true/false)")
+	fmt.Println("  CAN_SEND_QUEUE_SIZE    Capacity of each per-interface send queue")
+	fmt.Println("  CAN_ACTIVE_HEALTH_PROBE Send a probe frame when passive health checks are unavailable (true/false)")
+	fmt.Println("  CAN_ALERT_WEBHOOK_URL  Webhook URL for interface state change alerts")
+	fmt.Println("  CAN_ALERT_COOLDOWN     Minimum seconds between identical alerts per interface")
+	fmt.Println("  CAN_INFLUX_URL         InfluxDB base URL for periodic interface counters")
+	fmt.Println("  CAN_INFLUX_BUCKET      InfluxDB bucket to write to")
+	fmt.Println("  CAN_INFLUX_ORG         InfluxDB organization")
+	fmt.Println("  CAN_INFLUX_TOKEN       InfluxDB API token")
+	fmt.Println("  CAN_INFLUX_INTERVAL    Seconds between InfluxDB writes")
+	fmt.Println("  CAN_MAX_MESSAGES       Received messages buffered per interface")
+	fmt.Println("  CAN_MAX_LISTENERS      Interface listeners running at once (0 for no limit)")
+	fmt.Println("  CAN_WATCHDOG_INTERVAL  Watchdog check interval in seconds")
+	fmt.Println("  CAN_WATCHDOG_MAX_RECOVERY Watchdog recovery attempts per interface")
+	fmt.Println("  CAN_WATCHDOG_EXCLUDE   Comma-separated interfaces the watchdog never recovers")
+	fmt.Println("  CAN_ALLOW_VIRTUAL      Allow vcan/vxcan interfaces (true/false)")
+	fmt.Println("  CAN_NO_SETUP           Never run ip link commands (true/false)")
+	fmt.Println("  CAN_HTTP_READ_TIMEOUT  HTTP read timeout in seconds")
+	fmt.Println("  CAN_HTTP_WRITE_TIMEOUT HTTP write timeout in seconds")
+	fmt.Println("  CAN_HTTP_IDLE_TIMEOUT  HTTP keep-alive idle timeout in seconds")
+	fmt.Println("  CAN_HTTP_MAX_BODY_BYTES Maximum request body size in bytes")
+	fmt.Println("  CAN_LISTEN_ADDR        IP address the HTTP server binds to")
+	fmt.Println("  CAN_RX_BUFFER_BYTES    SO_RCVBUF for listening sockets in bytes")
+	fmt.Println("  CAN_FRAME_SOCKET       Unix socket path streaming received frames")
+	fmt.Println("  CAN_FRAME_SOCKET_FORMAT Frame socket encoding: json or raw")
+	fmt.Println("  CAN_HEALTH_PROBE_ID    Active health probe CAN ID")
+	fmt.Println("  CAN_HEALTH_PROBE_DATA  Active health probe payload in hex")
+	fmt.Println("  CAN_LATENCY_WINDOW     Send latency samples kept per interface")
+	fmt.Println("  CAN_COLLAPSE_DUPLICATES Fold identical consecutive frames in the message buffer")
+	fmt.Println("  CAN_ERROR_MASK         Error classes captured by listeners")
+	fmt.Println("  CAN_SEND_RETRIES       Retries when the TX queue is full")
+	fmt.Println("  CAN_SEND_RETRY_BACKOFF Milliseconds before the first TX queue full retry")
+	fmt.Println("  CAN_TX_PAD_TO_DLC8     Pad sent classic frames to 8 bytes")
+	fmt.Println("  CAN_TX_PAD_BYTE        Fill byte for CAN_TX_PAD_TO_DLC8")
+	fmt.Println("  CAN_DEBUG               Enable diagnostic endpoints")
+	fmt.Println("  CAN_PPROF_ADDR          Address for a separate pprof server")
+	fmt.Println("  CAN_DRY_RUN             Log interface setup commands instead of running them")
+	fmt.Println("  CAN_RX_STALE_AFTER      Seconds without received frames before health is downgraded")
+	fmt.Println("  CAN_MIN_RX_RATE         Minimum frames received per watchdog check interval")
+	fmt.Println("  CAN_TRUSTED_PROXIES     Comma-separated proxy IPs/CIDRs trusted for X-Forwarded-For")
+	fmt.Println("  CAN_STATE_CACHE_TTL     Milliseconds interface state is cached")
+	fmt.Println("  CAN_FINDER_BROADCAST_ADDR  UDP address the service finder broadcasts to")
+	fmt.Println("  CAN_FINDER_INTERFACE    Network interface the service finder broadcasts on")
+	fmt.Println("  CAN_DEVICE_ID           Unique device ID announced by the service finder")
+	fmt.Println("  CAN_DEVICE_NAME         Device name announced by the service finder")
+	fmt.Println("  CAN_DEVICE_MODEL        Device model announced by the service finder")
+	fmt.Println("  CAN_DEVICE_LOCATION     Free-form device location announced by the service finder")
+	fmt.Println("  CAN_DEVICE_TAGS         Comma-separated tags announced by the service finder")
+	fmt.Println("  CAN_SNAPSHOT_ON_EXIT    File the message buffers are saved to on shutdown")
+	fmt.Println("  CAN_RESTORE_SNAPSHOT    Reload the message buffers from the snapshot at startup (true/false)")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  # Basic usage with default settings")
@@ -345,15 +1366,28 @@ func PrintUsage() {
 	fmt.Println("  # Custom bitrate and sample point")
 	fmt.Println("  ./can-bridge -can-ports can0 -bitrate 500000 -sample-point 0.8")
 	fmt.Println("")
+	fmt.Println("  # Different bitrate per interface")
+	fmt.Println("  ./can-bridge -can-ports can0@500000,can1@250000:0.8")
+	fmt.Println("")
 	fmt.Println("  # Disable auto-setup (manual setup via API)")
 	fmt.Println("  ./can-bridge -can-ports can0,can1 -auto-setup=false")
 	fmt.Println("")
 	fmt.Println("  # Using environment variables")
 	fmt.Println("  CAN_PORTS=can0,can1 CAN_BITRATE=500000 ./can-bridge")
 	fmt.Println("")
+	fmt.Println("  # Load settings (including per-interface overrides) from a file")
+	fmt.Println("  ./can-bridge -config /etc/can-bridge.yaml")
+	fmt.Println("")
 	fmt.Println("  # High availability setup with more retries")
 	fmt.Println("  ./can-bridge -can-ports can0,can1 -setup-retry 5 -setup-delay 3")
 	fmt.Println("")
+	fmt.Println("Subcommands (run once and exit, without the HTTP server):")
+	fmt.Println("  ./can-bridge send -interface can0 -id 0x123 -data DEADBEEF  # exit status 0 once sent")
+	fmt.Println("  ./can-bridge dump -interface can0 [-n 10]                   # print received frames candump-style")
+	fmt.Println("")
+	fmt.Println("Precedence: command-line flags > environment variables > config file > defaults")
+	fmt.Println("Send SIGHUP to reload setup parameters, watchdog settings, finder settings and buffer sizes.")
+	fmt.Println("")
 	fmt.Println("Valid CAN Bitrates:")
 	fmt.Println("  10000, 20000, 50000, 100000, 125000, 250000, 500000, 1000000 (bps)")
 	fmt.Println("")
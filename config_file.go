@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the on-disk configuration format. YAML and JSON are both accepted.
+// Pointer fields distinguish "not set" from zero values so the file only overrides what it names.
+type FileConfig struct {
+	CanPorts            []string                       `yaml:"canPorts" json:"canPorts,omitempty"`
+	Port                *string                        `yaml:"port" json:"port,omitempty"`
+	AutoSetup           *bool                          `yaml:"autoSetup" json:"autoSetup,omitempty"`
+	Bitrate             *int                           `yaml:"bitrate" json:"bitrate,omitempty"`
+	SamplePoint         *string                        `yaml:"samplePoint" json:"samplePoint,omitempty"`
+	RestartMs           *int                           `yaml:"restartMs" json:"restartMs,omitempty"`
+	SetupRetry          *int                           `yaml:"setupRetry" json:"setupRetry,omitempty"`
+	SetupDelay          *int                           `yaml:"setupDelay" json:"setupDelay,omitempty"` // seconds
+	EnableFinder        *bool                          `yaml:"enableFinder" json:"enableFinder,omitempty"`
+	FinderInterval      *int                           `yaml:"finderInterval" json:"finderInterval,omitempty"` // seconds
+	EnableHealthCheck   *bool                          `yaml:"enableHealthCheck" json:"enableHealthCheck,omitempty"`
+	AsyncSend           *bool                          `yaml:"asyncSend" json:"asyncSend,omitempty"`
+	SendQueueSize       *int                           `yaml:"sendQueueSize" json:"sendQueueSize,omitempty"`
+	ActiveHealthProbe   *bool                          `yaml:"activeHealthProbe" json:"activeHealthProbe,omitempty"`
+	AlertWebhookURL     *string                        `yaml:"alertWebhookUrl" json:"alertWebhookUrl,omitempty"`
+	AlertCooldown       *int                           `yaml:"alertCooldown" json:"alertCooldown,omitempty"` // seconds
+	MaxMessages         *int                           `yaml:"maxMessages" json:"maxMessages,omitempty"`
+	MaxListeners        *int                           `yaml:"maxListeners" json:"maxListeners,omitempty"`
+	WatchdogInterval    *int                           `yaml:"watchdogInterval" json:"watchdogInterval,omitempty"` // seconds
+	WatchdogMaxRecovery *int                           `yaml:"watchdogMaxRecovery" json:"watchdogMaxRecovery,omitempty"`
+	WatchdogExclude     []string                       `yaml:"watchdogExclude" json:"watchdogExclude,omitempty"`
+	AllowVirtual        *bool                          `yaml:"allowVirtual" json:"allowVirtual,omitempty"`
+	NoSetup             *bool                          `yaml:"noSetup" json:"noSetup,omitempty"`
+	HTTPReadTimeout     *int                           `yaml:"httpReadTimeout" json:"httpReadTimeout,omitempty"`   // seconds
+	HTTPWriteTimeout    *int                           `yaml:"httpWriteTimeout" json:"httpWriteTimeout,omitempty"` // seconds
+	HTTPIdleTimeout     *int                           `yaml:"httpIdleTimeout" json:"httpIdleTimeout,omitempty"`   // seconds
+	HTTPMaxBodyBytes    *int64                         `yaml:"httpMaxBodyBytes" json:"httpMaxBodyBytes,omitempty"`
+	ListenAddr          *string                        `yaml:"listenAddr" json:"listenAddr,omitempty"`
+	RxBufferBytes       *int                           `yaml:"rxBufferBytes" json:"rxBufferBytes,omitempty"`
+	FrameSocket         *string                        `yaml:"frameSocket" json:"frameSocket,omitempty"`
+	FrameSocketFormat   *string                        `yaml:"frameSocketFormat" json:"frameSocketFormat,omitempty"`
+	HealthProbeID       *string                        `yaml:"healthProbeId" json:"healthProbeId,omitempty"`
+	HealthProbeData     *string                        `yaml:"healthProbeData" json:"healthProbeData,omitempty"`
+	LatencyWindow       *int                           `yaml:"latencyWindow" json:"latencyWindow,omitempty"`
+	CollapseDuplicates  *bool                          `yaml:"collapseDuplicates" json:"collapseDuplicates,omitempty"`
+	ErrorMask           *string                        `yaml:"errorMask" json:"errorMask,omitempty"`
+	SendRetries         *int                           `yaml:"sendRetries" json:"sendRetries,omitempty"`
+	SendRetryBackoff    *int                           `yaml:"sendRetryBackoff" json:"sendRetryBackoff,omitempty"` // milliseconds
+	TxPadToDLC8         *bool                          `yaml:"txPadToDlc8" json:"txPadToDlc8,omitempty"`
+	TxPadByte           *string                        `yaml:"txPadByte" json:"txPadByte,omitempty"`
+	Debug               *bool                          `yaml:"debug" json:"debug,omitempty"`
+	PprofAddr           *string                        `yaml:"pprofAddr" json:"pprofAddr,omitempty"`
+	DryRun              *bool                          `yaml:"dryRun" json:"dryRun,omitempty"`
+	RxStaleAfter        *int                           `yaml:"rxStaleAfter" json:"rxStaleAfter,omitempty"` // seconds
+	MinRxRate           *int                           `yaml:"minRxRate" json:"minRxRate,omitempty"`
+	TrustedProxies      []string                       `yaml:"trustedProxies" json:"trustedProxies,omitempty"`
+	StateCacheTTL       *int                           `yaml:"stateCacheTtl" json:"stateCacheTtl,omitempty"` // milliseconds
+	FinderBroadcastAddr *string                        `yaml:"finderBroadcastAddr" json:"finderBroadcastAddr,omitempty"`
+	FinderInterface     *string                        `yaml:"finderInterface" json:"finderInterface,omitempty"`
+	DeviceID            *string                        `yaml:"deviceId" json:"deviceId,omitempty"`
+	DeviceName          *string                        `yaml:"deviceName" json:"deviceName,omitempty"`
+	DeviceModel         *string                        `yaml:"deviceModel" json:"deviceModel,omitempty"`
+	DeviceLocation      *string                        `yaml:"deviceLocation" json:"deviceLocation,omitempty"`
+	DeviceTags          []string                       `yaml:"deviceTags" json:"deviceTags,omitempty"`
+	InfluxURL           *string                        `yaml:"influxUrl" json:"influxUrl,omitempty"`
+	InfluxBucket        *string                        `yaml:"influxBucket" json:"influxBucket,omitempty"`
+	InfluxOrg           *string                        `yaml:"influxOrg" json:"influxOrg,omitempty"`
+	InfluxToken         *string                        `yaml:"influxToken" json:"influxToken,omitempty"`
+	InfluxInterval      *int                           `yaml:"influxInterval" json:"influxInterval,omitempty"` // seconds
+	DefaultInterface    *string                        `yaml:"defaultInterface" json:"defaultInterface,omitempty"`
+	SnapshotOnExit      *string                        `yaml:"snapshotOnExit" json:"snapshotOnExit,omitempty"`
+	RestoreSnapshot     *bool                          `yaml:"restoreSnapshot" json:"restoreSnapshot,omitempty"`
+	Interfaces          map[string]InterfaceFileConfig `yaml:"interfaces" json:"interfaces,omitempty"`
+}
+
+// InterfaceFileConfig holds per-interface overrides of the global setup parameters
+type InterfaceFileConfig struct {
+	Bitrate     *int    `yaml:"bitrate" json:"bitrate,omitempty"`
+	SamplePoint *string `yaml:"samplePoint" json:"samplePoint,omitempty"`
+	RestartMs   *int    `yaml:"restartMs" json:"restartMs,omitempty"`
+	MinRxRate   *int    `yaml:"minRxRate" json:"minRxRate,omitempty"` // Overrides -min-rx-rate
+}
+
+// LoadConfigFile reads a YAML or JSON configuration file.
+// Unknown keys are rejected and reported with their line number.
+func LoadConfigFile(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	fc := &FileConfig{}
+	if err := DecodeFileConfig(data, fc); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return fc, nil
+}
+
+// DecodeFileConfig decodes a YAML or JSON document into fc. Keys present in the
+// document replace the values already in fc; all other fields are left as they are.
+func DecodeFileConfig(data []byte, fc *FileConfig) error {
+	// JSON is a subset of YAML, so a single strict YAML decoder handles both
+	// and reports line numbers for either format.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(fc); err != nil && !errors.Is(err, io.EOF) { // io.EOF: empty document
+		return err
+	}
+	return nil
+}
+
+// FileConfig returns the configuration as a complete file document, usable as a
+// -config file or with /api/config/import. The alert webhook URL and the InfluxDB
+// token are left out because they are credentials.
+func (c *Config) FileConfig() *FileConfig {
+	fc := &FileConfig{
+		CanPorts:            slices.Clone(c.CanPorts),
+		Port:                valuePtr(c.Port),
+		AutoSetup:           valuePtr(c.AutoSetup),
+		Bitrate:             valuePtr(c.Bitrate),
+		SamplePoint:         valuePtr(c.SamplePoint),
+		RestartMs:           valuePtr(c.RestartMs),
+		SetupRetry:          valuePtr(c.SetupRetry),
+		SetupDelay:          valuePtr(int(c.SetupDelay / time.Second)),
+		EnableFinder:        valuePtr(c.EnableFinder),
+		FinderInterval:      valuePtr(int(c.SetupFinderInterval / time.Second)),
+		EnableHealthCheck:   valuePtr(c.EnableHealthCheck),
+		AsyncSend:           valuePtr(c.AsyncSend),
+		SendQueueSize:       valuePtr(c.SendQueueSize),
+		ActiveHealthProbe:   valuePtr(c.ActiveHealthProbe),
+		AlertCooldown:       valuePtr(int(c.AlertCooldown / time.Second)),
+		MaxMessages:         valuePtr(c.MaxMessages),
+		MaxListeners:        valuePtr(c.MaxListeners),
+		WatchdogInterval:    valuePtr(int(c.WatchdogInterval / time.Second)),
+		WatchdogMaxRecovery: valuePtr(c.WatchdogMaxRecovery),
+		WatchdogExclude:     slices.Clone(c.WatchdogExclude),
+		AllowVirtual:        valuePtr(c.AllowVirtual),
+		NoSetup:             valuePtr(c.NoSetup),
+		HTTPReadTimeout:     valuePtr(int(c.HTTPReadTimeout / time.Second)),
+		HTTPWriteTimeout:    valuePtr(int(c.HTTPWriteTimeout / time.Second)),
+		HTTPIdleTimeout:     valuePtr(int(c.HTTPIdleTimeout / time.Second)),
+		HTTPMaxBodyBytes:    valuePtr(c.HTTPMaxBodyBytes),
+		ListenAddr:          valuePtr(c.ListenAddr),
+		RxBufferBytes:       valuePtr(c.RxBufferBytes),
+		FrameSocket:         valuePtr(c.FrameSocket),
+		FrameSocketFormat:   valuePtr(c.FrameSocketFormat),
+		HealthProbeID:       valuePtr(fmt.Sprintf("0x%X", c.HealthProbeID)),
+		HealthProbeData:     valuePtr(hex.EncodeToString(c.HealthProbeData)),
+		LatencyWindow:       valuePtr(c.LatencyWindow),
+		CollapseDuplicates:  valuePtr(c.CollapseDuplicates),
+		ErrorMask:           valuePtr(fmt.Sprintf("0x%X", c.ErrorMask)),
+		SendRetries:         valuePtr(c.SendRetries),
+		SendRetryBackoff:    valuePtr(int(c.SendRetryBackoff / time.Millisecond)),
+		TxPadToDLC8:         valuePtr(c.TxPadToDLC8),
+		TxPadByte:           valuePtr(fmt.Sprintf("0x%02X", c.TxPadByte)),
+		Debug:               valuePtr(c.Debug),
+		PprofAddr:           valuePtr(c.PprofAddr),
+		DryRun:              valuePtr(c.DryRun),
+		RxStaleAfter:        valuePtr(int(c.RxStaleAfter / time.Second)),
+		MinRxRate:           valuePtr(c.MinRxRate),
+		TrustedProxies:      slices.Clone(c.TrustedProxies),
+		StateCacheTTL:       valuePtr(int(c.StateCacheTTL / time.Millisecond)),
+		FinderBroadcastAddr: valuePtr(c.FinderBroadcastAddr),
+		FinderInterface:     valuePtr(c.FinderInterface),
+		DeviceID:            valuePtr(c.DeviceID),
+		DeviceName:          valuePtr(c.DeviceName),
+		DeviceModel:         valuePtr(c.DeviceModel),
+		DeviceLocation:      valuePtr(c.DeviceLocation),
+		DeviceTags:          slices.Clone(c.DeviceTags),
+		InfluxURL:           valuePtr(c.InfluxURL),
+		InfluxBucket:        valuePtr(c.InfluxBucket),
+		InfluxOrg:           valuePtr(c.InfluxOrg),
+		InfluxInterval:      valuePtr(int(c.InfluxInterval / time.Second)),
+		DefaultInterface:    valuePtr(c.DefaultInterface),
+		SnapshotOnExit:      valuePtr(c.SnapshotOnExit),
+		RestoreSnapshot:     valuePtr(c.RestoreSnapshot),
+		Interfaces:          make(map[string]InterfaceFileConfig, len(c.Interfaces)),
+	}
+	for ifName, ifConfig := range c.Interfaces {
+		fc.Interfaces[ifName] = InterfaceFileConfig{
+			Bitrate:     valuePtr(ifConfig.Bitrate),
+			SamplePoint: valuePtr(ifConfig.SamplePoint),
+			RestartMs:   valuePtr(ifConfig.RestartMs),
+		}
+	}
+	for ifName, rate := range c.MinRxRates {
+		ifConfig := fc.Interfaces[ifName]
+		ifConfig.MinRxRate = valuePtr(rate)
+		fc.Interfaces[ifName] = ifConfig
+	}
+	return fc
+}
+
+// valuePtr returns a pointer to a copy of v
+func valuePtr[T any](v T) *T {
+	return &v
+}
+
+// hasSetupOverride reports whether the entry overrides any interface setup parameter
+func (ifc InterfaceFileConfig) hasSetupOverride() bool {
+	return ifc.Bitrate != nil || ifc.SamplePoint != nil || ifc.RestartMs != nil
+}
+
+// applyTo overrides setup parameters in cfg with the values set in the file
+func (ifc InterfaceFileConfig) applyTo(cfg InterfaceSetupConfig) InterfaceSetupConfig {
+	if ifc.Bitrate != nil {
+		cfg.Bitrate = *ifc.Bitrate
+	}
+	if ifc.SamplePoint != nil {
+		cfg.SamplePoint = *ifc.SamplePoint
+	}
+	if ifc.RestartMs != nil {
+		cfg.RestartMs = *ifc.RestartMs
+	}
+	return cfg
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxErrorFramesPerInterface caps the error frames kept for each interface
+const maxErrorFramesPerInterface = 256
+
+// CanErrorLog is a decoded CAN error frame (linux/can/error.h)
+type CanErrorLog struct {
+	Interface   string    `json:"interface"`
+	Timestamp   time.Time `json:"timestamp"`
+	Classes     []string  `json:"classes"`              // Error classes from the ID, e.g. "bus-off", "ack"
+	Controller  []string  `json:"controller,omitempty"` // Controller state details, e.g. "tx-passive"
+	Protocol    []string  `json:"protocol,omitempty"`   // Protocol violation types, e.g. "stuff"
+	Location    string    `json:"location,omitempty"`   // Where in the frame a protocol violation happened
+	Transceiver string    `json:"transceiver,omitempty"`
+	LostArbBit  uint8     `json:"lostArbitrationBit,omitempty"` // Bit at which arbitration was lost, if known
+	TxErrors    *uint8    `json:"txErrors,omitempty"`           // Error counters, when the driver reports them
+	RxErrors    *uint8    `json:"rxErrors,omitempty"`
+	HEX_ID      string    `json:"hex_id"`
+	HEX_Data    []string  `json:"hex_data"`
+}
+
+// canErrorClassNames maps error class bits in the ID to names
+var canErrorClassNames = []struct {
+	bit  uint32
+	name string
+}{
+	{unix.CAN_ERR_TX_TIMEOUT, "tx-timeout"},
+	{unix.CAN_ERR_LOSTARB, "lost-arbitration"},
+	{unix.CAN_ERR_CRTL, "controller"},
+	{unix.CAN_ERR_PROT, "protocol"},
+	{unix.CAN_ERR_TRX, "transceiver"},
+	{unix.CAN_ERR_ACK, "ack"},
+	{unix.CAN_ERR_BUSOFF, "bus-off"},
+	{unix.CAN_ERR_BUSERROR, "bus-error"},
+	{unix.CAN_ERR_RESTARTED, "restarted"},
+	{unix.CAN_ERR_CNT, "counters"},
+}
+
+// canErrorControllerNames maps data[1] bits to controller states
+var canErrorControllerNames = []struct {
+	bit  uint8
+	name string
+}{
+	{unix.CAN_ERR_CRTL_RX_OVERFLOW, "rx-overflow"},
+	{unix.CAN_ERR_CRTL_TX_OVERFLOW, "tx-overflow"},
+	{unix.CAN_ERR_CRTL_RX_WARNING, "rx-warning"},
+	{unix.CAN_ERR_CRTL_TX_WARNING, "tx-warning"},
+	{unix.CAN_ERR_CRTL_RX_PASSIVE, "rx-passive"},
+	{unix.CAN_ERR_CRTL_TX_PASSIVE, "tx-passive"},
+	{unix.CAN_ERR_CRTL_ACTIVE, "active"},
+}
+
+// canErrorProtocolNames maps data[2] bits to protocol violation types
+var canErrorProtocolNames = []struct {
+	bit  uint8
+	name string
+}{
+	{unix.CAN_ERR_PROT_BIT, "bit"},
+	{unix.CAN_ERR_PROT_FORM, "form"},
+	{unix.CAN_ERR_PROT_STUFF, "stuff"},
+	{unix.CAN_ERR_PROT_BIT0, "bit0"},
+	{unix.CAN_ERR_PROT_BIT1, "bit1"},
+	{unix.CAN_ERR_PROT_OVERLOAD, "overload"},
+	{unix.CAN_ERR_PROT_ACTIVE, "active-error-announcement"},
+	{unix.CAN_ERR_PROT_TX, "tx"},
+}
+
+// canErrorLocationNames maps data[3] to the frame position of a protocol violation
+var canErrorLocationNames = map[uint8]string{
+	unix.CAN_ERR_PROT_LOC_SOF:     "start-of-frame",
+	unix.CAN_ERR_PROT_LOC_ID28_21: "id-28-21",
+	unix.CAN_ERR_PROT_LOC_ID20_18: "id-20-18",
+	unix.CAN_ERR_PROT_LOC_SRTR:    "srtr",
+	unix.CAN_ERR_PROT_LOC_IDE:     "ide",
+	unix.CAN_ERR_PROT_LOC_ID17_13: "id-17-13",
+	unix.CAN_ERR_PROT_LOC_CRC_SEQ: "crc-sequence",
+	unix.CAN_ERR_PROT_LOC_RES0:    "reserved-0",
+	unix.CAN_ERR_PROT_LOC_DATA:    "data",
+	unix.CAN_ERR_PROT_LOC_DLC:     "dlc",
+	unix.CAN_ERR_PROT_LOC_RTR:     "rtr",
+	unix.CAN_ERR_PROT_LOC_RES1:    "reserved-1",
+	unix.CAN_ERR_PROT_LOC_ID04_00: "id-04-00",
+	unix.CAN_ERR_PROT_LOC_ID12_05: "id-12-05",
+	unix.CAN_ERR_PROT_LOC_INTERM:  "intermission",
+	unix.CAN_ERR_PROT_LOC_CRC_DEL: "crc-delimiter",
+	unix.CAN_ERR_PROT_LOC_ACK:     "ack-slot",
+	unix.CAN_ERR_PROT_LOC_EOF:     "end-of-frame",
+	unix.CAN_ERR_PROT_LOC_ACK_DEL: "ack-delimiter",
+}
+
+// decodeErrorFrame turns a received error frame into a CanErrorLog
+func decodeErrorFrame(interfaceName string, frame *bufferedFrame) CanErrorLog {
+	var data [8]byte
+	copy(data[:], frame.Data[:frame.Length])
+	class := frame.ID & unix.CAN_ERR_MASK
+
+	result := CanErrorLog{
+		Interface: interfaceName,
+		Timestamp: frame.Timestamp,
+		Classes:   []string{},
+		HEX_ID:    fmt.Sprintf("%08x", frame.ID),
+		HEX_Data:  bytesToHexArray(frame.Data[:frame.Length]),
+	}
+
+	for _, c := range canErrorClassNames {
+		if class&c.bit != 0 {
+			result.Classes = append(result.Classes, c.name)
+		}
+	}
+	if class&unix.CAN_ERR_LOSTARB != 0 {
+		result.LostArbBit = data[0]
+	}
+	if class&unix.CAN_ERR_CRTL != 0 {
+		for _, c := range canErrorControllerNames {
+			if data[1]&c.bit != 0 {
+				result.Controller = append(result.Controller, c.name)
+			}
+		}
+	}
+	if class&unix.CAN_ERR_PROT != 0 {
+		for _, c := range canErrorProtocolNames {
+			if data[2]&c.bit != 0 {
+				result.Protocol = append(result.Protocol, c.name)
+			}
+		}
+		result.Location = canErrorLocationNames[data[3]]
+	}
+	if class&unix.CAN_ERR_TRX != 0 && data[4] != unix.CAN_ERR_TRX_UNSPEC {
+		result.Transceiver = fmt.Sprintf("0x%02x", data[4])
+	}
+	if class&unix.CAN_ERR_CNT != 0 {
+		tx, rx := data[6], data[7]
+		result.TxErrors, result.RxErrors = &tx, &rx
+	}
+
+	return result
+}
+
+// errorFrameLog keeps the most recent decoded error frames of one interface
+type errorFrameLog struct {
+	entries []CanErrorLog
+	total   uint64
+	mu      sync.RWMutex
+}
+
+// add appends an error frame, dropping the oldest beyond maxErrorFramesPerInterface, and returns the new total
+func (l *errorFrameLog) add(entry CanErrorLog) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total++
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > maxErrorFramesPerInterface {
+		l.entries = append([]CanErrorLog(nil), l.entries[len(l.entries)-maxErrorFramesPerInterface:]...)
+	}
+	return l.total
+}
+
+// recent returns copies of the last limit error frames (0 means all kept) and the lifetime total
+func (l *errorFrameLog) recent(limit int) ([]CanErrorLog, uint64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := l.entries
+	if limit > 0 && limit < len(entries) {
+		entries = entries[len(entries)-limit:]
+	}
+	return append([]CanErrorLog{}, entries...), l.total
+}
+
+// reset forgets all error frames
+func (l *errorFrameLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = nil
+	l.total = 0
+}
+
+// count returns the lifetime number of error frames
+func (l *errorFrameLog) count() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.total
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// IDRange is an inclusive range of frame identifiers; a single ID has Min == Max
+type IDRange struct {
+	Min uint32 `json:"min"`
+	Max uint32 `json:"max"`
+}
+
+// Contains reports whether an identifier lies in the range
+func (r IDRange) Contains(id uint32) bool {
+	return id >= r.Min && id <= r.Max
+}
+
+// UnmarshalJSON accepts {"min": 256, "max": 511}, a plain ID number, or a string in the
+// query parameter form ("0x100" or "0x100-0x1FF")
+func (r *IDRange) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		parsed, err := parseIDRange(text)
+		if err != nil {
+			return err
+		}
+		*r = parsed
+		return nil
+	}
+
+	var id uint32
+	if err := json.Unmarshal(data, &id); err == nil {
+		*r = IDRange{Min: id, Max: id}
+		return nil
+	}
+
+	type plain IDRange // Avoids recursing into this method
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("invalid ID range %s: want an ID, \"min-max\" or {\"min\", \"max\"}", data)
+	}
+	*r = IDRange(obj)
+	return nil
+}
+
+// parseIDRange parses "ID" or "MIN-MAX", each ID as hex with a "0x" prefix or decimal
+func parseIDRange(s string) (IDRange, error) {
+	minStr, maxStr, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	if !isRange {
+		maxStr = minStr
+	}
+
+	low, err := parseCanID(strings.TrimSpace(minStr))
+	if err != nil {
+		return IDRange{}, fmt.Errorf("invalid ID range %q: %w", s, err)
+	}
+	high, err := parseCanID(strings.TrimSpace(maxStr))
+	if err != nil {
+		return IDRange{}, fmt.Errorf("invalid ID range %q: %w", s, err)
+	}
+	return IDRange{Min: low, Max: high}, nil
+}
+
+// parseIDRanges parses a comma-separated list of IDs and ID ranges
+func parseIDRanges(list string) ([]IDRange, error) {
+	var ranges []IDRange
+	for _, item := range splitList(list) {
+		r, err := parseIDRange(item)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// FilterSpec selects frames in software. It is the one filter expression shared by message
+// queries, subscriptions and the frame socket, so a filter means the same thing everywhere.
+// Ranges are compared against the identifier without the EFF/RTR/ERR flag bits.
+type FilterSpec struct {
+	Include      []IDRange `json:"include,omitempty"`      // A frame must be in one of these; empty includes every ID
+	Exclude      []IDRange `json:"exclude,omitempty"`      // A frame in any of these is rejected, even if included
+	ExtendedOnly bool      `json:"extendedOnly,omitempty"` // Only 29-bit frames
+	StandardOnly bool      `json:"standardOnly,omitempty"` // Only 11-bit frames
+}
+
+// exactIDFilter returns a filter matching a single raw can_id, including its frame type
+func exactIDFilter(canID uint32) *FilterSpec {
+	id := canID & unix.CAN_EFF_MASK
+	extended := canID&unix.CAN_EFF_FLAG != 0
+	return &FilterSpec{Include: []IDRange{{Min: id, Max: id}}, ExtendedOnly: extended, StandardOnly: !extended}
+}
+
+// Validate checks that every range is ordered and the frame type options do not conflict
+func (s *FilterSpec) Validate() error {
+	if s.ExtendedOnly && s.StandardOnly {
+		return fmt.Errorf("extendedOnly and standardOnly cannot both be set")
+	}
+	for _, r := range slices.Concat(s.Include, s.Exclude) {
+		if r.Min > r.Max {
+			return fmt.Errorf("invalid ID range: min 0x%X is greater than max 0x%X", r.Min, r.Max)
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the filter passes every frame
+func (s *FilterSpec) IsEmpty() bool {
+	return s == nil || (len(s.Include) == 0 && len(s.Exclude) == 0 && !s.ExtendedOnly && !s.StandardOnly)
+}
+
+// Matches reports whether a raw can_id passes the filter; a nil filter matches everything
+func (s *FilterSpec) Matches(canID uint32) bool {
+	if s == nil {
+		return true
+	}
+
+	extended := canID&unix.CAN_EFF_FLAG != 0
+	if (s.ExtendedOnly && !extended) || (s.StandardOnly && extended) {
+		return false
+	}
+
+	id := canID & unix.CAN_EFF_MASK
+	if len(s.Include) > 0 && !rangesContain(s.Include, id) {
+		return false
+	}
+	return !rangesContain(s.Exclude, id)
+}
+
+// rangesContain reports whether id lies in any of the ranges
+func rangesContain(ranges []IDRange, id uint32) bool {
+	for _, r := range ranges {
+		if r.Contains(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the filter in the query parameter form, for logs
+func (s *FilterSpec) String() string {
+	if s.IsEmpty() {
+		return "all frames"
+	}
+
+	var parts []string
+	if len(s.Include) > 0 {
+		parts = append(parts, "include="+formatIDRanges(s.Include))
+	}
+	if len(s.Exclude) > 0 {
+		parts = append(parts, "exclude="+formatIDRanges(s.Exclude))
+	}
+	if s.ExtendedOnly {
+		parts = append(parts, "extendedOnly")
+	}
+	if s.StandardOnly {
+		parts = append(parts, "standardOnly")
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatIDRanges renders ranges as a comma-separated list of hex IDs and ID ranges
+func formatIDRanges(ranges []IDRange) string {
+	items := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.Min == r.Max {
+			items[i] = fmt.Sprintf("0x%X", r.Min)
+		} else {
+			items[i] = fmt.Sprintf("0x%X-0x%X", r.Min, r.Max)
+		}
+	}
+	return strings.Join(items, ",")
+}
@@ -1,75 +1,402 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+
+	"golang.org/x/sys/unix"
 )
 
 // DeviceInfo represents information about the device
 type DeviceInfo struct {
-	Name    string `json:"name"`
-	IP      string `json:"ip"`
-	MAC     string `json:"mac"`
-	Model   string `json:"model"`
-	Version string `json:"version"`
+	ID       string   `json:"id"` // Stable across IPs, so clients can dedupe a device seen on several networks
+	Name     string   `json:"name"`
+	IP       string   `json:"ip"`
+	MAC      string   `json:"mac"`
+	Model    string   `json:"model"`
+	Version  string   `json:"version"`
+	Location string   `json:"location,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// Default device identity announced by the finder
+const (
+	DefaultDeviceName  = "Can-Bridge"
+	DefaultDeviceModel = "LinkerHand OSS"
+)
+
+// DeviceIdentity is the configurable part of the announced device information
+type DeviceIdentity struct {
+	ID       string // Empty derives the ID from the advertised MAC address
+	Name     string
+	Model    string
+	Location string
+	Tags     []string
+}
+
+// deviceID returns the configured ID, or one derived from the MAC address (or the hostname
+// when the interface has no MAC)
+func (d DeviceIdentity) deviceID(mac string) string {
+	if d.ID != "" {
+		return d.ID
+	}
+	if id := strings.ToLower(strings.ReplaceAll(mac, ":", "")); id != "" {
+		return id
+	}
+	hostname, _ := os.Hostname()
+	return hostname
 }
 
-func NodeFinder(interval time.Duration) {
-	broadcastAddr := "255.255.255.255:9999"
+// MinFinderInterval is the shortest allowed broadcast interval. Every device on the segment
+// receives each broadcast, so a tighter loop would only flood the network.
+const MinFinderInterval = time.Second
+
+// DefaultFinderBroadcastAddr is the limited broadcast address and port clients listen on
+const DefaultFinderBroadcastAddr = "255.255.255.255:9999"
+
+// Finder periodically broadcasts device information so clients can discover the service
+type Finder struct {
+	interval      time.Duration
+	broadcastAddr string
+	netInterface  string // Network interface to broadcast on; empty picks one automatically
+	identity      DeviceIdentity
+	lastErr       string             // Error of the latest setup or broadcast attempt, empty after a success
+	cancel        context.CancelFunc // Stops the running broadcast loop; nil while stopped
+	done          chan struct{}      // Closed when the broadcast loop has exited
+	wake          chan struct{}      // Cuts the current wait short after an interval change
+	mu            sync.RWMutex
+}
+
+// FinderStatus reports whether the finder is broadcasting, where to and how often
+type FinderStatus struct {
+	Enabled       bool   `json:"enabled"`
+	Interval      string `json:"interval"`
+	BroadcastAddr string `json:"broadcastAddr"`
+	Interface     string `json:"interface,omitempty"` // Empty when picked automatically
+	LastError     string `json:"lastError,omitempty"` // Why the latest attempt to broadcast failed
+}
 
-	conn, err := net.DialUDP("udp4", nil, resolveUDPAddr(broadcastAddr))
+// NewFinder creates a new, stopped finder broadcasting to broadcastAddr at the given interval
+func NewFinder(interval time.Duration, broadcastAddr string) *Finder {
+	return &Finder{
+		interval:      max(interval, MinFinderInterval),
+		broadcastAddr: broadcastAddr,
+		identity:      DeviceIdentity{Name: DefaultDeviceName, Model: DefaultDeviceModel},
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// validateBroadcastAddr checks that addr is an IPv4 address and port, e.g. a directed
+// broadcast address such as 192.168.1.255:9999
+func validateBroadcastAddr(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to broadcast address: %v", err)
+		return fmt.Errorf("invalid finder broadcast address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.To4() == nil {
+		return fmt.Errorf("invalid finder broadcast address %q: host must be an IPv4 address", addr)
 	}
-	defer conn.Close()
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid finder broadcast address %q: port must be 1-65535", addr)
+	}
+	return nil
+}
+
+// SetInterval changes the broadcast interval, raised to MinFinderInterval if shorter;
+// a running finder broadcasts again right away and then waits the new interval
+func (f *Finder) SetInterval(interval time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.interval = max(interval, MinFinderInterval)
+
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SetBroadcastAddr changes where broadcasts are sent; a running finder is restarted to use it
+func (f *Finder) SetBroadcastAddr(addr string) {
+	f.mu.Lock()
+	f.broadcastAddr = addr
+	f.mu.Unlock()
+
+	f.restartIfRunning()
+}
+
+// SetNetworkInterface changes the network interface broadcasts leave through (empty picks one
+// automatically); a running finder is restarted to use it
+func (f *Finder) SetNetworkInterface(ifName string) {
+	f.mu.Lock()
+	f.netInterface = ifName
+	f.mu.Unlock()
+
+	f.restartIfRunning()
+}
+
+// SetIdentity changes the announced device identity; a running finder is restarted to use it
+func (f *Finder) SetIdentity(identity DeviceIdentity) {
+	f.mu.Lock()
+	f.identity = identity
+	f.mu.Unlock()
+
+	f.restartIfRunning()
+}
+
+// getIdentity returns the announced device identity
+func (f *Finder) getIdentity() DeviceIdentity {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.identity
+}
+
+// GetNetworkInterface returns the configured network interface, empty when picked automatically
+func (f *Finder) GetNetworkInterface() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.netInterface
+}
+
+// getLastError returns the error of the latest setup or broadcast attempt
+func (f *Finder) getLastError() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.lastErr
+}
+
+// restartIfRunning restarts a running finder so it picks up a new address or interface
+func (f *Finder) restartIfRunning() {
+	if f.IsRunning() {
+		f.Stop()
+		f.Start()
+	}
+}
+
+// GetBroadcastAddr returns the address broadcasts are sent to
+func (f *Finder) GetBroadcastAddr() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.broadcastAddr
+}
+
+// GetInterval returns the current broadcast interval
+func (f *Finder) GetInterval() time.Duration {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.interval
+}
+
+// Start begins broadcasting in the background; it does nothing if the finder is already running
+func (f *Finder) Start() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cancel != nil {
+		return
+	}
+
+	// Drop a wake-up left over from an interval change while stopped
+	select {
+	case <-f.wake:
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go f.run(ctx, f.done)
+}
 
-	localIP, mac := getLocalIPAndMAC()
+// Stop ends broadcasting and waits for the broadcast loop to exit
+func (f *Finder) Stop() {
+	f.mu.Lock()
+	cancel, done := f.cancel, f.done
+	f.cancel = nil
+	f.done = nil
+	f.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// IsRunning reports whether the finder is broadcasting
+func (f *Finder) IsRunning() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.cancel != nil
+}
+
+// GetStatus returns the current finder state
+func (f *Finder) GetStatus() FinderStatus {
+	return FinderStatus{
+		Enabled:       f.IsRunning(),
+		Interval:      f.GetInterval().String(),
+		BroadcastAddr: f.GetBroadcastAddr(),
+		Interface:     f.GetNetworkInterface(),
+		LastError:     f.getLastError(),
+	}
+}
+
+// run broadcasts device information until ctx is cancelled. Network discovery failures are
+// logged and retried with backoff; they never stop the rest of the service.
+func (f *Finder) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	backoff := MinFinderInterval
+	for {
+		conn, device, err := f.connect()
+		if err == nil {
+			f.setLastError(nil)
+			f.broadcast(ctx, conn, device)
+			conn.Close()
+			return
+		}
+
+		f.setLastError(err)
+		log.Printf("⚠️ Finder cannot broadcast, retrying in %v: %v", backoff, err)
+		if !f.wait(ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, maxFinderBackoff)
+	}
+}
+
+// maxFinderBackoff caps the wait between attempts to set up broadcasting
+const maxFinderBackoff = time.Minute
+
+// connect discovers the network interface to advertise and opens the broadcast socket
+func (f *Finder) connect() (*net.UDPConn, DeviceInfo, error) {
+	wanted := f.GetNetworkInterface()
+	ifName, localIP, mac, err := getLocalIPAndMAC(wanted)
+	if err != nil {
+		return nil, DeviceInfo{}, err
+	}
+	if wanted == "" {
+		log.Printf("📡 Finder auto-selected network interface %s (%s)", ifName, localIP)
+	}
+
+	conn, err := dialBroadcast(f.GetBroadcastAddr(), wanted, localIP)
+	if err != nil {
+		return nil, DeviceInfo{}, fmt.Errorf("failed to connect to broadcast address: %w", err)
+	}
+
+	identity := f.getIdentity()
 	device := DeviceInfo{
-		Name:    "Can-Bridge",
-		IP:      localIP,
-		MAC:     mac,
-		Model:   "LinkerHand OSS",
-		Version: VERSION,
+		ID:       identity.deviceID(mac),
+		Name:     identity.Name,
+		IP:       localIP.String(),
+		MAC:      mac,
+		Model:    identity.Model,
+		Version:  VERSION,
+		Location: identity.Location,
+		Tags:     identity.Tags,
 	}
+	return conn, device, nil
+}
 
+// broadcast sends device information every interval until ctx is cancelled
+func (f *Finder) broadcast(ctx context.Context, conn *net.UDPConn, device DeviceInfo) {
 	for {
 		data, err := json.Marshal(device)
 		if err != nil {
 			log.Printf("⚠️ JSON serialization error: %v", err)
-			continue
-		}
-
-		_, err = conn.Write(data)
-		if err != nil {
+		} else if _, err = conn.Write(data); err != nil {
+			f.setLastError(err)
 			log.Printf("❌ Broadcast failed: %v", err)
 		} else {
+			f.setLastError(nil)
 			log.Printf("📡 Broadcast successful: %s", string(data))
 		}
 
-		time.Sleep(interval * time.Second)
+		if !f.wait(ctx, f.GetInterval()) {
+			return
+		}
+	}
+}
+
+// wait blocks for d or until the interval is changed; it returns false once ctx is cancelled
+func (f *Finder) wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		log.Printf("🔕 Finder stopped")
+		return false
+	case <-f.wake:
+	case <-timer.C:
+	}
+	return true
+}
+
+// setLastError records the outcome of the latest setup or broadcast attempt
+func (f *Finder) setLastError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastErr = ""
+	if err != nil {
+		f.lastErr = err.Error()
 	}
 }
 
-// resolveUDPAddr resolves a UDP address from string
-func resolveUDPAddr(addr string) *net.UDPAddr {
-	udpAddr, err := net.ResolveUDPAddr("udp4", addr)
+// dialBroadcast opens the UDP socket broadcasts are written to. With a network interface
+// given, the socket is bound to it and to its address so broadcasts leave through that NIC.
+func dialBroadcast(broadcastAddr, ifName string, localIP net.IP) (*net.UDPConn, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp4", broadcastAddr)
 	if err != nil {
-		log.Fatalf("❌ Failed to resolve address: %v", err)
+		return nil, fmt.Errorf("failed to resolve %s: %w", broadcastAddr, err)
 	}
-	return udpAddr
+
+	dialer := net.Dialer{}
+	if ifName != "" {
+		dialer.LocalAddr = &net.UDPAddr{IP: localIP}
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			if err := c.Control(func(fd uintptr) {
+				sockErr = unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE, ifName)
+			}); err != nil {
+				return err
+			}
+			if sockErr != nil {
+				return fmt.Errorf("failed to bind to %s: %w", ifName, sockErr)
+			}
+			return nil
+		}
+	}
+
+	conn, err := dialer.Dial("udp4", udpAddr.String())
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.UDPConn), nil
 }
 
-// getLocalIPAndMAC retrieves the IPv4 address and corresponding MAC address of the local device
-func getLocalIPAndMAC() (string, string) {
+// getLocalIPAndMAC retrieves the name, IPv4 address and MAC address of the network interface
+// to advertise: ifName if given, otherwise the first non-loopback interface that is up
+func getLocalIPAndMAC(ifName string) (string, net.IP, string, error) {
 	interfaces, err := net.Interfaces()
 	if err != nil {
-		log.Fatalf("❌ Failed to get network interfaces: %v", err)
+		return "", nil, "", fmt.Errorf("failed to get network interfaces: %w", err)
 	}
 
 	for _, iface := range interfaces {
+		if ifName != "" && iface.Name != ifName {
+			continue
+		}
+
 		// Skip invalid and loopback interfaces
 		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
 			continue
@@ -84,12 +411,15 @@ func getLocalIPAndMAC() (string, string) {
 			ipnet, ok := addr.(*net.IPNet)
 			if ok && ipnet.IP.To4() != nil {
 				mac := formatMACAddress(iface.HardwareAddr)
-				return ipnet.IP.String(), mac
+				return iface.Name, ipnet.IP.To4(), mac, nil
 			}
 		}
 	}
 
-	return "", ""
+	if ifName != "" {
+		return "", nil, "", fmt.Errorf("network interface %s is not up or has no IPv4 address", ifName)
+	}
+	return "", nil, "", fmt.Errorf("no network interface is up with an IPv4 address")
 }
 
 // formatMACAddress formats MAC address into a standard string representation
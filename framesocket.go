@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Frame socket encodings
+const (
+	FrameSocketFormatJSON = "json" // 4-byte big-endian length followed by a CanMessageLog JSON document
+	FrameSocketFormatRaw  = "raw"  // struct can_frame (16 bytes) or struct canfd_frame (72 bytes) in host byte order
+)
+
+// frameSocketClientQueue is how many encoded frames may wait for a slow client before frames are dropped
+const frameSocketClientQueue = 1024
+
+// FrameSocketPublisher streams every received frame to clients of a Unix domain socket
+type FrameSocketPublisher struct {
+	path            string
+	format          string
+	messageListener *CanMessageListener
+	logger          Logger
+
+	ln          net.Listener
+	unsubscribe func()
+	clients     map[*frameSocketClient]struct{}
+	closed      bool // Set by Stop; no new clients are registered afterwards
+	mu          sync.Mutex
+	wg          sync.WaitGroup
+}
+
+// frameSocketClient is one connected consumer with its own outgoing queue
+type frameSocketClient struct {
+	conn    net.Conn
+	queue   chan []byte
+	dropped uint64
+	filter  atomic.Pointer[FilterSpec] // Set by the client with a JSON line; nil streams every frame
+}
+
+// maxFrameSocketFilterBytes bounds one filter line sent by a client
+const maxFrameSocketFilterBytes = 64 * 1024
+
+// NewFrameSocketPublisher creates a publisher for the given socket path and encoding
+func NewFrameSocketPublisher(path, format string, messageListener *CanMessageListener, logger Logger) *FrameSocketPublisher {
+	return &FrameSocketPublisher{
+		path:            path,
+		format:          format,
+		messageListener: messageListener,
+		logger:          logger,
+		clients:         make(map[*frameSocketClient]struct{}),
+	}
+}
+
+// Start creates the socket file and begins accepting clients and publishing frames
+func (p *FrameSocketPublisher) Start() error {
+	// A socket file left behind by an unclean exit would make Listen fail
+	if info, err := os.Lstat(p.path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return fmt.Errorf("frame socket path %s exists and is not a socket", p.path)
+		}
+		if err := os.Remove(p.path); err != nil {
+			return fmt.Errorf("failed to remove stale frame socket %s: %w", p.path, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", p.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on frame socket %s: %w", p.path, err)
+	}
+	p.ln = ln
+
+	frames, unsubscribe := p.messageListener.Subscribe("", nil)
+	p.unsubscribe = unsubscribe
+
+	p.wg.Add(2)
+	go p.acceptLoop()
+	go p.publishLoop(frames)
+
+	p.logger.Printf("🔌 Streaming received frames on unix socket %s (%s)", p.path, p.format)
+	return nil
+}
+
+// Stop disconnects all clients and removes the socket file
+func (p *FrameSocketPublisher) Stop() {
+	if p.ln == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.unsubscribe()
+	p.ln.Close()
+
+	p.mu.Lock()
+	for client := range p.clients {
+		client.conn.Close()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	os.Remove(p.path)
+	p.logger.Printf("🔌 Frame socket %s closed", p.path)
+}
+
+// ClientCount returns the number of connected clients
+func (p *FrameSocketPublisher) ClientCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.clients)
+}
+
+// acceptLoop registers new clients until the listener is closed
+func (p *FrameSocketPublisher) acceptLoop() {
+	defer p.wg.Done()
+
+	for {
+		conn, err := p.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				p.logger.Printf("❌ Frame socket accept failed: %v", err)
+			}
+			return
+		}
+
+		client := &frameSocketClient{
+			conn:  conn,
+			queue: make(chan []byte, frameSocketClientQueue),
+		}
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			conn.Close()
+			return
+		}
+		p.clients[client] = struct{}{}
+		p.mu.Unlock()
+
+		p.wg.Add(2)
+		go p.writeLoop(client)
+		go p.readLoop(client)
+	}
+}
+
+// publishLoop encodes each received frame once and queues it for every client without blocking
+func (p *FrameSocketPublisher) publishLoop(frames <-chan CanMessageLog) {
+	defer p.wg.Done()
+
+	for msg := range frames {
+		var data []byte // Encoded once, when the first client wants the frame
+
+		p.mu.Lock()
+		for client := range p.clients {
+			if !client.filter.Load().Matches(msg.ID) {
+				continue
+			}
+			if data == nil {
+				var err error
+				if data, err = p.encode(msg); err != nil {
+					p.logger.Printf("❌ Failed to encode frame for frame socket: %v", err)
+					break
+				}
+			}
+			select {
+			case client.queue <- data:
+			default:
+				client.dropped++
+			}
+		}
+		p.mu.Unlock()
+	}
+
+	// Unsubscribed: let the writers finish
+	p.mu.Lock()
+	for client := range p.clients {
+		close(client.queue)
+		delete(p.clients, client)
+	}
+	p.mu.Unlock()
+}
+
+// writeLoop sends queued frames to one client; a write error disconnects only that client
+func (p *FrameSocketPublisher) writeLoop(client *frameSocketClient) {
+	defer p.wg.Done()
+	defer client.conn.Close()
+
+	for data := range client.queue {
+		if _, err := client.conn.Write(data); err != nil {
+			p.mu.Lock()
+			if _, exists := p.clients[client]; exists {
+				delete(p.clients, client)
+				close(client.queue)
+			}
+			dropped := client.dropped
+			p.mu.Unlock()
+
+			if dropped > 0 {
+				p.logger.Printf("🔌 Frame socket client disconnected (%d frame(s) dropped while it was slow)", dropped)
+			}
+			return
+		}
+	}
+}
+
+// readLoop applies filters sent by one client as newline-terminated JSON FilterSpec
+// documents, e.g. {"include": ["0x100-0x1FF"]}; {} streams every frame again
+func (p *FrameSocketPublisher) readLoop(client *frameSocketClient) {
+	defer p.wg.Done()
+
+	scanner := bufio.NewScanner(client.conn)
+	scanner.Buffer(make([]byte, 0, 1024), maxFrameSocketFilterBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var spec FilterSpec
+		if err := json.Unmarshal(line, &spec); err != nil {
+			p.logger.Printf("⚠️ Ignoring invalid frame socket filter: %v", err)
+			continue
+		}
+		if err := spec.Validate(); err != nil {
+			p.logger.Printf("⚠️ Ignoring invalid frame socket filter: %v", err)
+			continue
+		}
+
+		if spec.IsEmpty() {
+			client.filter.Store(nil)
+		} else {
+			client.filter.Store(&spec)
+		}
+		p.logger.Printf("🔌 Frame socket client filter set to %s", spec.String())
+	}
+}
+
+// encode renders a frame in the configured wire format
+func (p *FrameSocketPublisher) encode(msg CanMessageLog) ([]byte, error) {
+	if p.format == FrameSocketFormatRaw {
+		if msg.FD {
+			frame := CanFDFrame{ID: msg.ID}
+			frame.Length = uint8(copy(frame.Data[:], msg.Data))
+			return append([]byte(nil), (*[CANFD_MTU]byte)(unsafe.Pointer(&frame))[:]...), nil
+		}
+		frame := CanFrame{ID: msg.ID}
+		frame.Length = uint8(copy(frame.Data[:], msg.Data))
+		return append([]byte(nil), (*[CAN_MTU]byte)(unsafe.Pointer(&frame))[:]...), nil
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 4, 4+len(body))
+	binary.BigEndian.PutUint32(data, uint32(len(body)))
+	return append(data, body...), nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Frame generator ID strategies
+const (
+	GeneratorIDFixed  = "fixed"  // Every frame uses ID
+	GeneratorIDRandom = "random" // Uniformly random standard (11-bit) IDs
+	GeneratorIDSweep  = "sweep"  // ID, ID+1, ... wrapping after the last standard ID
+)
+
+const (
+	minGeneratorRateHz       = 0.001 // One frame every 1000 s; lower rates would overflow the ticker period
+	maxGeneratorRateHz       = 10000
+	maxRunningGeneratorJobs  = 16
+	maxFinishedGeneratorJobs = 32          // Finished jobs kept for status queries
+	maxStandardCanID         = 0x7FF       // Highest 11-bit identifier
+	generatorSource          = "generator" // Source recorded on generated frames
+)
+
+// GenerateRequest describes a synthetic traffic job
+type GenerateRequest struct {
+	Interface  string  `json:"interface" binding:"required"`
+	Count      int     `json:"count"`                     // Frames to send; 0 runs until the job is canceled
+	RateHz     float64 `json:"rateHz" binding:"required"` // Frames per second
+	IDStrategy string  `json:"idStrategy"`                // fixed (default), random or sweep
+	ID         uint32  `json:"id"`                        // Fixed ID, or the first ID of a sweep
+	Data       []byte  `json:"data"`                      // Payload; empty sends an 8-byte big-endian frame counter
+}
+
+// GeneratorJobStatus is a snapshot of a generator job
+type GeneratorJobStatus struct {
+	ID         string    `json:"id"`
+	Interface  string    `json:"interface"`
+	Count      int       `json:"count"`
+	RateHz     float64   `json:"rateHz"`
+	IDStrategy string    `json:"idStrategy"`
+	Sent       uint64    `json:"sent"`
+	Errors     uint64    `json:"errors"`
+	LastError  string    `json:"lastError,omitempty"`
+	Running    bool      `json:"running"`
+	StopReason string    `json:"stopReason,omitempty"` // "completed", "canceled", "interface teardown" or "service shutdown"
+	StartTime  time.Time `json:"startTime"`
+	StopTime   time.Time `json:"stopTime"`
+}
+
+// generatorJob is one running or finished job
+type generatorJob struct {
+	request  GenerateRequest
+	status   GeneratorJobStatus
+	stopChan chan string // Receives the stop reason
+	done     chan struct{}
+	mu       sync.Mutex
+}
+
+// FrameGenerator produces synthetic frames through the normal send path for load testing
+type FrameGenerator struct {
+	messageSender *MessageSender
+	logger        Logger
+	jobs          map[string]*generatorJob
+	nextID        uint64
+	mu            sync.Mutex
+}
+
+// NewFrameGenerator creates a frame generator that sends through messageSender
+func NewFrameGenerator(messageSender *MessageSender, logger Logger) *FrameGenerator {
+	return &FrameGenerator{
+		messageSender: messageSender,
+		logger:        logger,
+		jobs:          make(map[string]*generatorJob),
+	}
+}
+
+// ErrTooManyGeneratorJobs is returned when the running job limit is reached
+var ErrTooManyGeneratorJobs = errors.New("too many running generator jobs")
+
+// validateGenerateRequest checks job parameters and fills in defaults
+func (fg *FrameGenerator) validateGenerateRequest(req *GenerateRequest) error {
+	if req.IDStrategy == "" {
+		req.IDStrategy = GeneratorIDFixed
+	}
+	switch req.IDStrategy {
+	case GeneratorIDFixed, GeneratorIDRandom, GeneratorIDSweep:
+	default:
+		return fmt.Errorf("idStrategy must be %s, %s or %s, got %q", GeneratorIDFixed, GeneratorIDRandom, GeneratorIDSweep, req.IDStrategy)
+	}
+	if !(req.RateHz >= minGeneratorRateHz && req.RateHz <= maxGeneratorRateHz) {
+		return fmt.Errorf("rateHz must be between %g and %d, got %g", minGeneratorRateHz, maxGeneratorRateHz, req.RateHz)
+	}
+	if req.Count < 0 {
+		return fmt.Errorf("count cannot be negative, got %d", req.Count)
+	}
+	if len(req.Data) > 0 {
+		if err := validateDataLength(req.Data, false); err != nil {
+			return err
+		}
+	}
+	if req.IDStrategy == GeneratorIDSweep && req.ID > maxStandardCanID {
+		return fmt.Errorf("sweep start ID 0x%X is not a standard ID", req.ID)
+	}
+
+	// Validate one representative frame the same way /api/can would
+	return fg.messageSender.ValidateMessage(CanMessage{Interface: req.Interface, ID: req.ID, Data: []byte{0}})
+}
+
+// Start validates the request and launches a job, returning its initial status
+func (fg *FrameGenerator) Start(req GenerateRequest) (GeneratorJobStatus, error) {
+	if err := fg.validateGenerateRequest(&req); err != nil {
+		return GeneratorJobStatus{}, err
+	}
+
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+
+	if fg.runningJobs() >= maxRunningGeneratorJobs {
+		return GeneratorJobStatus{}, fmt.Errorf("%w (limit %d)", ErrTooManyGeneratorJobs, maxRunningGeneratorJobs)
+	}
+	fg.pruneFinished()
+
+	fg.nextID++
+	job := &generatorJob{
+		request: req,
+		status: GeneratorJobStatus{
+			ID:         fmt.Sprintf("gen-%d", fg.nextID),
+			Interface:  req.Interface,
+			Count:      req.Count,
+			RateHz:     req.RateHz,
+			IDStrategy: req.IDStrategy,
+			Running:    true,
+			StartTime:  time.Now(),
+		},
+		stopChan: make(chan string, 1),
+		done:     make(chan struct{}),
+	}
+	fg.jobs[job.status.ID] = job
+
+	go fg.run(job)
+
+	fg.logger.Printf("🎲 Generator %s started on %s: %g Hz, %s IDs, count %d",
+		job.status.ID, req.Interface, req.RateHz, req.IDStrategy, req.Count)
+	return job.snapshot(), nil
+}
+
+// pruneFinished drops the oldest finished jobs beyond maxFinishedGeneratorJobs. Caller holds fg.mu.
+func (fg *FrameGenerator) pruneFinished() {
+	var finished []GeneratorJobStatus
+	for _, job := range fg.jobs {
+		if status := job.snapshot(); !status.Running {
+			finished = append(finished, status)
+		}
+	}
+	if len(finished) <= maxFinishedGeneratorJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool { return finished[i].StopTime.Before(finished[j].StopTime) })
+	for _, status := range finished[:len(finished)-maxFinishedGeneratorJobs] {
+		delete(fg.jobs, status.ID)
+	}
+}
+
+// run sends frames at the requested rate until the count is reached or the job is stopped
+func (fg *FrameGenerator) run(job *generatorJob) {
+	defer close(job.done)
+
+	req := job.request
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / req.RateHz))
+	defer ticker.Stop()
+
+	reason := "completed"
+	var seq uint64
+	for req.Count == 0 || seq < uint64(req.Count) {
+		select {
+		case reason = <-job.stopChan:
+			fg.finish(job, reason)
+			return
+		case <-ticker.C:
+		}
+
+		msg := CanMessage{Interface: req.Interface, ID: generatorID(req, seq), Source: generatorSource}
+		if len(req.Data) > 0 {
+			msg.Data = req.Data
+		} else {
+			msg.Data = binary.BigEndian.AppendUint64(nil, seq)
+		}
+		seq++
+
+		var err error
+		if fg.messageSender.IsAsync() {
+			err = fg.messageSender.EnqueueCanMessage(msg)
+		} else {
+			err = fg.messageSender.SendCanMessage(msg)
+		}
+
+		job.mu.Lock()
+		if err != nil {
+			job.status.Errors++
+			job.status.LastError = err.Error()
+		} else {
+			job.status.Sent++
+		}
+		job.mu.Unlock()
+	}
+
+	fg.finish(job, reason)
+}
+
+// generatorID returns the ID of the seq-th frame of a job
+func generatorID(req GenerateRequest, seq uint64) uint32 {
+	switch req.IDStrategy {
+	case GeneratorIDRandom:
+		return rand.Uint32N(maxStandardCanID + 1)
+	case GeneratorIDSweep:
+		return uint32((uint64(req.ID) + seq) % (maxStandardCanID + 1))
+	default:
+		return req.ID
+	}
+}
+
+// finish marks a job stopped and logs its totals
+func (fg *FrameGenerator) finish(job *generatorJob, reason string) {
+	job.mu.Lock()
+	job.status.Running = false
+	job.status.StopReason = reason
+	job.status.StopTime = time.Now()
+	status := job.status
+	job.mu.Unlock()
+
+	fg.logger.Printf("🎲 Generator %s on %s stopped (%s): %d sent, %d error(s)",
+		status.ID, status.Interface, reason, status.Sent, status.Errors)
+}
+
+// snapshot returns a copy of the job status
+func (job *generatorJob) snapshot() GeneratorJobStatus {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return job.status
+}
+
+// stop asks a running job to exit and waits for it
+func (job *generatorJob) stop(reason string) {
+	select {
+	case job.stopChan <- reason:
+	default: // Already asked to stop
+	}
+	<-job.done
+}
+
+// Get returns the status of a job
+func (fg *FrameGenerator) Get(id string) (GeneratorJobStatus, bool) {
+	fg.mu.Lock()
+	job, exists := fg.jobs[id]
+	fg.mu.Unlock()
+
+	if !exists {
+		return GeneratorJobStatus{}, false
+	}
+	return job.snapshot(), true
+}
+
+// List returns the status of all known jobs, oldest first
+func (fg *FrameGenerator) List() []GeneratorJobStatus {
+	fg.mu.Lock()
+	result := make([]GeneratorJobStatus, 0, len(fg.jobs))
+	for _, job := range fg.jobs {
+		result = append(result, job.snapshot())
+	}
+	fg.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].StartTime.Before(result[j].StartTime) })
+	return result
+}
+
+// RunningCount returns the number of running jobs
+func (fg *FrameGenerator) RunningCount() int {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	return fg.runningJobs()
+}
+
+// runningJobs counts running jobs. Caller holds fg.mu.
+func (fg *FrameGenerator) runningJobs() int {
+	running := 0
+	for _, job := range fg.jobs {
+		if job.snapshot().Running {
+			running++
+		}
+	}
+	return running
+}
+
+// Stop cancels a job and returns its final status
+func (fg *FrameGenerator) Stop(id string) (GeneratorJobStatus, bool) {
+	fg.mu.Lock()
+	job, exists := fg.jobs[id]
+	fg.mu.Unlock()
+
+	if !exists {
+		return GeneratorJobStatus{}, false
+	}
+	job.stop("canceled")
+	return job.snapshot(), true
+}
+
+// StopInterface cancels every running job on an interface and returns how many were stopped
+func (fg *FrameGenerator) StopInterface(ifName string) int {
+	return fg.stopMatching("interface teardown", func(status GeneratorJobStatus) bool {
+		return status.Interface == ifName
+	})
+}
+
+// StopAll cancels every running job
+func (fg *FrameGenerator) StopAll() int {
+	return fg.stopMatching("service shutdown", func(GeneratorJobStatus) bool { return true })
+}
+
+// stopMatching stops the running jobs selected by match
+func (fg *FrameGenerator) stopMatching(reason string, match func(GeneratorJobStatus) bool) int {
+	fg.mu.Lock()
+	var jobs []*generatorJob
+	for _, job := range fg.jobs {
+		if status := job.snapshot(); status.Running && match(status) {
+			jobs = append(jobs, job)
+		}
+	}
+	fg.mu.Unlock()
+
+	for _, job := range jobs {
+		job.stop(reason)
+	}
+	return len(jobs)
+}
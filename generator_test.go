@@ -0,0 +1,22 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGenerateRequestRateBounds(t *testing.T) {
+	fg := NewFrameGenerator(nil, discardLogger{})
+	for _, rate := range []float64{1e-12, 0, -1, maxGeneratorRateHz + 1, math.Inf(1), math.NaN()} {
+		req := GenerateRequest{Interface: "can0", RateHz: rate}
+		if err := fg.validateGenerateRequest(&req); err == nil {
+			t.Errorf("rateHz %g was accepted", rate)
+		}
+	}
+
+	// The slowest accepted rate must still give NewTicker a positive period
+	if period := time.Duration(float64(time.Second) / minGeneratorRateHz); period <= 0 {
+		t.Fatalf("period at the minimum rate is %v", period)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// maxHistoryPerInterface caps the transitions kept for each interface
+const maxHistoryPerInterface = 100
+
+// Interface transition events
+const (
+	TransitionUp   = "up"
+	TransitionDown = "down"
+)
+
+// InterfaceTransition is a single recorded up/down change of an interface
+type InterfaceTransition struct {
+	Event     string    `json:"event"`             // TransitionUp or TransitionDown
+	Source    string    `json:"source"`            // What caused it: "setup", "teardown", "reset", "create", "watchdog"
+	Details   string    `json:"details,omitempty"` // Free-form context, e.g. the setup reason
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// InterfaceHistorySummary is the transition log of one interface plus lifetime counters
+type InterfaceHistorySummary struct {
+	Interface   string                `json:"interface"`
+	Transitions []InterfaceTransition `json:"transitions"` // Oldest first
+	UpCount     uint64                `json:"upCount"`     // Not capped by the history length
+	DownCount   uint64                `json:"downCount"`
+	LastUp      time.Time             `json:"lastUp"`
+	LastDown    time.Time             `json:"lastDown"`
+}
+
+// InterfaceHistory records interface state transitions, keeping the most recent ones per interface
+type InterfaceHistory struct {
+	mu        sync.RWMutex
+	summaries map[string]*InterfaceHistorySummary
+	maxLength int
+}
+
+// NewInterfaceHistory creates a history that keeps up to maxLength transitions per interface
+func NewInterfaceHistory(maxLength int) *InterfaceHistory {
+	return &InterfaceHistory{
+		summaries: make(map[string]*InterfaceHistorySummary),
+		maxLength: maxLength,
+	}
+}
+
+// Record appends a transition for an interface. Safe to call on a nil history.
+func (ih *InterfaceHistory) Record(ifName, event, source, details string) {
+	if ih == nil {
+		return
+	}
+
+	ih.mu.Lock()
+	defer ih.mu.Unlock()
+
+	summary, exists := ih.summaries[ifName]
+	if !exists {
+		summary = &InterfaceHistorySummary{Interface: ifName}
+		ih.summaries[ifName] = summary
+	}
+
+	now := time.Now()
+	switch event {
+	case TransitionUp:
+		summary.UpCount++
+		summary.LastUp = now
+	case TransitionDown:
+		summary.DownCount++
+		summary.LastDown = now
+	}
+
+	summary.Transitions = append(summary.Transitions, InterfaceTransition{
+		Event:     event,
+		Source:    source,
+		Details:   details,
+		Timestamp: now,
+	})
+	if len(summary.Transitions) > ih.maxLength {
+		summary.Transitions = append([]InterfaceTransition(nil), summary.Transitions[len(summary.Transitions)-ih.maxLength:]...)
+	}
+}
+
+// Get returns a copy of an interface's history limited to the last limit transitions (0 means all kept)
+func (ih *InterfaceHistory) Get(ifName string, limit int) InterfaceHistorySummary {
+	ih.mu.RLock()
+	defer ih.mu.RUnlock()
+
+	summary, exists := ih.summaries[ifName]
+	if !exists {
+		return InterfaceHistorySummary{Interface: ifName, Transitions: []InterfaceTransition{}}
+	}
+
+	result := *summary
+	transitions := summary.Transitions
+	if limit > 0 && limit < len(transitions) {
+		transitions = transitions[len(transitions)-limit:]
+	}
+	result.Transitions = append([]InterfaceTransition{}, transitions...)
+	return result
+}
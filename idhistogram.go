@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxTrackedIDs caps the distinct IDs counted per interface, so a bus flooded with random
+// extended IDs cannot grow the histogram without bound
+const maxTrackedIDs = 4096
+
+// CanIDStats summarizes the frames seen with one CAN ID on an interface
+type CanIDStats struct {
+	ID        uint32    `json:"id"`
+	Count     uint64    `json:"count"`
+	Rate      float64   `json:"rate"` // Frames per second between the first and last frame with this ID
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	LastData  []byte    `json:"lastData"`
+	HEX_ID    string    `json:"hex_id"`
+	HEX_Data  []string  `json:"hex_data"` // Hexadecimal representation of LastData
+}
+
+// idCounter is the histogram entry of one ID
+type idCounter struct {
+	count      uint64
+	firstSeen  time.Time
+	lastSeen   time.Time
+	lastLength uint8
+	lastData   [64]byte
+}
+
+// idHistogram counts frames per CAN ID. It has no lock of its own; the owning
+// InterfaceMessageBuffer's mutex guards it.
+type idHistogram struct {
+	ids       map[uint32]*idCounter
+	untracked uint64 // Frames with a new ID seen after maxTrackedIDs were already counted
+}
+
+// add counts a frame; only the first frame of an ID allocates
+func (h *idHistogram) add(frame *bufferedFrame) {
+	counter, exists := h.ids[frame.ID]
+	if !exists {
+		if len(h.ids) >= maxTrackedIDs {
+			h.untracked++
+			return
+		}
+		if h.ids == nil {
+			h.ids = make(map[uint32]*idCounter)
+		}
+		counter = &idCounter{firstSeen: frame.Timestamp}
+		h.ids[frame.ID] = counter
+	}
+	counter.count++
+	counter.lastSeen = frame.Timestamp
+	counter.lastLength = frame.Length
+	counter.lastData = frame.Data
+}
+
+// snapshot returns the stats of every counted ID, ordered by ID
+func (h *idHistogram) snapshot() []CanIDStats {
+	result := make([]CanIDStats, 0, len(h.ids))
+	for id, counter := range h.ids {
+		data := make([]byte, counter.lastLength)
+		copy(data, counter.lastData[:counter.lastLength])
+
+		var rate float64
+		if span := counter.lastSeen.Sub(counter.firstSeen).Seconds(); span > 0 {
+			rate = float64(counter.count-1) / span
+		}
+
+		result = append(result, CanIDStats{
+			ID:        id,
+			Count:     counter.count,
+			Rate:      rate,
+			FirstSeen: counter.firstSeen,
+			LastSeen:  counter.lastSeen,
+			LastData:  data,
+			HEX_ID:    fmt.Sprintf("%08x", id),
+			HEX_Data:  bytesToHexArray(data),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// remove forgets one ID
+func (h *idHistogram) remove(id uint32) {
+	delete(h.ids, id)
+}
+
+// reset forgets every counted ID
+func (h *idHistogram) reset() {
+	h.ids = nil
+	h.untracked = 0
+}
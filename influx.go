@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	influxMeasurement     = "can_interface"
+	maxInfluxPendingLines = 10000           // Points kept while InfluxDB is unreachable; the oldest are dropped first
+	maxInfluxBackoff      = 5 * time.Minute // Longest wait between write attempts after failures
+)
+
+// influxCounts is the previous sample of an interface, used to derive rates
+type influxCounts struct {
+	received uint64
+	sent     uint64
+}
+
+// InfluxWriter periodically writes per-interface counters to InfluxDB in line protocol.
+// It only reads the same counters the status API reports, so a slow or unreachable
+// server never holds up the listener or the send path.
+type InfluxWriter struct {
+	writeURL string
+	token    string
+	interval time.Duration
+	monitor  *Monitor
+	listener *CanMessageListener
+	client   *http.Client
+	logger   Logger
+
+	pending     []string
+	last        map[string]influxCounts
+	lastSample  time.Time
+	backoff     time.Duration
+	nextAttempt time.Time
+
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewInfluxWriter creates a writer for the /api/v2/write endpoint below baseURL. The same
+// endpoint is served by InfluxDB 2.x, 3.x and 1.8+ (with "database/retention-policy" as bucket).
+func NewInfluxWriter(baseURL, bucket, org, token string, interval time.Duration, monitor *Monitor, listener *CanMessageListener, logger Logger) *InfluxWriter {
+	query := url.Values{}
+	query.Set("bucket", bucket)
+	if org != "" {
+		query.Set("org", org)
+	}
+	query.Set("precision", "ns")
+
+	return &InfluxWriter{
+		writeURL: strings.TrimSuffix(baseURL, "/") + "/api/v2/write?" + query.Encode(),
+		token:    token,
+		interval: interval,
+		monitor:  monitor,
+		listener: listener,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+		last:     make(map[string]influxCounts),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start starts the periodic write loop
+func (w *InfluxWriter) Start() {
+	w.wg.Add(1)
+	go w.run()
+	w.logger.Printf("📈 InfluxDB writer enabled: %s (every %v)", w.writeURL, w.interval)
+}
+
+// Stop stops the write loop after a last attempt to flush pending points
+func (w *InfluxWriter) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	w.wg.Wait()
+}
+
+// run samples the counters every interval and writes them, backing off while writes fail
+func (w *InfluxWriter) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			w.collect(time.Now())
+			if err := w.flush(); err != nil {
+				w.logger.Printf("⚠️ Dropping %d InfluxDB points on shutdown: %v", len(w.pending), err)
+			}
+			return
+		case now := <-ticker.C:
+			w.collect(now)
+			if now.Before(w.nextAttempt) {
+				continue
+			}
+			if err := w.flush(); err != nil {
+				w.backoff = min(max(w.backoff*2, w.interval), maxInfluxBackoff)
+				w.nextAttempt = now.Add(w.backoff)
+				w.logger.Printf("❌ InfluxDB write failed, %d points pending, retrying in %v: %v", len(w.pending), w.backoff, err)
+				continue
+			}
+			if w.backoff > 0 {
+				w.logger.Printf("📈 InfluxDB writes recovered")
+			}
+			w.backoff = 0
+			w.nextAttempt = time.Time{}
+		}
+	}
+}
+
+// collect appends one point per active interface to the pending batch
+func (w *InfluxWriter) collect(now time.Time) {
+	elapsed := now.Sub(w.lastSample).Seconds()
+	if w.lastSample.IsZero() {
+		elapsed = 0
+	}
+	w.lastSample = now
+
+	status := w.monitor.GetSystemStatus()
+	names := make([]string, 0, len(status.Interfaces))
+	for name, ifStatus := range status.Interfaces {
+		if ifStatus.Active {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		ifStatus := status.Interfaces[name]
+		counts := influxCounts{sent: ifStatus.TotalSent}
+		fields := []string{
+			fmt.Sprintf("sent=%di", ifStatus.TotalSent),
+			fmt.Sprintf("send_errors=%di", ifStatus.TotalErrors),
+			fmt.Sprintf("queue_drops=%di", ifStatus.QueueDrops),
+		}
+		if w.listener != nil {
+			if received, ok := w.listener.GetRxFrameCount(name); ok {
+				counts.received = received
+				fields = append(fields, fmt.Sprintf("received=%di", received))
+			}
+		}
+		if ks := ifStatus.KernelStats; ks != nil {
+			fields = append(fields, fmt.Sprintf("rx_errors=%di", ks.RxErrors), fmt.Sprintf("tx_errors=%di", ks.TxErrors))
+		}
+
+		// Rates need a previous sample; counters that went backwards were reset
+		if prev, ok := w.last[name]; ok && elapsed > 0 {
+			if counts.received >= prev.received {
+				fields = append(fields, fmt.Sprintf("rx_rate=%g", float64(counts.received-prev.received)/elapsed))
+			}
+			if counts.sent >= prev.sent {
+				fields = append(fields, fmt.Sprintf("tx_rate=%g", float64(counts.sent-prev.sent)/elapsed))
+			}
+		}
+		w.last[name] = counts
+
+		w.pending = append(w.pending, fmt.Sprintf("%s,interface=%s %s %d",
+			influxMeasurement, escapeInfluxTag(name), strings.Join(fields, ","), now.UnixNano()))
+	}
+
+	if dropped := len(w.pending) - maxInfluxPendingLines; dropped > 0 {
+		w.pending = slices.Delete(w.pending, 0, dropped)
+		w.logger.Printf("⚠️ InfluxDB batch full, dropped %d oldest points", dropped)
+	}
+}
+
+// flush writes the pending batch in one request and clears it on success
+func (w *InfluxWriter) flush() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	body := strings.Join(w.pending, "\n") + "\n"
+	req, err := http.NewRequest(http.MethodPost, w.writeURL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("InfluxDB returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	w.pending = w.pending[:0]
+	return nil
+}
+
+// escapeInfluxTag escapes the characters line protocol reserves in tag values
+func escapeInfluxTag(value string) string {
+	return strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`).Replace(value)
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -34,23 +38,69 @@ func DefaultInterfaceSetupConfig() InterfaceSetupConfig {
 	}
 }
 
+// LinkStatus summarizes an interface's link and CAN controller state
+type LinkStatus string
+
+// Link statuses reported by GetInterfaceState
+const (
+	LinkStatusNotFound     LinkStatus = "not-found"     // No such network interface
+	LinkStatusDown         LinkStatus = "down"          // Exists but is not up
+	LinkStatusUp           LinkStatus = "up"            // Up and able to take part in bus traffic
+	LinkStatusBusOff       LinkStatus = "bus-off"       // Up, but the controller is bus-off
+	LinkStatusErrorPassive LinkStatus = "error-passive" // Up, but the controller is error-passive
+)
+
 // InterfaceState represents the current state of a CAN interface
 type InterfaceState struct {
-	Name      string    `json:"name"`
-	IsUp      bool      `json:"isUp"`
-	Bitrate   int       `json:"bitrate"`
-	State     string    `json:"state"` // UP, DOWN, ERROR-ACTIVE, etc.
-	TxErrors  int       `json:"txErrors"`
-	RxErrors  int       `json:"rxErrors"`
-	RestartMs int       `json:"restartMs"`
-	LastError string    `json:"lastError,omitempty"`
-	SetupTime time.Time `json:"setupTime,omitempty"`
+	Name      string     `json:"name"`
+	Status    LinkStatus `json:"status"`
+	IsUp      bool       `json:"isUp"`
+	Bitrate   int        `json:"bitrate"`
+	State     string     `json:"state"`              // UP, DOWN, UNKNOWN, etc.
+	CanState  string     `json:"canState,omitempty"` // ERROR-ACTIVE, ERROR-PASSIVE, BUS-OFF, etc.
+	Virtual   bool       `json:"virtual"`            // vcan/vxcan links have no bitrate
+	MTU       int        `json:"mtu"`                // 16 for classic CAN, 72 for CAN FD
+	LinkType  string     `json:"linkType"`           // Link layer type from "link/<type>", e.g. "can"
+	TxErrors  int        `json:"txErrors"`
+	RxErrors  int        `json:"rxErrors"`
+	RestartMs int        `json:"restartMs"`
+	LastError string     `json:"lastError,omitempty"`
+	SetupTime time.Time  `json:"setupTime,omitempty"`
 }
 
 // CommandExecutor interface for dependency injection
 type CommandExecutor interface {
 	Execute(name string, args ...string) ([]byte, error)
 	ExecuteWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error)
+	ExecuteContext(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error)
+}
+
+// CommandError is a failed external command. Output holds its combined stdout and stderr,
+// which usually names the real cause (e.g. "RTNETLINK answers: Device or resource busy");
+// it is kept out of Error() so it only reaches API clients that ask for it.
+type CommandError struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+// newCommandError records a failed command with its output
+func newCommandError(output []byte, err error, name string, args ...string) *CommandError {
+	return &CommandError{
+		Command: strings.Join(append([]string{name}, args...), " "),
+		Output:  strings.TrimSpace(string(output)),
+		Err:     err,
+	}
+}
+
+// Error describes the failed command without its output
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Command, e.Err)
+}
+
+// Unwrap returns the error the command failed with
+func (e *CommandError) Unwrap() error {
+	return e.Err
 }
 
 // SystemCommandExecutor implements CommandExecutor using real system commands
@@ -70,7 +120,12 @@ func (e *SystemCommandExecutor) Execute(name string, args ...string) ([]byte, er
 
 // ExecuteWithTimeout executes a system command with timeout
 func (e *SystemCommandExecutor) ExecuteWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return e.ExecuteContext(context.Background(), timeout, name, args...)
+}
+
+// ExecuteContext executes a system command with timeout, killing it early if ctx is cancelled
+func (e *SystemCommandExecutor) ExecuteContext(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
@@ -78,97 +133,576 @@ func (e *SystemCommandExecutor) ExecuteWithTimeout(timeout time.Duration, name s
 	return output, err
 }
 
+// DryRunCommandExecutor logs commands that would change interfaces instead of running them.
+// Read-only queries (ip ... show) still run so state and existence checks see the real system.
+type DryRunCommandExecutor struct {
+	next   CommandExecutor
+	logger Logger
+}
+
+// NewDryRunCommandExecutor wraps next so that only read-only commands reach it
+func NewDryRunCommandExecutor(next CommandExecutor, logger Logger) *DryRunCommandExecutor {
+	return &DryRunCommandExecutor{next: next, logger: logger}
+}
+
+// Execute runs a read-only command or logs any other and reports success
+func (e *DryRunCommandExecutor) Execute(name string, args ...string) ([]byte, error) {
+	if isReadOnlyCommand(name, args) {
+		return e.next.Execute(name, args...)
+	}
+	e.logSkipped(name, args)
+	return nil, nil
+}
+
+// ExecuteWithTimeout runs a read-only command or logs any other and reports success
+func (e *DryRunCommandExecutor) ExecuteWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if isReadOnlyCommand(name, args) {
+		return e.next.ExecuteWithTimeout(timeout, name, args...)
+	}
+	e.logSkipped(name, args)
+	return nil, nil
+}
+
+// ExecuteContext runs a read-only command or logs any other and reports success
+func (e *DryRunCommandExecutor) ExecuteContext(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if isReadOnlyCommand(name, args) {
+		return e.next.ExecuteContext(ctx, timeout, name, args...)
+	}
+	e.logSkipped(name, args)
+	return nil, nil
+}
+
+// logSkipped logs a command that was not run
+func (e *DryRunCommandExecutor) logSkipped(name string, args []string) {
+	e.logger.Printf("🧪 [dry-run] Would run: %s %s", name, strings.Join(args, " "))
+}
+
+// isReadOnlyCommand reports whether a command only queries interfaces (ip ... show)
+func isReadOnlyCommand(name string, args []string) bool {
+	return name == "ip" && slices.Contains(args, "show")
+}
+
+// ErrSetupDisabled is returned by operations that would run ip link while setup is disabled (-no-setup)
+var ErrSetupDisabled = errors.New("interface setup is disabled (-no-setup)")
+
+// ErrPermissionDenied is returned when ip link fails because the process lacks CAP_NET_ADMIN
+var ErrPermissionDenied = errors.New("permission denied: configuring CAN interfaces requires root or CAP_NET_ADMIN")
+
+// ErrInvalidInterfaceName is returned for interface names the kernel would never accept
+var ErrInvalidInterfaceName = errors.New("invalid interface name")
+
+// ErrInterfaceBusy is returned when an interface up at the wrong bitrate cannot be brought down to reconfigure it
+var ErrInterfaceBusy = errors.New("interface busy, cannot reconfigure")
+
+// ErrInterfaceNotFound is returned when the named network interface does not exist
+var ErrInterfaceNotFound = errors.New("CAN interface does not exist")
+
 // InterfaceSetupManager manages CAN interface setup and configuration
 type InterfaceSetupManager struct {
-	config          InterfaceSetupConfig
-	commandExecutor CommandExecutor
-	logger          Logger
+	config           InterfaceSetupConfig
+	interfaceConfigs map[string]InterfaceSetupConfig // Per-interface overrides of config
+	configMutex      sync.RWMutex                    // Guards config and interfaceConfigs, which reloads replace
+	allowVirtual     bool
+	noSetup          bool
+	commandExecutor  CommandExecutor
+	logger           Logger
+	created          map[string]bool // Virtual interfaces created by this service
+	createdMutex     sync.Mutex
+	history          *InterfaceHistory // Records up/down transitions; may be nil
+	dryRun           bool              // Commands that change interfaces are only logged
+	stateCache       map[string]cachedInterfaceState
+	stateCacheTTL    time.Duration // How long GetInterfaceState reuses a parsed state; 0 disables
+	stateGeneration  uint64        // Bumped on every invalidation, so a read that raced a change is not cached
+	stateMutex       sync.Mutex
+	applied          map[string]AppliedSetup // Last configuration applied per interface
+	appliedMutex     sync.Mutex
+}
+
+// AppliedSetup records the configuration this service last applied to an interface
+type AppliedSetup struct {
+	Interface string               `json:"interface"`
+	Config    InterfaceSetupConfig `json:"config"`
+	Command   string               `json:"command"` // The literal ip command that was run
+	AppliedAt time.Time            `json:"appliedAt"`
+	DryRun    bool                 `json:"dryRun,omitempty"` // The command was only logged (-dry-run)
+}
+
+// cachedInterfaceState is a parsed interface state and when it was read
+type cachedInterfaceState struct {
+	state  InterfaceState
+	readAt time.Time
 }
 
 // NewInterfaceSetupManager creates a new interface setup manager
 func NewInterfaceSetupManager(config InterfaceSetupConfig, commandExecutor CommandExecutor, logger Logger) *InterfaceSetupManager {
 	return &InterfaceSetupManager{
-		config:          config,
-		commandExecutor: commandExecutor,
-		logger:          logger,
+		config:           config,
+		interfaceConfigs: make(map[string]InterfaceSetupConfig),
+		commandExecutor:  commandExecutor,
+		logger:           logger,
+		created:          make(map[string]bool),
+		stateCache:       make(map[string]cachedInterfaceState),
+		applied:          make(map[string]AppliedSetup),
+	}
+}
+
+// SetAllowVirtual sets whether virtual CAN links (vcan/vxcan) may be set up
+func (ism *InterfaceSetupManager) SetAllowVirtual(allow bool) {
+	ism.allowVirtual = allow
+}
+
+// SetNoSetup disables every operation that changes interfaces with ip link
+func (ism *InterfaceSetupManager) SetNoSetup(noSetup bool) {
+	ism.noSetup = noSetup
+}
+
+// SetStateCacheTTL sets how long GetInterfaceState reuses a parsed state (0 disables caching)
+func (ism *InterfaceSetupManager) SetStateCacheTTL(ttl time.Duration) {
+	ism.stateMutex.Lock()
+	defer ism.stateMutex.Unlock()
+
+	ism.stateCacheTTL = ttl
+	if ttl <= 0 {
+		clear(ism.stateCache)
+	}
+}
+
+// invalidateState forgets the cached state of an interface; called after every command that changes it
+func (ism *InterfaceSetupManager) invalidateState(ifName string) {
+	ism.stateMutex.Lock()
+	defer ism.stateMutex.Unlock()
+	delete(ism.stateCache, ifName)
+	ism.stateGeneration++
+}
+
+// SetHistory sets the history that setup, reset and teardown transitions are recorded in
+func (ism *InterfaceSetupManager) SetHistory(history *InterfaceHistory) {
+	ism.history = history
+}
+
+// SetDryRun makes the manager log the commands that would change interfaces instead of
+// running them. Each command then reports success, missing interfaces are treated as down,
+// post-setup verification is skipped and no transitions are recorded. Call before first use.
+func (ism *InterfaceSetupManager) SetDryRun(dryRun bool) {
+	if dryRun && !ism.dryRun {
+		ism.commandExecutor = NewDryRunCommandExecutor(ism.commandExecutor, ism.logger)
+	}
+	ism.dryRun = dryRun
+}
+
+// IsDryRun reports whether the manager only logs the commands that would change interfaces
+func (ism *InterfaceSetupManager) IsDryRun() bool {
+	return ism.dryRun
+}
+
+// recordTransition records an up/down transition unless running dry
+func (ism *InterfaceSetupManager) recordTransition(ifName, event, source, details string) {
+	if !ism.dryRun {
+		ism.history.Record(ifName, event, source, details)
 	}
 }
 
-// SetupInterface configures and brings up a CAN interface
-func (ism *InterfaceSetupManager) SetupInterface(ifName string) error {
+// IsSetupDisabled reports whether interface setup is disabled
+func (ism *InterfaceSetupManager) IsSetupDisabled() bool {
+	return ism.noSetup
+}
+
+// checkPermission turns an "Operation not permitted" failure of ip into an actionable error
+func checkPermission(ifName string, output []byte, err error) error {
+	if errors.Is(err, syscall.EPERM) || strings.Contains(string(output), "Operation not permitted") {
+		return fmt.Errorf("cannot change %s: %w; run as root, grant the capability with "+
+			"`setcap cap_net_admin+ep can-bridge`, or start with -no-setup if the OS configures the interface", ifName, ErrPermissionDenied)
+	}
+	return nil
+}
+
+// validateInterfaceName rejects names that are empty, longer than IFNAMSIZ-1 bytes,
+// or contain characters outside [a-zA-Z0-9._-], before they reach ip or a socket bind
+func validateInterfaceName(ifName string) error {
+	if ifName == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidInterfaceName)
+	}
+	if len(ifName) > IFNAMSIZ-1 {
+		return fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidInterfaceName, ifName, IFNAMSIZ-1)
+	}
+	for _, r := range ifName {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return fmt.Errorf("%w: %q contains %q; only letters, digits, '.', '_' and '-' are allowed", ErrInvalidInterfaceName, ifName, r)
+		}
+	}
+	return nil
+}
+
+// isVirtualInterfaceName reports whether a name follows the virtual CAN naming convention
+func isVirtualInterfaceName(ifName string) bool {
+	return strings.HasPrefix(ifName, "vcan") || strings.HasPrefix(ifName, "vxcan")
+}
+
+// CreateVirtualInterface creates a vcan interface and brings it up.
+// Interfaces created here are deleted again by TeardownInterface.
+func (ism *InterfaceSetupManager) CreateVirtualInterface(ifName string) error {
+	if err := validateInterfaceName(ifName); err != nil {
+		return err
+	}
+	if ism.noSetup {
+		return ErrSetupDisabled
+	}
+	if !ism.allowVirtual {
+		return fmt.Errorf("cannot create virtual interface %s; start with -allow-virtual to use virtual CAN links", ifName)
+	}
+	if ism.interfaceExists(ifName) {
+		return fmt.Errorf("interface %s already exists", ifName)
+	}
+
+	ism.logger.Printf("➕ Creating virtual CAN interface %s...", ifName)
+	timeout := time.Duration(ism.GetSetupConfig().TimeoutSeconds) * time.Second
+	defer ism.invalidateState(ifName)
+	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", "link", "add", "dev", ifName, "type", "vcan")
+	if err != nil {
+		ism.logger.Printf("❌ Failed to create %s: %v, output: %s", ifName, err, string(output))
+		if permErr := checkPermission(ifName, output, err); permErr != nil {
+			return permErr
+		}
+		return fmt.Errorf("failed to create virtual interface: %w", newCommandError(output, err, "ip", "link", "add", "dev", ifName, "type", "vcan"))
+	}
+
+	ism.createdMutex.Lock()
+	ism.created[ifName] = true
+	ism.createdMutex.Unlock()
+
+	if err := ism.bringInterfaceUp(context.Background(), ifName); err != nil {
+		return err
+	}
+
+	ism.recordTransition(ifName, TransitionUp, "create", "")
+	ism.logger.Printf("✅ Virtual CAN interface %s created", ifName)
+	return nil
+}
+
+// IsCreatedByService reports whether the interface was created by CreateVirtualInterface
+func (ism *InterfaceSetupManager) IsCreatedByService(ifName string) bool {
+	ism.createdMutex.Lock()
+	defer ism.createdMutex.Unlock()
+	return ism.created[ifName]
+}
+
+// deleteInterface removes an interface created by this service
+func (ism *InterfaceSetupManager) deleteInterface(ifName string) error {
+	ism.logger.Printf("➖ Deleting virtual CAN interface %s...", ifName)
+	timeout := time.Duration(ism.GetSetupConfig().TimeoutSeconds) * time.Second
+	defer ism.invalidateState(ifName)
+	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", "link", "delete", ifName)
+	if err != nil {
+		ism.logger.Printf("❌ Failed to delete %s: %v, output: %s", ifName, err, string(output))
+		return fmt.Errorf("failed to delete interface: %w", newCommandError(output, err, "ip", "link", "delete", ifName))
+	}
+
+	ism.createdMutex.Lock()
+	delete(ism.created, ifName)
+	ism.createdMutex.Unlock()
+	return nil
+}
+
+// SetInterfaceConfigs sets per-interface setup configurations that take precedence over the global config
+func (ism *InterfaceSetupManager) SetInterfaceConfigs(configs map[string]InterfaceSetupConfig) {
+	interfaceConfigs := make(map[string]InterfaceSetupConfig, len(configs))
+	for ifName, config := range configs {
+		interfaceConfigs[ifName] = config
+	}
+
+	ism.configMutex.Lock()
+	defer ism.configMutex.Unlock()
+	ism.interfaceConfigs = interfaceConfigs
+}
+
+// GetInterfaceConfigs returns the effective setup configuration for each named interface
+func (ism *InterfaceSetupManager) GetInterfaceConfigs(ifNames []string) map[string]InterfaceSetupConfig {
+	result := make(map[string]InterfaceSetupConfig, len(ifNames))
+	for _, ifName := range ifNames {
+		result[ifName] = ism.GetInterfaceConfig(ifName)
+	}
+	return result
+}
+
+// GetInterfaceConfig returns the effective setup configuration for an interface.
+// Per-interface bus parameters are layered over the current global configuration.
+func (ism *InterfaceSetupManager) GetInterfaceConfig(ifName string) InterfaceSetupConfig {
+	ism.configMutex.RLock()
+	defer ism.configMutex.RUnlock()
+
+	config := ism.config
+	if override, ok := ism.interfaceConfigs[ifName]; ok {
+		config.Bitrate = override.Bitrate
+		config.SamplePoint = override.SamplePoint
+		config.RestartMs = override.RestartMs
+	}
+	return config
+}
+
+// Setup outcome reasons
+const (
+	SetupReasonAlreadyConfigured = "already-configured"
+	SetupReasonReconfigured      = "reconfigured"
+	SetupReasonBroughtUp         = "brought-up"
+)
+
+// SetupOutcome describes what a setup call actually did to the interface
+type SetupOutcome struct {
+	Changed bool   `json:"changed"`
+	Reason  string `json:"reason"`
+	Warning string `json:"warning,omitempty"` // Setup succeeded but the bus looks wrong, e.g. a likely bitrate mismatch
+}
+
+// After bringing a link up, its error counters are watched for busCheckWindow. Climbing by
+// busErrorClimbThreshold or more, or leaving ERROR-ACTIVE, means the controller cannot
+// receive what is on the bus, which is almost always a bitrate mismatch.
+const (
+	busCheckWindow         = time.Second
+	busCheckInterval       = 250 * time.Millisecond
+	busErrorClimbThreshold = 16
+)
+
+// SetupInterface configures and brings up a CAN interface using its effective configuration
+func (ism *InterfaceSetupManager) SetupInterface(ctx context.Context, ifName string) (SetupOutcome, error) {
+	return ism.SetupInterfaceWithConfig(ctx, ifName, ism.GetInterfaceConfig(ifName))
+}
+
+// SetupInterfaceWithConfig configures and brings up a CAN interface using the given configuration
+func (ism *InterfaceSetupManager) SetupInterfaceWithConfig(ctx context.Context, ifName string, config InterfaceSetupConfig) (outcome SetupOutcome, err error) {
+	defer func() {
+		if err == nil && outcome.Changed {
+			ism.recordTransition(ifName, TransitionUp, "setup", outcome.Reason)
+		}
+	}()
+
+	if err := validateInterfaceName(ifName); err != nil {
+		return SetupOutcome{}, err
+	}
+	if ism.noSetup {
+		return SetupOutcome{}, ErrSetupDisabled
+	}
+
 	ism.logger.Printf("🔧 Setting up CAN interface %s...", ifName)
 
+	if err := ctx.Err(); err != nil {
+		return SetupOutcome{}, fmt.Errorf("setup of %s cancelled: %w", ifName, err)
+	}
+
 	// First, check if interface exists
+	var currentState *InterfaceState
 	if !ism.interfaceExists(ifName) {
-		return fmt.Errorf("CAN interface %s does not exist", ifName)
+		if !ism.dryRun {
+			return SetupOutcome{}, fmt.Errorf("%w: %s", ErrInterfaceNotFound, ifName)
+		}
+		ism.logger.Printf("🧪 [dry-run] %s does not exist; showing the commands for a down interface", ifName)
+	} else {
+		// Get current state to see if interface is already up
+		state, stateErr := ism.RefreshInterfaceState(ifName)
+		if stateErr != nil {
+			ism.logger.Printf("⚠️ Warning: could not get current state of %s: %v", ifName, stateErr)
+		}
+		currentState = state
 	}
 
-	// Get current state to see if interface is already up
-	currentState, err := ism.GetInterfaceState(ifName)
-	if err != nil {
-		ism.logger.Printf("⚠️ Warning: could not get current state of %s: %v", ifName, err)
+	// Virtual links have no bitrate: only bring them up
+	if (currentState != nil && currentState.Virtual) || isVirtualInterfaceName(ifName) {
+		return ism.setupVirtualInterface(ctx, ifName, currentState)
 	}
 
 	// If interface is already up and configured correctly, skip setup
-	if currentState != nil && currentState.IsUp && currentState.Bitrate == ism.config.Bitrate {
-		ism.logger.Printf("✅ Interface %s is already configured correctly (bitrate=%d)", ifName, currentState.Bitrate)
-		return nil
+	var mismatch string
+	if currentState != nil && currentState.IsUp {
+		mismatch = ism.setupMismatch(ifName, currentState, config)
+		if mismatch == "" {
+			ism.logger.Printf("✅ Interface %s is already configured correctly (bitrate=%d)", ifName, currentState.Bitrate)
+			return SetupOutcome{Changed: false, Reason: SetupReasonAlreadyConfigured}, nil
+		}
+		ism.logger.Printf("🔧 %s is up but differs from the wanted setup: %s", ifName, mismatch)
 	}
 
+	outcome = SetupOutcome{Changed: true, Reason: SetupReasonBroughtUp}
+
 	// Bring interface down first (only if it's up)
 	if currentState != nil && currentState.IsUp {
-		if err := ism.bringInterfaceDown(ifName); err != nil {
+		outcome.Reason = SetupReasonReconfigured
+		if err := ism.bringInterfaceDown(ctx, ifName); err != nil {
 			ism.logger.Printf("⚠️ Warning: failed to bring %s down: %v", ifName, err)
+			if errors.Is(err, ErrPermissionDenied) || ctx.Err() != nil {
+				return SetupOutcome{}, fmt.Errorf("failed to bring %s down: %w", ifName, err)
+			}
 			// Try to force down
-			if err := ism.forceInterfaceDown(ifName); err != nil {
-				ism.logger.Printf("⚠️ Warning: failed to force %s down: %v", ifName, err)
+			if forceErr := ism.forceInterfaceDown(ctx, ifName); forceErr != nil {
+				ism.logger.Printf("⚠️ Warning: failed to force %s down: %v", ifName, forceErr)
+				// Bus parameters cannot change while the link is up, so configuring would only fail less clearly
+				return SetupOutcome{}, fmt.Errorf("%w: %s is up with %s, and it could not be brought down: %w",
+					ErrInterfaceBusy, ifName, mismatch, err)
 			}
 		}
 		// Brief pause after bringing down
-		time.Sleep(500 * time.Millisecond)
+		if err := sleepContext(ctx, 500*time.Millisecond); err != nil {
+			return SetupOutcome{}, fmt.Errorf("setup of %s cancelled: %w", ifName, err)
+		}
 	}
 
 	// Configure interface parameters
-	if err := ism.configureInterface(ifName); err != nil {
-		return fmt.Errorf("failed to configure %s: %w", ifName, err)
+	if err := ism.configureInterface(ctx, ifName, config); err != nil {
+		return SetupOutcome{}, fmt.Errorf("failed to configure %s: %w", ifName, err)
 	}
 
 	// Bring interface up
-	if err := ism.bringInterfaceUp(ifName); err != nil {
-		return fmt.Errorf("failed to bring %s up: %w", ifName, err)
+	if err := ism.bringInterfaceUp(ctx, ifName); err != nil {
+		return SetupOutcome{}, fmt.Errorf("failed to bring %s up: %w", ifName, err)
 	}
 
 	// Verify interface is working
-	if err := ism.verifyInterface(ifName); err != nil {
-		return fmt.Errorf("interface %s verification failed: %w", ifName, err)
+	if ism.dryRun {
+		ism.logger.Printf("🧪 [dry-run] Skipping verification of %s", ifName)
+	} else if err := ism.verifyInterface(ifName, config); err != nil {
+		return SetupOutcome{}, fmt.Errorf("interface %s verification failed: %w", ifName, err)
+	} else if outcome.Warning = ism.checkBusErrors(ctx, ifName, config); outcome.Warning != "" {
+		ism.logger.Printf("⚠️ CAN interface %s is up (%s) but %s", ifName, outcome.Reason, outcome.Warning)
+		return outcome, nil
 	}
 
-	ism.logger.Printf("✅ CAN interface %s successfully configured and activated", ifName)
-	return nil
+	ism.logger.Printf("✅ CAN interface %s successfully configured and activated (%s)", ifName, outcome.Reason)
+	return outcome, nil
+}
+
+// setupMismatch returns why an up link does not match config, or "" if it does. Bitrate and
+// restart-ms are read from the link; restart-ms is only compared when config sets one, as
+// configureInterface leaves it alone otherwise. The sample point is not parsed from ip output,
+// so it is compared with what this service last applied, and trusted when nothing was applied.
+func (ism *InterfaceSetupManager) setupMismatch(ifName string, state *InterfaceState, config InterfaceSetupConfig) string {
+	var mismatch []string
+	if state.Bitrate != config.Bitrate {
+		mismatch = append(mismatch, fmt.Sprintf("bitrate %d, want %d", state.Bitrate, config.Bitrate))
+	}
+	if config.RestartMs > 0 && state.RestartMs != config.RestartMs {
+		mismatch = append(mismatch, fmt.Sprintf("restart-ms %d, want %d", state.RestartMs, config.RestartMs))
+	}
+	if applied, ok := ism.GetAppliedSetup(ifName); ok && applied.Config.SamplePoint != config.SamplePoint {
+		mismatch = append(mismatch, fmt.Sprintf("sample-point %q applied, want %q", applied.Config.SamplePoint, config.SamplePoint))
+	}
+	return strings.Join(mismatch, ", ")
+}
+
+// checkBusErrors watches a freshly brought up link for busCheckWindow and returns a warning
+// when its error counters climb quickly or the controller leaves ERROR-ACTIVE. An idle bus
+// produces no errors, so a mismatch only shows once other nodes are transmitting.
+func (ism *InterfaceSetupManager) checkBusErrors(ctx context.Context, ifName string, config InterfaceSetupConfig) string {
+	baseline, err := ism.RefreshInterfaceState(ifName)
+	if err != nil {
+		return ""
+	}
+
+	state := baseline
+	for elapsed := time.Duration(0); elapsed < busCheckWindow; elapsed += busCheckInterval {
+		if sleepContext(ctx, busCheckInterval) != nil {
+			return ""
+		}
+		if state, err = ism.RefreshInterfaceState(ifName); err != nil {
+			return ""
+		}
+		if state.Status == LinkStatusErrorPassive || state.Status == LinkStatusBusOff {
+			break
+		}
+	}
+
+	climb := (state.TxErrors - baseline.TxErrors) + (state.RxErrors - baseline.RxErrors)
+	if climb < busErrorClimbThreshold && state.Status != LinkStatusErrorPassive && state.Status != LinkStatusBusOff {
+		return ""
+	}
+	symptom := fmt.Sprintf("error counters rose by %d (now tx %d, rx %d)", climb, state.TxErrors, state.RxErrors)
+	if state.Status == LinkStatusErrorPassive || state.Status == LinkStatusBusOff {
+		symptom = fmt.Sprintf("the controller went %s and %s", state.Status, symptom)
+	}
+	return fmt.Sprintf("likely bitrate mismatch: %s within %v of coming up at %d bps; check the bitrate of the bus",
+		symptom, busCheckWindow, config.Bitrate)
+}
+
+// setupVirtualInterface brings up a vcan/vxcan link, skipping bitrate configuration
+func (ism *InterfaceSetupManager) setupVirtualInterface(ctx context.Context, ifName string, currentState *InterfaceState) (SetupOutcome, error) {
+	if !ism.allowVirtual {
+		return SetupOutcome{}, fmt.Errorf("interface %s is a virtual CAN link; start with -allow-virtual to use it", ifName)
+	}
+
+	if currentState != nil && currentState.IsUp {
+		ism.logger.Printf("✅ Virtual interface %s is already up", ifName)
+		return SetupOutcome{Changed: false, Reason: SetupReasonAlreadyConfigured}, nil
+	}
+
+	if err := ism.bringInterfaceUp(ctx, ifName); err != nil {
+		return SetupOutcome{}, fmt.Errorf("failed to bring %s up: %w", ifName, err)
+	}
+
+	if ism.dryRun {
+		return SetupOutcome{Changed: true, Reason: SetupReasonBroughtUp}, nil
+	}
+
+	state, err := ism.RefreshInterfaceState(ifName)
+	if err != nil {
+		return SetupOutcome{}, fmt.Errorf("interface %s verification failed: %w", ifName, err)
+	}
+	if !state.IsUp {
+		return SetupOutcome{}, fmt.Errorf("interface %s verification failed: interface is not up", ifName)
+	}
+
+	ism.logger.Printf("✅ Virtual CAN interface %s is up", ifName)
+	return SetupOutcome{Changed: true, Reason: SetupReasonBroughtUp}, nil
 }
 
 // SetupInterfaceWithRetry sets up interface with retry logic
-func (ism *InterfaceSetupManager) SetupInterfaceWithRetry(ifName string) error {
+func (ism *InterfaceSetupManager) SetupInterfaceWithRetry(ctx context.Context, ifName string) (SetupOutcome, error) {
+	return ism.SetupInterfaceWithConfigAndRetry(ctx, ifName, ism.GetInterfaceConfig(ifName))
+}
+
+// SetupInterfaceWithConfigAndRetry sets up interface with the given configuration and retry logic.
+// Cancelling ctx aborts the running command and any remaining attempts.
+func (ism *InterfaceSetupManager) SetupInterfaceWithConfigAndRetry(ctx context.Context, ifName string, config InterfaceSetupConfig) (SetupOutcome, error) {
 	var lastErr error
+	retry := ism.GetSetupConfig()
 
-	for attempt := 1; attempt <= ism.config.RetryAttempts; attempt++ {
-		err := ism.SetupInterface(ifName)
+	for attempt := 1; attempt <= retry.RetryAttempts; attempt++ {
+		outcome, err := ism.SetupInterfaceWithConfig(ctx, ifName, config)
 		if err == nil {
-			return nil
+			return outcome, nil
+		}
+		if ctx.Err() != nil {
+			ism.logger.Printf("🛑 Setup of %s aborted: %v", ifName, ctx.Err())
+			return SetupOutcome{}, err
+		}
+		if errors.Is(err, ErrInvalidInterfaceName) || errors.Is(err, ErrSetupDisabled) {
+			return SetupOutcome{}, err // Retrying cannot help
 		}
 
 		lastErr = err
 		ism.logger.Printf("❌ Setup attempt %d/%d failed for %s: %v",
-			attempt, ism.config.RetryAttempts, ifName, err)
+			attempt, retry.RetryAttempts, ifName, err)
 
-		if attempt < ism.config.RetryAttempts {
-			ism.logger.Printf("⏳ Retrying in %v...", ism.config.RetryDelay)
-			time.Sleep(ism.config.RetryDelay)
+		if attempt < retry.RetryAttempts {
+			ism.logger.Printf("⏳ Retrying in %v...", retry.RetryDelay)
+			if err := sleepContext(ctx, retry.RetryDelay); err != nil {
+				ism.logger.Printf("🛑 Setup of %s aborted: %v", ifName, err)
+				return SetupOutcome{}, fmt.Errorf("setup of %s cancelled after %d attempts: %w", ifName, attempt, err)
+			}
 		}
 	}
 
-	return fmt.Errorf("failed to setup %s after %d attempts: %w",
-		ifName, ism.config.RetryAttempts, lastErr)
+	return SetupOutcome{}, fmt.Errorf("failed to setup %s after %d attempts: %w",
+		ifName, retry.RetryAttempts, lastErr)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // interfaceExists checks if a CAN interface exists in the system
@@ -184,77 +718,124 @@ func (ism *InterfaceSetupManager) interfaceExists(ifName string) bool {
 }
 
 // bringInterfaceDown brings CAN interface down
-func (ism *InterfaceSetupManager) bringInterfaceDown(ifName string) error {
+func (ism *InterfaceSetupManager) bringInterfaceDown(ctx context.Context, ifName string) error {
 	ism.logger.Printf("🔽 Bringing %s down...", ifName)
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", "link", "set", ifName, "down")
+	timeout := time.Duration(ism.GetSetupConfig().TimeoutSeconds) * time.Second
+	defer ism.invalidateState(ifName)
+	output, err := ism.commandExecutor.ExecuteContext(ctx, timeout, "ip", "link", "set", ifName, "down")
 	if err != nil {
 		ism.logger.Printf("❌ Failed to bring %s down: %v, output: %s", ifName, err, string(output))
-		return err
+		if permErr := checkPermission(ifName, output, err); permErr != nil {
+			return permErr
+		}
+		return newCommandError(output, err, "ip", "link", "set", ifName, "down")
 	}
 	ism.logger.Printf("✅ Successfully brought %s down", ifName)
 	return nil
 }
 
 // forceInterfaceDown forces interface down using different approach
-func (ism *InterfaceSetupManager) forceInterfaceDown(ifName string) error {
+func (ism *InterfaceSetupManager) forceInterfaceDown(ctx context.Context, ifName string) error {
 	ism.logger.Printf("🔽 Force bringing %s down...", ifName)
 
 	// Try using ifconfig as alternative
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ifconfig", ifName, "down")
+	timeout := time.Duration(ism.GetSetupConfig().TimeoutSeconds) * time.Second
+	defer ism.invalidateState(ifName)
+	output, err := ism.commandExecutor.ExecuteContext(ctx, timeout, "ifconfig", ifName, "down")
 	if err != nil {
 		ism.logger.Printf("❌ Failed to force %s down with ifconfig: %v, output: %s", ifName, err, string(output))
-		return err
+		return newCommandError(output, err, "ifconfig", ifName, "down")
 	}
 	ism.logger.Printf("✅ Successfully forced %s down with ifconfig", ifName)
 	return nil
 }
 
 // configureInterface configures CAN interface parameters
-func (ism *InterfaceSetupManager) configureInterface(ifName string) error {
+func (ism *InterfaceSetupManager) configureInterface(ctx context.Context, ifName string, config InterfaceSetupConfig) error {
 	ism.logger.Printf("⚙️ Configuring %s parameters...", ifName)
 
 	args := []string{"link", "set", ifName, "type", "can"}
 
 	// Add bitrate
-	args = append(args, "bitrate", strconv.Itoa(ism.config.Bitrate))
+	args = append(args, "bitrate", strconv.Itoa(config.Bitrate))
 
 	// Add sample point if specified
-	if ism.config.SamplePoint != "" {
-		args = append(args, "sample-point", ism.config.SamplePoint)
+	if config.SamplePoint != "" {
+		args = append(args, "sample-point", config.SamplePoint)
 	}
 
 	// Add restart-ms if specified
-	if ism.config.RestartMs > 0 {
-		args = append(args, "restart-ms", strconv.Itoa(ism.config.RestartMs))
+	if config.RestartMs > 0 {
+		args = append(args, "restart-ms", strconv.Itoa(config.RestartMs))
 	}
 
 	ism.logger.Printf("📝 Executing: ip %s", strings.Join(args, " "))
 
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", args...)
+	timeout := time.Duration(ism.GetSetupConfig().TimeoutSeconds) * time.Second
+	defer ism.invalidateState(ifName)
+	output, err := ism.commandExecutor.ExecuteContext(ctx, timeout, "ip", args...)
 
 	if err != nil {
 		ism.logger.Printf("❌ Configuration failed for %s: %v, output: %s", ifName, err, string(output))
-		return fmt.Errorf("configuration failed: %v, output: %s", err, string(output))
+		if permErr := checkPermission(ifName, output, err); permErr != nil {
+			return permErr
+		}
+		return fmt.Errorf("configuration failed: %w", newCommandError(output, err, "ip", args...))
 	}
 
 	ism.logger.Printf("✅ Successfully configured %s: bitrate=%d, sample-point=%s, restart-ms=%d",
-		ifName, ism.config.Bitrate, ism.config.SamplePoint, ism.config.RestartMs)
+		ifName, config.Bitrate, config.SamplePoint, config.RestartMs)
+
+	ism.appliedMutex.Lock()
+	ism.applied[ifName] = AppliedSetup{
+		Interface: ifName,
+		Config:    config,
+		Command:   "ip " + strings.Join(args, " "),
+		AppliedAt: time.Now(),
+		DryRun:    ism.dryRun,
+	}
+	ism.appliedMutex.Unlock()
 
 	return nil
 }
 
+// GetAppliedSetup returns the configuration last applied to an interface by this service
+func (ism *InterfaceSetupManager) GetAppliedSetup(ifName string) (AppliedSetup, bool) {
+	ism.appliedMutex.Lock()
+	defer ism.appliedMutex.Unlock()
+
+	applied, ok := ism.applied[ifName]
+	return applied, ok
+}
+
+// setupDrift lists where the live interface state no longer matches the applied configuration
+func setupDrift(applied AppliedSetup, state *InterfaceState) []string {
+	drift := []string{}
+	if state.Bitrate != applied.Config.Bitrate {
+		drift = append(drift, fmt.Sprintf("bitrate: applied %d, now %d", applied.Config.Bitrate, state.Bitrate))
+	}
+	if state.RestartMs != applied.Config.RestartMs {
+		drift = append(drift, fmt.Sprintf("restart-ms: applied %d, now %d", applied.Config.RestartMs, state.RestartMs))
+	}
+	if !state.IsUp {
+		drift = append(drift, "interface is down")
+	}
+	return drift
+}
+
 // bringInterfaceUp brings CAN interface up
-func (ism *InterfaceSetupManager) bringInterfaceUp(ifName string) error {
+func (ism *InterfaceSetupManager) bringInterfaceUp(ctx context.Context, ifName string) error {
 	ism.logger.Printf("🚀 Bringing %s up...", ifName)
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", "link", "set", ifName, "up")
+	timeout := time.Duration(ism.GetSetupConfig().TimeoutSeconds) * time.Second
+	defer ism.invalidateState(ifName)
+	output, err := ism.commandExecutor.ExecuteContext(ctx, timeout, "ip", "link", "set", ifName, "up")
 
 	if err != nil {
 		ism.logger.Printf("❌ Failed to bring %s up: %v, output: %s", ifName, err, string(output))
-		return fmt.Errorf("failed to bring interface up: %v, output: %s", err, string(output))
+		if permErr := checkPermission(ifName, output, err); permErr != nil {
+			return permErr
+		}
+		return fmt.Errorf("failed to bring interface up: %w", newCommandError(output, err, "ip", "link", "set", ifName, "up"))
 	}
 
 	ism.logger.Printf("✅ Successfully brought %s up", ifName)
@@ -262,10 +843,10 @@ func (ism *InterfaceSetupManager) bringInterfaceUp(ifName string) error {
 }
 
 // verifyInterface verifies that the interface is working properly
-func (ism *InterfaceSetupManager) verifyInterface(ifName string) error {
+func (ism *InterfaceSetupManager) verifyInterface(ifName string, config InterfaceSetupConfig) error {
 	ism.logger.Printf("🔍 Verifying %s configuration...", ifName)
 
-	state, err := ism.GetInterfaceState(ifName)
+	state, err := ism.RefreshInterfaceState(ifName)
 	if err != nil {
 		return fmt.Errorf("failed to get interface state: %w", err)
 	}
@@ -274,9 +855,9 @@ func (ism *InterfaceSetupManager) verifyInterface(ifName string) error {
 		return fmt.Errorf("interface is not up")
 	}
 
-	if state.Bitrate != ism.config.Bitrate {
+	if state.Bitrate != config.Bitrate {
 		return fmt.Errorf("bitrate mismatch: expected %d, got %d",
-			ism.config.Bitrate, state.Bitrate)
+			config.Bitrate, state.Bitrate)
 	}
 
 	if strings.Contains(strings.ToUpper(state.State), "ERROR") && !strings.Contains(strings.ToUpper(state.State), "ERROR-ACTIVE") {
@@ -289,84 +870,183 @@ func (ism *InterfaceSetupManager) verifyInterface(ifName string) error {
 	return nil
 }
 
-// GetInterfaceState gets current state of a CAN interface
+// GetInterfaceState gets the current state of a CAN interface. For an interface that does
+// not exist it returns a state with status LinkStatusNotFound and an error wrapping ErrInterfaceNotFound.
+// A state read within the cache TTL is reused, so frequent status polling does not spawn ip each time.
 func (ism *InterfaceSetupManager) GetInterfaceState(ifName string) (*InterfaceState, error) {
-	output, err := ism.commandExecutor.Execute("ip", "-details", "link", "show", ifName)
+	ism.stateMutex.Lock()
+	cached, ok := ism.stateCache[ifName]
+	fresh := ok && time.Since(cached.readAt) < ism.stateCacheTTL
+	ism.stateMutex.Unlock()
+
+	if fresh {
+		state := cached.state
+		return &state, nil
+	}
+	return ism.RefreshInterfaceState(ifName)
+}
+
+// RefreshInterfaceState reads the state of a CAN interface with ip, bypassing the cache,
+// and caches the result. Operations that act on the state use this.
+func (ism *InterfaceSetupManager) RefreshInterfaceState(ifName string) (*InterfaceState, error) {
+	if err := validateInterfaceName(ifName); err != nil {
+		return nil, err
+	}
+
+	ism.stateMutex.Lock()
+	generation := ism.stateGeneration
+	ism.stateMutex.Unlock()
+
+	readAt := time.Now()
+	state, err := ism.readInterfaceState(ifName)
+	if err != nil {
+		return state, err
+	}
+
+	ism.stateMutex.Lock()
+	if ism.stateCacheTTL > 0 && ism.stateGeneration == generation {
+		ism.stateCache[ifName] = cachedInterfaceState{state: *state, readAt: readAt}
+	}
+	ism.stateMutex.Unlock()
+	return state, nil
+}
+
+// readInterfaceState runs ip and parses the state of a CAN interface
+func (ism *InterfaceSetupManager) readInterfaceState(ifName string) (*InterfaceState, error) {
+
+	// -statistics adds the RX/TX counters used when the driver reports no berr-counter
+	output, err := ism.commandExecutor.Execute("ip", "-details", "-statistics", "link", "show", ifName)
 	if err != nil {
+		// iproute2 prints: Device "can9" does not exist.
+		if strings.Contains(string(output), "does not exist") {
+			return &InterfaceState{Name: ifName, Status: LinkStatusNotFound}, fmt.Errorf("%w: %s", ErrInterfaceNotFound, ifName)
+		}
 		return nil, fmt.Errorf("failed to get interface details: %w", err)
 	}
 
 	return ism.parseInterfaceState(ifName, string(output))
 }
 
+// Patterns for "ip link show" output, with and without -details -statistics
+var (
+	linkFlagsPattern   = regexp.MustCompile(`<([^>]*)>`)
+	virtualLinkPattern = regexp.MustCompile(`(?m)^\s+(vcan|vxcan)\b`)
+	linkStatePattern   = regexp.MustCompile(`state (\w+(?:-\w+)*)`)
+	mtuPattern         = regexp.MustCompile(`\bmtu (\d+)`)
+	linkTypePattern    = regexp.MustCompile(`link/(\S+)`)
+	canStatePattern    = regexp.MustCompile(`can (?:<[^>]*> )?state ([\w-]+)`)
+	bitratePattern     = regexp.MustCompile(`bitrate (\d+)`)
+	restartMsPattern   = regexp.MustCompile(`restart-ms (\d+)`)
+	berrCounterPattern = regexp.MustCompile(`berr-counter tx (\d+) rx (\d+)`)
+	statsErrorsPattern = regexp.MustCompile(`\d+\s+\d+\s+(\d+)`) // bytes, packets, then errors
+	linkNamePattern    = regexp.MustCompile(`^\d+:\s+(\w+):`)
+)
+
 // parseInterfaceState parses interface state from ip command output
 func (ism *InterfaceSetupManager) parseInterfaceState(ifName, output string) (*InterfaceState, error) {
 	state := &InterfaceState{
 		Name: ifName,
 	}
 
+	// Administrative UP flag from the link flags, e.g. <NOARP,UP,LOWER_UP,ECHO>
+	adminUp := false
+	if match := linkFlagsPattern.FindStringSubmatch(output); len(match) > 1 {
+		adminUp = slices.Contains(strings.Split(match[1], ","), "UP")
+	}
+
 	// Check if interface is UP
 	if strings.Contains(output, "state UP") {
 		state.IsUp = true
 	} else if strings.Contains(output, "state DOWN") {
 		state.IsUp = false
+	} else if strings.Contains(output, "state UNKNOWN") {
+		// Virtual links report UNKNOWN operstate; fall back to the administrative UP flag
+		state.IsUp = adminUp
 	}
 
+	// Detect virtual CAN links by name or by link kind
+	state.Virtual = isVirtualInterfaceName(ifName) || virtualLinkPattern.MatchString(output)
+
 	// Extract more detailed state information
-	if match := regexp.MustCompile(`state (\w+(?:-\w+)*)`).FindStringSubmatch(output); len(match) > 1 {
+	if match := linkStatePattern.FindStringSubmatch(output); len(match) > 1 {
 		state.State = match[1]
 	}
 
+	// Extract MTU and link type, e.g. "mtu 72 qdisc ..." and "link/can"
+	if match := mtuPattern.FindStringSubmatch(output); len(match) > 1 {
+		if mtu, err := strconv.Atoi(match[1]); err == nil {
+			state.MTU = mtu
+		}
+	}
+	if match := linkTypePattern.FindStringSubmatch(output); len(match) > 1 {
+		state.LinkType = match[1]
+	}
+
+	// Extract CAN controller state
+	if match := canStatePattern.FindStringSubmatch(output); len(match) > 1 {
+		state.CanState = match[1]
+	}
+
 	// Extract bitrate
-	if match := regexp.MustCompile(`bitrate (\d+)`).FindStringSubmatch(output); len(match) > 1 {
+	if match := bitratePattern.FindStringSubmatch(output); len(match) > 1 {
 		if bitrate, err := strconv.Atoi(match[1]); err == nil {
 			state.Bitrate = bitrate
 		}
 	}
 
 	// Extract restart-ms
-	if match := regexp.MustCompile(`restart-ms (\d+)`).FindStringSubmatch(output); len(match) > 1 {
+	if match := restartMsPattern.FindStringSubmatch(output); len(match) > 1 {
 		if restartMs, err := strconv.Atoi(match[1]); err == nil {
 			state.RestartMs = restartMs
 		}
 	}
 
-	// Get additional CAN statistics if available
-	ism.getCanStatistics(state, ifName)
+	// Error counters: the controller's berr-counter when the driver reports it,
+	// otherwise the generic RX/TX error statistics
+	if !ism.parseCanStatistics(state, output) {
+		ism.parseIpStatistics(state, output)
+	}
 
+	state.Status = linkStatus(state, adminUp)
 	return state, nil
 }
 
-// getCanStatistics gets additional CAN statistics
-func (ism *InterfaceSetupManager) getCanStatistics(state *InterfaceState, ifName string) {
-	// Try to get CAN statistics from /proc/net/can/stats
-	output, err := ism.commandExecutor.Execute("cat", fmt.Sprintf("/proc/net/can/stats/%s", ifName))
-	if err == nil {
-		ism.parseCanStatistics(state, string(output))
-	}
+// SupportsFD reports whether the interface MTU allows CAN FD frames
+func (s *InterfaceState) SupportsFD() bool {
+	return s.MTU >= CANFD_MTU
+}
 
-	// Try alternative approach with ip -s link show
-	output, err = ism.commandExecutor.Execute("ip", "-s", "link", "show", ifName)
-	if err == nil {
-		ism.parseIpStatistics(state, string(output))
+// linkStatus derives the summary status from the parsed link and controller state.
+// A bus-off controller drops the carrier, so its operstate reads DOWN even though the
+// interface is administratively up; that case is reported as bus-off, not down.
+func linkStatus(state *InterfaceState, adminUp bool) LinkStatus {
+	switch {
+	case adminUp && state.CanState == "BUS-OFF":
+		return LinkStatusBusOff
+	case !state.IsUp:
+		return LinkStatusDown
+	case state.CanState == "ERROR-PASSIVE":
+		return LinkStatusErrorPassive
+	default:
+		return LinkStatusUp
 	}
 }
 
-// parseCanStatistics parses CAN-specific statistics
-func (ism *InterfaceSetupManager) parseCanStatistics(state *InterfaceState, output string) {
-	// Parse TX errors
-	if match := regexp.MustCompile(`bus_error_tx:\s*(\d+)`).FindStringSubmatch(output); len(match) > 1 {
-		if txErrors, err := strconv.Atoi(match[1]); err == nil {
-			state.TxErrors = txErrors
-		}
+// parseCanStatistics reads the controller error counters from the
+// "can state ERROR-ACTIVE (berr-counter tx 0 rx 0)" line and reports whether it was present
+func (ism *InterfaceSetupManager) parseCanStatistics(state *InterfaceState, output string) bool {
+	match := berrCounterPattern.FindStringSubmatch(output)
+	if len(match) < 3 {
+		return false
 	}
 
-	// Parse RX errors
-	if match := regexp.MustCompile(`bus_error_rx:\s*(\d+)`).FindStringSubmatch(output); len(match) > 1 {
-		if rxErrors, err := strconv.Atoi(match[1]); err == nil {
-			state.RxErrors = rxErrors
-		}
+	if txErrors, err := strconv.Atoi(match[1]); err == nil {
+		state.TxErrors = txErrors
 	}
+	if rxErrors, err := strconv.Atoi(match[2]); err == nil {
+		state.RxErrors = rxErrors
+	}
+	return true
 }
 
 // parseIpStatistics parses statistics from ip command output
@@ -376,7 +1056,7 @@ func (ism *InterfaceSetupManager) parseIpStatistics(state *InterfaceState, outpu
 	for i, line := range lines {
 		if strings.Contains(line, "RX:") && i+1 < len(lines) {
 			// Next line should contain error stats
-			if match := regexp.MustCompile(`\d+\s+\d+\s+(\d+)`).FindStringSubmatch(lines[i+1]); len(match) > 1 {
+			if match := statsErrorsPattern.FindStringSubmatch(lines[i+1]); len(match) > 1 {
 				if rxErrors, err := strconv.Atoi(match[1]); err == nil {
 					state.RxErrors = rxErrors
 				}
@@ -384,7 +1064,7 @@ func (ism *InterfaceSetupManager) parseIpStatistics(state *InterfaceState, outpu
 		}
 		if strings.Contains(line, "TX:") && i+1 < len(lines) {
 			// Next line should contain error stats
-			if match := regexp.MustCompile(`\d+\s+\d+\s+(\d+)`).FindStringSubmatch(lines[i+1]); len(match) > 1 {
+			if match := statsErrorsPattern.FindStringSubmatch(lines[i+1]); len(match) > 1 {
 				if txErrors, err := strconv.Atoi(match[1]); err == nil {
 					state.TxErrors = txErrors
 				}
@@ -395,17 +1075,26 @@ func (ism *InterfaceSetupManager) parseIpStatistics(state *InterfaceState, outpu
 
 // ResetInterface resets a CAN interface (down and up)
 func (ism *InterfaceSetupManager) ResetInterface(ifName string) error {
+	if err := validateInterfaceName(ifName); err != nil {
+		return err
+	}
+	if ism.noSetup {
+		return ErrSetupDisabled
+	}
+
 	ism.logger.Printf("🔄 Resetting CAN interface %s", ifName)
 
-	if err := ism.bringInterfaceDown(ifName); err != nil {
+	if err := ism.bringInterfaceDown(context.Background(), ifName); err != nil {
 		return fmt.Errorf("failed to bring interface down: %w", err)
 	}
+	ism.recordTransition(ifName, TransitionDown, "reset", "")
 
 	time.Sleep(500 * time.Millisecond) // Brief pause
 
-	if err := ism.bringInterfaceUp(ifName); err != nil {
+	if err := ism.bringInterfaceUp(context.Background(), ifName); err != nil {
 		return fmt.Errorf("failed to bring interface up: %w", err)
 	}
+	ism.recordTransition(ifName, TransitionUp, "reset", "")
 
 	ism.logger.Printf("✅ Interface %s reset successfully", ifName)
 	return nil
@@ -413,11 +1102,25 @@ func (ism *InterfaceSetupManager) ResetInterface(ifName string) error {
 
 // TeardownInterface brings down a CAN interface
 func (ism *InterfaceSetupManager) TeardownInterface(ifName string) error {
+	if err := validateInterfaceName(ifName); err != nil {
+		return err
+	}
+	if ism.noSetup {
+		return ErrSetupDisabled
+	}
+
 	ism.logger.Printf("🔽 Tearing down CAN interface %s", ifName)
 
-	if err := ism.bringInterfaceDown(ifName); err != nil {
+	if err := ism.bringInterfaceDown(context.Background(), ifName); err != nil {
 		return fmt.Errorf("failed to teardown interface: %w", err)
 	}
+	ism.recordTransition(ifName, TransitionDown, "teardown", "")
+
+	if ism.IsCreatedByService(ifName) {
+		if err := ism.deleteInterface(ifName); err != nil {
+			return fmt.Errorf("failed to teardown interface: %w", err)
+		}
+	}
 
 	ism.logger.Printf("✅ Interface %s teardown complete", ifName)
 	return nil
@@ -434,7 +1137,7 @@ func (ism *InterfaceSetupManager) GetAvailableInterfaces() ([]string, error) {
 	lines := strings.Split(string(output), "\n")
 
 	for _, line := range lines {
-		if match := regexp.MustCompile(`^\d+:\s+(\w+):`).FindStringSubmatch(line); len(match) > 1 {
+		if match := linkNamePattern.FindStringSubmatch(line); len(match) > 1 {
 			interfaces = append(interfaces, match[1])
 		}
 	}
@@ -443,22 +1146,75 @@ func (ism *InterfaceSetupManager) GetAvailableInterfaces() ([]string, error) {
 	return interfaces, nil
 }
 
+// AvailableInterfaceDetail pairs a discovered interface with its current state
+type AvailableInterfaceDetail struct {
+	Name  string          `json:"name"`
+	State *InterfaceState `json:"state,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// maxStateLookupWorkers bounds concurrent `ip` invocations when enriching interface lists
+const maxStateLookupWorkers = 4
+
+// GetAvailableInterfacesDetailed returns available CAN interfaces along with their current state.
+// States are looked up concurrently; interfaces whose lookup fails are still listed with the error.
+func (ism *InterfaceSetupManager) GetAvailableInterfacesDetailed() ([]AvailableInterfaceDetail, error) {
+	interfaces, err := ism.GetAvailableInterfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]AvailableInterfaceDetail, len(interfaces))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := min(maxStateLookupWorkers, len(interfaces))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				details[i].Name = interfaces[i]
+				state, err := ism.GetInterfaceState(interfaces[i])
+				if err != nil {
+					details[i].Error = err.Error()
+					continue
+				}
+				details[i].State = state
+			}
+		}()
+	}
+
+	for i := range interfaces {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return details, nil
+}
+
 // ValidateSetupConfig validates the setup configuration
 func (ism *InterfaceSetupManager) ValidateSetupConfig() error {
-	if ism.config.Bitrate <= 0 {
+	return validateSetupConfig(ism.GetSetupConfig())
+}
+
+// validateSetupConfig checks a setup configuration without applying it
+func validateSetupConfig(config InterfaceSetupConfig) error {
+	if config.Bitrate <= 0 {
 		return fmt.Errorf("bitrate must be positive")
 	}
 
-	if ism.config.TimeoutSeconds <= 0 {
+	if config.TimeoutSeconds <= 0 {
 		return fmt.Errorf("timeout must be positive")
 	}
 
-	if ism.config.RetryAttempts <= 0 {
+	if config.RetryAttempts <= 0 {
 		return fmt.Errorf("retry attempts must be positive")
 	}
 
-	if ism.config.SamplePoint != "" {
-		if point, err := strconv.ParseFloat(ism.config.SamplePoint, 64); err != nil || point <= 0 || point >= 1 {
+	if config.SamplePoint != "" {
+		if point, err := strconv.ParseFloat(config.SamplePoint, 64); err != nil || point <= 0 || point >= 1 {
 			return fmt.Errorf("sample point must be between 0 and 1")
 		}
 	}
@@ -468,11 +1224,20 @@ func (ism *InterfaceSetupManager) ValidateSetupConfig() error {
 
 // GetSetupConfig returns current setup configuration
 func (ism *InterfaceSetupManager) GetSetupConfig() InterfaceSetupConfig {
+	ism.configMutex.RLock()
+	defer ism.configMutex.RUnlock()
 	return ism.config
 }
 
-// UpdateSetupConfig updates the setup configuration
+// UpdateSetupConfig validates the setup configuration and replaces the current one with it.
+// An invalid configuration is not applied.
 func (ism *InterfaceSetupManager) UpdateSetupConfig(config InterfaceSetupConfig) error {
+	if err := validateSetupConfig(config); err != nil {
+		return err
+	}
+
+	ism.configMutex.Lock()
+	defer ism.configMutex.Unlock()
 	ism.config = config
-	return ism.ValidateSetupConfig()
+	return nil
 }
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Output of "ip -details -statistics link show" for a controller whose driver reports berr-counter
+const ipOutputBerrCounter = `3: can0: <NOARP,UP,LOWER_UP,ECHO> mtu 16 qdisc pfifo_fast state UP mode DEFAULT group default qlen 10
+    link/can  promiscuity 0 minmtu 0 maxmtu 0
+    can state ERROR-WARNING (berr-counter tx 96 rx 17) restart-ms 100
+	  bitrate 500000 sample-point 0.875
+	  tq 125 prop-seg 6 phase-seg1 7 phase-seg2 2 sjw 1
+	  mcp251x: tseg1 3..16 tseg2 2..8 sjw 1..4 brp 1..64 brp-inc 1
+	  clock 8000000
+	  re-started bus-errors arbit-lost error-warn error-pass bus-off
+	  0          4          0          1          0          0         numtxqueues 1 numrxqueues 1 gso_max_size 65536 gso_max_segs 65535
+    RX: bytes  packets  errors  dropped overrun mcast
+    10240      1280     3       0       0       0
+    TX: bytes  packets  errors  dropped carrier collsns
+    4096       512      5       0       0       0
+`
+
+// The same for a driver without berr-counter support, e.g. gs_usb
+const ipOutputNoBerrCounter = `4: can1: <NOARP,UP,LOWER_UP,ECHO> mtu 16 qdisc pfifo_fast state UP mode DEFAULT group default qlen 10
+    link/can  promiscuity 0 minmtu 0 maxmtu 0
+    can state ERROR-ACTIVE restart-ms 0
+	  bitrate 250000 sample-point 0.875
+	  tq 250 prop-seg 6 phase-seg1 7 phase-seg2 2 sjw 1
+	  gs_usb: tseg1 1..16 tseg2 1..8 sjw 1..4 brp 1..1024 brp-inc 1
+	  clock 48000000
+	  re-started bus-errors arbit-lost error-warn error-pass bus-off
+	  0          0          0          0          0          0         numtxqueues 1 numrxqueues 1 gso_max_size 65536 gso_max_segs 65535
+    RX:  bytes packets errors dropped  missed   mcast
+         2048     256      7       0       0       0
+    TX:  bytes packets errors dropped carrier collsns
+         1024     128      2       0       0       0
+`
+
+// A virtual CAN FD link
+const ipOutputVcan = `5: vcan0: <NOARP,UP,LOWER_UP> mtu 72 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000
+    link/can  promiscuity 0 minmtu 0 maxmtu 0
+    vcan numtxqueues 1 numrxqueues 1 gso_max_size 65536 gso_max_segs 65535
+    RX:  bytes packets errors dropped  missed   mcast
+             0       0      0       0       0       0
+    TX:  bytes packets errors dropped carrier collsns
+             0       0      0       0       0       0
+`
+
+func TestParseStatistics(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		berr     bool
+		txErrors int
+		rxErrors int
+	}{
+		{name: "berr-counter", output: ipOutputBerrCounter, berr: true, txErrors: 96, rxErrors: 17},
+		{name: "no berr-counter", output: ipOutputNoBerrCounter, txErrors: 2, rxErrors: 7},
+		{name: "vcan", output: ipOutputVcan},
+	}
+
+	ism := &InterfaceSetupManager{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &InterfaceState{}
+			if berr := ism.parseCanStatistics(state, tt.output); berr != tt.berr {
+				t.Fatalf("parseCanStatistics = %v, want %v", berr, tt.berr)
+			}
+			if !tt.berr {
+				ism.parseIpStatistics(state, tt.output)
+			}
+			if state.TxErrors != tt.txErrors || state.RxErrors != tt.rxErrors {
+				t.Fatalf("errors tx %d rx %d, want tx %d rx %d", state.TxErrors, state.RxErrors, tt.txErrors, tt.rxErrors)
+			}
+		})
+	}
+}
+
+func TestParseInterfaceState(t *testing.T) {
+	tests := []struct {
+		name   string
+		ifName string
+		output string
+		want   InterfaceState
+	}{
+		{
+			name:   "berr-counter",
+			ifName: "can0",
+			output: ipOutputBerrCounter,
+			want: InterfaceState{Status: LinkStatusUp, IsUp: true, State: "UP", CanState: "ERROR-WARNING",
+				Bitrate: 500000, RestartMs: 100, MTU: CAN_MTU, LinkType: "can", TxErrors: 96, RxErrors: 17},
+		},
+		{
+			name:   "no berr-counter",
+			ifName: "can1",
+			output: ipOutputNoBerrCounter,
+			want: InterfaceState{Status: LinkStatusUp, IsUp: true, State: "UP", CanState: "ERROR-ACTIVE",
+				Bitrate: 250000, MTU: CAN_MTU, LinkType: "can", TxErrors: 2, RxErrors: 7},
+		},
+		{
+			name:   "vcan",
+			ifName: "vcan0",
+			output: ipOutputVcan,
+			want:   InterfaceState{Status: LinkStatusUp, IsUp: true, State: "UNKNOWN", Virtual: true, MTU: CANFD_MTU, LinkType: "can"},
+		},
+	}
+
+	ism := &InterfaceSetupManager{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := ism.parseInterfaceState(tt.ifName, tt.output)
+			if err != nil {
+				t.Fatalf("parseInterfaceState: %v", err)
+			}
+			tt.want.Name = tt.ifName
+			if *state != tt.want {
+				t.Fatalf("state = %+v\nwant %+v", *state, tt.want)
+			}
+		})
+	}
+}
+
+// fakeIPLink answers ip commands for one physical CAN interface and keeps its up/down state and bitrate
+type fakeIPLink struct {
+	mu        sync.Mutex
+	name      string
+	up        bool
+	bitrate   int
+	restartMs int
+}
+
+func (f *fakeIPLink) Execute(name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if name != "ip" {
+		return nil, fmt.Errorf("unexpected command %s", name)
+	}
+	switch {
+	case slices.Equal(args, []string{"link", "set", f.name, "up"}):
+		f.up = true
+	case slices.Equal(args, []string{"link", "set", f.name, "down"}):
+		f.up = false
+	case len(args) > 4 && slices.Equal(args[:5], []string{"link", "set", f.name, "type", "can"}):
+		for i := 5; i+1 < len(args); i++ {
+			switch args[i] {
+			case "bitrate":
+				f.bitrate, _ = strconv.Atoi(args[i+1])
+			case "restart-ms":
+				f.restartMs, _ = strconv.Atoi(args[i+1])
+			}
+		}
+	case slices.Contains(args, "show"):
+		return []byte(f.show()), nil
+	default:
+		return nil, fmt.Errorf("unexpected command ip %v", args)
+	}
+	return nil, nil
+}
+
+func (f *fakeIPLink) ExecuteWithTimeout(_ time.Duration, name string, args ...string) ([]byte, error) {
+	return f.Execute(name, args...)
+}
+
+func (f *fakeIPLink) ExecuteContext(_ context.Context, _ time.Duration, name string, args ...string) ([]byte, error) {
+	return f.Execute(name, args...)
+}
+
+// show renders the link the way ip -details -statistics link show does; the caller holds mu
+func (f *fakeIPLink) show() string {
+	flags, state := "NOARP,ECHO", "DOWN"
+	if f.up {
+		flags, state = "NOARP,UP,LOWER_UP,ECHO", "UP"
+	}
+	return fmt.Sprintf(`3: %s: <%s> mtu 16 qdisc pfifo_fast state %s mode DEFAULT group default qlen 10
+    link/can  promiscuity 0 minmtu 0 maxmtu 0
+    can state ERROR-ACTIVE (berr-counter tx 0 rx 0) restart-ms %d
+	  bitrate %d sample-point 0.750
+`, f.name, flags, state, f.restartMs, f.bitrate)
+}
+
+func TestSetupAndTeardownRecordTransitions(t *testing.T) {
+	link := &fakeIPLink{name: "can0"}
+	ism := NewInterfaceSetupManager(DefaultInterfaceSetupConfig(), link, discardLogger{})
+	history := NewInterfaceHistory(10)
+	ism.SetHistory(history)
+
+	outcome, err := ism.SetupInterfaceWithConfig(context.Background(), "can0", DefaultInterfaceSetupConfig())
+	if err != nil {
+		t.Fatalf("SetupInterfaceWithConfig: %v", err)
+	}
+	if !outcome.Changed || !link.up || link.bitrate != 1000000 {
+		t.Fatalf("outcome %+v, link up %t bitrate %d", outcome, link.up, link.bitrate)
+	}
+	if err := ism.TeardownInterface("can0"); err != nil {
+		t.Fatalf("TeardownInterface: %v", err)
+	}
+	if link.up {
+		t.Fatal("can0 is still up after teardown")
+	}
+
+	summary := history.Get("can0", 0)
+	if summary.UpCount != 1 || summary.DownCount != 1 || len(summary.Transitions) != 2 {
+		t.Fatalf("history %+v, want one setup and one teardown", summary)
+	}
+	if up := summary.Transitions[0]; up.Event != TransitionUp || up.Source != "setup" || up.Details != string(SetupReasonBroughtUp) {
+		t.Fatalf("first transition %+v, want up from setup", up)
+	}
+	if down := summary.Transitions[1]; down.Event != TransitionDown || down.Source != "teardown" {
+		t.Fatalf("second transition %+v, want down from teardown", down)
+	}
+}
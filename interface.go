@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
+	"strings"
 	"time"
 	"unsafe"
 
@@ -62,11 +65,17 @@ func (p *UnixSocketProvider) Close(fd int) error {
 	return unix.Close(fd)
 }
 
+// InterfaceStateProvider interface for dependency injection of OS-level interface state
+type InterfaceStateProvider interface {
+	GetInterfaceState(ifName string) (*InterfaceState, error)
+}
+
 // InterfaceManager manages CAN interfaces
 type InterfaceManager struct {
 	interfaces     map[string]*CanInterface
 	configProvider ConfigProvider
 	socketProvider SocketProvider
+	stateProvider  InterfaceStateProvider
 	logger         Logger
 }
 
@@ -93,7 +102,7 @@ func NewInterfaceManager(configProvider ConfigProvider, socketProvider SocketPro
 }
 
 // InitializeAll initializes all CAN interfaces based on configuration
-func (im *InterfaceManager) InitializeAll() error {
+func (im *InterfaceManager) InitializeAll(ctx context.Context) error {
 	ports := im.configProvider.GetCanPorts()
 	im.logger.Printf("🔧 Initializing CAN interfaces: %v", ports)
 
@@ -101,7 +110,10 @@ func (im *InterfaceManager) InitializeAll() error {
 	successCount := 0
 
 	for _, ifName := range ports {
-		err := im.InitializeSingle(ifName)
+		err := im.initializeSingle(ctx, ifName)
+		if ctx.Err() != nil {
+			return fmt.Errorf("initialization aborted: %w", ctx.Err())
+		}
 		if err != nil {
 			lastErr = err
 			im.logger.Printf("❌ Failed to initialize %s: %v", ifName, err)
@@ -122,6 +134,11 @@ func (im *InterfaceManager) InitializeAll() error {
 
 // InitializeSingle initializes a single CAN interface with retry logic
 func (im *InterfaceManager) InitializeSingle(ifName string) error {
+	return im.initializeSingle(context.Background(), ifName)
+}
+
+// initializeSingle initializes a single CAN interface, retrying until ctx is cancelled
+func (im *InterfaceManager) initializeSingle(ctx context.Context, ifName string) error {
 	retries := 5
 	retryDelay := 2 * time.Second
 
@@ -135,7 +152,9 @@ func (im *InterfaceManager) InitializeSingle(ifName string) error {
 
 		im.logger.Printf("⚠️ %s initialization attempt %d failed: %v. Retrying in %v...",
 			ifName, i+1, err, retryDelay)
-		time.Sleep(retryDelay)
+		if err := sleepContext(ctx, retryDelay); err != nil {
+			return fmt.Errorf("initialization of %s cancelled: %w", ifName, err)
+		}
 	}
 
 	return fmt.Errorf("failed to initialize %s after %d attempts", ifName, retries)
@@ -164,7 +183,7 @@ func (im *InterfaceManager) createInterface(ifName string) (*CanInterface, error
 	}
 
 	// Create interface struct
-	canIf := NewCanInterface(ifName, fd, addr)
+	canIf := NewCanInterface(ifName, fd, addr, im.configProvider.GetLatencyWindow())
 	return canIf, nil
 }
 
@@ -174,6 +193,12 @@ func (im *InterfaceManager) GetInterface(name string) (*CanInterface, bool) {
 	return canIf, ok
 }
 
+// InterfaceExists reports whether a network interface with this name exists at the OS level
+func (im *InterfaceManager) InterfaceExists(ifName string) bool {
+	_, err := net.InterfaceByName(ifName)
+	return err == nil
+}
+
 // GetAllInterfaces returns all interfaces
 func (im *InterfaceManager) GetAllInterfaces() map[string]*CanInterface {
 	result := make(map[string]*CanInterface)
@@ -213,23 +238,87 @@ func (im *InterfaceManager) Cleanup() {
 	im.interfaces = make(map[string]*CanInterface)
 }
 
-// CheckHealth performs a health check on an interface
+// SetStateProvider sets the provider used for passive health checks
+func (im *InterfaceManager) SetStateProvider(stateProvider InterfaceStateProvider) {
+	im.stateProvider = stateProvider
+}
+
+// GetInterfaceState returns the OS-level state of an interface from the state provider
+func (im *InterfaceManager) GetInterfaceState(ifName string) (*InterfaceState, error) {
+	if im.stateProvider == nil {
+		return nil, fmt.Errorf("interface state provider not available")
+	}
+	return im.stateProvider.GetInterfaceState(ifName)
+}
+
+// CheckHealth performs a health check on an interface.
+// The OS-level interface state is inspected first; an active probe frame is only
+// sent on the bus when that is not possible and -active-health-probe is enabled.
 func (im *InterfaceManager) CheckHealth(ifName string) bool {
 	canIf, ok := im.interfaces[ifName]
 	if !ok {
 		return false
 	}
 
+	if im.stateProvider != nil {
+		healthy, err := im.checkPassiveHealth(canIf)
+		if err == nil {
+			return healthy
+		}
+		im.logger.Printf("⚠️ %s passive health check unavailable: %v", ifName, err)
+	}
+
+	if !im.configProvider.GetActiveHealthProbe() {
+		return false
+	}
+
+	return im.checkActiveHealth(canIf)
+}
+
+// checkPassiveHealth inspects interface state without putting traffic on the bus
+func (im *InterfaceManager) checkPassiveHealth(canIf *CanInterface) (bool, error) {
+	state, err := im.stateProvider.GetInterfaceState(canIf.Name)
+	if err != nil {
+		return false, err
+	}
+
 	canIf.Lock()
 	defer canIf.Unlock()
 
-	// Simple probe message (0x00 is typically a diagnostic/echo ID)
-	frame := CanFrame{
-		ID:     0x00,
-		Length: 1,
-		Data:   [8]byte{0x00},
+	prevTx, prevRx := canIf.lastTxErrors, canIf.lastRxErrors
+	canIf.lastTxErrors, canIf.lastRxErrors = state.TxErrors, state.RxErrors
+
+	if !state.IsUp {
+		im.logger.Printf("⚠️ %s health check failed: interface is not up (state=%s)", canIf.Name, state.State)
+		return false, nil
+	}
+
+	if strings.EqualFold(state.CanState, "BUS-OFF") {
+		im.logger.Printf("⚠️ %s health check failed: controller is BUS-OFF", canIf.Name)
+		return false, nil
 	}
 
+	if state.TxErrors > prevTx || state.RxErrors > prevRx {
+		im.logger.Printf("⚠️ %s health check failed: error counters climbing (tx %d→%d, rx %d→%d)",
+			canIf.Name, prevTx, state.TxErrors, prevRx, state.RxErrors)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// checkActiveHealth sends a probe frame on the bus
+func (im *InterfaceManager) checkActiveHealth(canIf *CanInterface) bool {
+	ifName := canIf.Name
+
+	canIf.Lock()
+	defer canIf.Unlock()
+
+	// The probe is real traffic on a shared bus; its ID and payload come from -health-probe-id/-data
+	probeID, probeData := im.configProvider.GetHealthProbeFrame()
+	frame := CanFrame{ID: probeID}
+	frame.Length = uint8(copy(frame.Data[:], probeData))
+
 	buf := (*[16]byte)(unsafe.Pointer(&frame))[:]
 	err := im.socketProvider.SendTo(canIf.FD, buf, canIf.Addr)
 
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ISO-TP (ISO 15765-2) frame types, stored in the upper nibble of the first byte
+const (
+	isotpSingleFrame      = 0x0
+	isotpFirstFrame       = 0x1
+	isotpConsecutiveFrame = 0x2
+	isotpFlowControl      = 0x3
+)
+
+// ISO-TP flow control status values
+const (
+	isotpFlowContinue = 0x0
+	isotpFlowWait     = 0x1
+	isotpFlowOverflow = 0x2
+)
+
+const (
+	isotpMaxPayload      = 4095            // 12-bit length of classic ISO-TP
+	isotpFrameTimeout    = 1 * time.Second // N_Bs / N_Cr: max wait for flow control or the next consecutive frame
+	isotpMaxWaitFrames   = 10              // Max consecutive WAIT flow control frames before giving up
+	isotpSubscriberQueue = 64
+	isotpSource          = "isotp" // Source recorded on frames sent by ISO-TP transfers
+)
+
+// ErrISOTPTimeout is returned when the peer stops responding during an ISO-TP transfer
+var ErrISOTPTimeout = errors.New("ISO-TP timeout")
+
+// SendISOTP sends data as an ISO-TP message on txID, segmenting it when it exceeds
+// a single frame and honoring flow control frames received on rxID.
+func (ms *MessageSender) SendISOTP(interfaceName string, txID, rxID uint32, data []byte) error {
+	if err := ms.validateISOTP(interfaceName, txID, rxID, data); err != nil {
+		return err
+	}
+
+	// Flow control frames must be captured from the moment the first frame goes out
+	frames, cancel := ms.messageListener.SubscribeID(interfaceName, rxID, isotpSubscriberQueue)
+	defer cancel()
+
+	return ms.sendISOTP(interfaceName, txID, data, frames)
+}
+
+// RequestISOTP sends data as an ISO-TP message and waits for the reassembled response on rxID
+func (ms *MessageSender) RequestISOTP(interfaceName string, txID, rxID uint32, data []byte, timeout time.Duration) ([]byte, error) {
+	if err := ms.validateISOTP(interfaceName, txID, rxID, data); err != nil {
+		return nil, err
+	}
+
+	// One subscription covers both flow control for the request and the response frames
+	frames, cancel := ms.messageListener.SubscribeID(interfaceName, rxID, isotpSubscriberQueue)
+	defer cancel()
+
+	if err := ms.sendISOTP(interfaceName, txID, data, frames); err != nil {
+		return nil, err
+	}
+
+	return ms.receiveISOTP(interfaceName, txID, frames, timeout)
+}
+
+// ReceiveISOTP waits for the next ISO-TP message on rxID, e.g. one the peer sends unsolicited,
+// and returns it reassembled. Flow control for multi-frame messages is sent on txID.
+func (ms *MessageSender) ReceiveISOTP(interfaceName string, txID, rxID uint32, timeout time.Duration) ([]byte, error) {
+	if err := ms.validateISOTPChannel(interfaceName, txID, rxID); err != nil {
+		return nil, err
+	}
+
+	frames, cancel := ms.messageListener.SubscribeID(interfaceName, rxID, isotpSubscriberQueue)
+	defer cancel()
+
+	return ms.receiveISOTP(interfaceName, txID, frames, timeout)
+}
+
+// validateISOTPChannel checks the interface and ID pair of an ISO-TP transfer
+func (ms *MessageSender) validateISOTPChannel(interfaceName string, txID, rxID uint32) error {
+	if ms.messageListener == nil {
+		return fmt.Errorf("message listener not available")
+	}
+	if !ms.messageListener.IsListening(interfaceName) {
+		return fmt.Errorf("not listening on interface %s; ISO-TP needs the listener for flow control", interfaceName)
+	}
+	if txID == rxID {
+		return fmt.Errorf("txId and rxId must differ")
+	}
+	return nil
+}
+
+// validateISOTP checks ISO-TP transfer parameters
+func (ms *MessageSender) validateISOTP(interfaceName string, txID, rxID uint32, data []byte) error {
+	if err := ms.validateISOTPChannel(interfaceName, txID, rxID); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("ISO-TP payload cannot be empty")
+	}
+	if len(data) > isotpMaxPayload {
+		return fmt.Errorf("ISO-TP payload exceeds maximum length (%d bytes)", isotpMaxPayload)
+	}
+	return nil
+}
+
+// sendISOTP segments and sends data, reading flow control from frames
+func (ms *MessageSender) sendISOTP(interfaceName string, txID uint32, data []byte, frames <-chan CanMessageLog) error {
+	send := func(payload []byte) error {
+		return ms.SendCanMessage(CanMessage{Interface: interfaceName, ID: txID, Data: payload, Source: isotpSource})
+	}
+
+	// Single frame
+	if len(data) <= 7 {
+		return send(append([]byte{byte(len(data))}, data...))
+	}
+
+	// First frame carries the 12-bit total length and the first 6 bytes
+	first := append([]byte{byte(isotpFirstFrame<<4 | len(data)>>8), byte(len(data))}, data[:6]...)
+	if err := send(first); err != nil {
+		return fmt.Errorf("failed to send first frame: %w", err)
+	}
+
+	offset := 6
+	seq := byte(1)
+	for offset < len(data) {
+		blockSize, separation, err := waitFlowControl(frames)
+		if err != nil {
+			return err
+		}
+
+		for sent := 0; offset < len(data) && (blockSize == 0 || sent < blockSize); sent++ {
+			if sent > 0 && separation > 0 {
+				time.Sleep(separation)
+			}
+
+			end := min(offset+7, len(data))
+			frame := append([]byte{byte(isotpConsecutiveFrame<<4) | seq&0x0F}, data[offset:end]...)
+			if err := send(frame); err != nil {
+				return fmt.Errorf("failed to send consecutive frame %d: %w", seq, err)
+			}
+
+			offset = end
+			seq++
+		}
+	}
+
+	ms.logger.Printf("✅ %s ISO-TP message sent: ID=0x%X, %d bytes", interfaceName, txID, len(data))
+	return nil
+}
+
+// waitFlowControl waits for a clear-to-send flow control frame and returns its block size and STmin
+func waitFlowControl(frames <-chan CanMessageLog) (int, time.Duration, error) {
+	timer := time.NewTimer(isotpFrameTimeout)
+	defer timer.Stop()
+
+	waits := 0
+	for {
+		select {
+		case msg := <-frames:
+			if len(msg.Data) < 3 || msg.Data[0]>>4 != isotpFlowControl {
+				continue // Not a flow control frame
+			}
+
+			switch msg.Data[0] & 0x0F {
+			case isotpFlowContinue:
+				return int(msg.Data[1]), decodeSTmin(msg.Data[2]), nil
+			case isotpFlowWait:
+				waits++
+				if waits > isotpMaxWaitFrames {
+					return 0, 0, fmt.Errorf("receiver sent more than %d WAIT flow control frames", isotpMaxWaitFrames)
+				}
+				timer.Reset(isotpFrameTimeout)
+			case isotpFlowOverflow:
+				return 0, 0, fmt.Errorf("receiver reported buffer overflow")
+			default:
+				return 0, 0, fmt.Errorf("invalid flow control status 0x%X", msg.Data[0]&0x0F)
+			}
+		case <-timer.C:
+			return 0, 0, fmt.Errorf("no flow control frame within %v: %w", isotpFrameTimeout, ErrISOTPTimeout)
+		}
+	}
+}
+
+// decodeSTmin converts the STmin byte of a flow control frame into a duration
+func decodeSTmin(stmin byte) time.Duration {
+	switch {
+	case stmin <= 0x7F:
+		return time.Duration(stmin) * time.Millisecond
+	case stmin >= 0xF1 && stmin <= 0xF9:
+		return time.Duration(stmin-0xF0) * 100 * time.Microsecond
+	default:
+		return 0x7F * time.Millisecond // Reserved values are treated as the maximum
+	}
+}
+
+// receiveISOTP reassembles one ISO-TP message from frames, sending flow control on txID
+func (ms *MessageSender) receiveISOTP(interfaceName string, txID uint32, frames <-chan CanMessageLog, timeout time.Duration) ([]byte, error) {
+	reassembler := &ISOTPReassembler{}
+
+	// The first frame may take up to timeout; consecutive frames must follow within N_Cr
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case msg := <-frames:
+			payload, needFlowControl, err := reassembler.Feed(msg.Data)
+			if err != nil {
+				return nil, err
+			}
+			if needFlowControl {
+				// Clear to send everything, no separation time
+				fc := CanMessage{Interface: interfaceName, ID: txID, Data: []byte{isotpFlowControl << 4, 0x00, 0x00}, Source: isotpSource}
+				if err := ms.SendCanMessage(fc); err != nil {
+					return nil, fmt.Errorf("failed to send flow control: %w", err)
+				}
+			}
+			if payload != nil {
+				return payload, nil
+			}
+			if reassembler.InProgress() {
+				timer.Reset(isotpFrameTimeout)
+			}
+		case <-timer.C:
+			if reassembler.InProgress() {
+				return nil, fmt.Errorf("incomplete ISO-TP message: %w", ErrISOTPTimeout)
+			}
+			return nil, fmt.Errorf("no ISO-TP response within %v: %w", timeout, ErrISOTPTimeout)
+		}
+	}
+}
+
+// ISOTPReassembler rebuilds ISO-TP messages from single, first and consecutive frames
+type ISOTPReassembler struct {
+	expected int
+	data     []byte
+	nextSeq  byte
+	active   bool
+}
+
+// InProgress reports whether a multi-frame message is partially received
+func (r *ISOTPReassembler) InProgress() bool {
+	return r.active
+}
+
+// Feed processes one frame's data. It returns the payload when a message completes and
+// whether a flow control frame must be sent (after a first frame). Flow control frames
+// and consecutive frames outside a transfer are ignored.
+func (r *ISOTPReassembler) Feed(frame []byte) (payload []byte, needFlowControl bool, err error) {
+	if len(frame) == 0 {
+		return nil, false, nil
+	}
+
+	switch frame[0] >> 4 {
+	case isotpSingleFrame:
+		length := int(frame[0] & 0x0F)
+		if length == 0 || length > len(frame)-1 {
+			return nil, false, fmt.Errorf("invalid single frame length %d", length)
+		}
+		r.active = false
+		return append([]byte(nil), frame[1:1+length]...), false, nil
+
+	case isotpFirstFrame:
+		if len(frame) < 8 {
+			return nil, false, fmt.Errorf("first frame too short (%d bytes)", len(frame))
+		}
+		length := int(frame[0]&0x0F)<<8 | int(frame[1])
+		if length < 8 {
+			return nil, false, fmt.Errorf("invalid first frame length %d", length)
+		}
+		r.expected = length
+		r.data = append(make([]byte, 0, length), frame[2:]...)
+		r.nextSeq = 1
+		r.active = true
+		return nil, true, nil
+
+	case isotpConsecutiveFrame:
+		if !r.active {
+			return nil, false, nil
+		}
+		if seq := frame[0] & 0x0F; seq != r.nextSeq {
+			r.active = false
+			return nil, false, fmt.Errorf("unexpected sequence number %d (expected %d)", seq, r.nextSeq)
+		}
+		remaining := r.expected - len(r.data)
+		r.data = append(r.data, frame[1:1+min(remaining, len(frame)-1)]...)
+		r.nextSeq = (r.nextSeq + 1) & 0x0F
+		if len(r.data) >= r.expected {
+			r.active = false
+			return r.data, false, nil
+		}
+		return nil, false, nil
+	}
+
+	return nil, false, nil
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -17,45 +21,196 @@ type CanMessageLog struct {
 	Data      []byte    `json:"data"`
 	Length    uint8     `json:"length"`
 	Timestamp time.Time `json:"timestamp"`
-	Direction string    `json:"direction"` // "RX" for received messages
+	Direction string    `json:"direction"`        // "RX" from the bus, "TX" for frames sent from this host
+	Source    string    `json:"source,omitempty"` // Who sent a TX frame through this service, e.g. the API client IP
+	FD        bool      `json:"fd,omitempty"`     // Received as a CAN FD frame
+	Seq       uint64    `json:"seq"`              // Monotonic per-interface sequence number
+
+	// Identical consecutive frames folded into this entry after the first, with -collapse-duplicates.
+	// Timestamp and Seq then belong to the latest repeat.
+	RepeatCount uint32 `json:"repeatCount,omitempty"`
+
+	// Frames the kernel dropped on the socket between the previous message and this one
+	DroppedBefore uint32 `json:"droppedBefore,omitempty"`
 
 	HEX_ID   string   `json:"hex_id"`   // Hexadecimal representation of ID
 	HEX_Data []string `json:"hex_data"` // Hexadecimal representation of data
 }
 
+// bufferedFrame is the fixed-size form a message takes inside the ring buffer,
+// so storing a received frame never allocates. Readers get CanMessageLog copies.
+type bufferedFrame struct {
+	ID            uint32
+	Length        uint8
+	Data          [64]byte // Large enough for CAN FD payloads
+	FD            bool
+	Timestamp     time.Time
+	Direction     string
+	Source        string
+	Seq           uint64
+	DroppedBefore uint32
+	RepeatCount   uint32
+}
+
+// toLog materializes a CanMessageLog with its own Data and hex fields
+func (f *bufferedFrame) toLog(interfaceName string) CanMessageLog {
+	data := make([]byte, f.Length)
+	copy(data, f.Data[:f.Length])
+
+	return CanMessageLog{
+		Interface:     interfaceName,
+		ID:            f.ID,
+		Data:          data,
+		Length:        f.Length,
+		Timestamp:     f.Timestamp,
+		Direction:     f.Direction,
+		Source:        f.Source,
+		FD:            f.FD,
+		Seq:           f.Seq,
+		DroppedBefore: f.DroppedBefore,
+		RepeatCount:   f.RepeatCount,
+		HEX_ID:        fmt.Sprintf("%08x", f.ID),
+		HEX_Data:      bytesToHexArray(data),
+	}
+}
+
+// sameFrame reports whether two frames carry the same ID, payload, kind and origin
+func (f *bufferedFrame) sameFrame(other *bufferedFrame) bool {
+	return f.ID == other.ID && f.Length == other.Length && f.FD == other.FD &&
+		f.Direction == other.Direction && f.Source == other.Source &&
+		f.Data == other.Data
+}
+
 // InterfaceMessageBuffer manages message history for a single interface
 type InterfaceMessageBuffer struct {
 	interfaceName string
-	messages      []CanMessageLog
+	frames        []bufferedFrame // Ring storage, len(frames) == maxSize
+	start         int             // Index of the oldest frame
+	count         int             // Number of buffered frames
 	maxSize       int
 	mutex         sync.RWMutex
 	totalReceived uint64
+	lastSeq       uint64
+	droppedCount  uint64
+	malformed     uint64    // Reads whose length matched neither a classic nor an FD frame
+	lastRxTime    time.Time // Arrival of the newest frame from the bus, kept across clears
+	rxFrames      uint64    // Frames received from the bus, kept across clears
+	collapse      bool      // Fold identical consecutive frames into the newest entry
+	discard       bool      // Logging disabled: frames are counted but not stored
+	errorFrames   errorFrameLog
+	idCounts      idHistogram // Frames per ID, counted even while logging is off
+	readErrors    uint64      // Reads that failed with a real error, kept across clears
+	lastError     string      // The most recent of those errors
+	lastErrorTime time.Time
 }
 
 // NewInterfaceMessageBuffer creates a new message buffer for an interface
 func NewInterfaceMessageBuffer(interfaceName string, maxSize int) *InterfaceMessageBuffer {
 	return &InterfaceMessageBuffer{
 		interfaceName: interfaceName,
-		messages:      make([]CanMessageLog, 0, maxSize),
+		frames:        make([]bufferedFrame, maxSize),
 		maxSize:       maxSize,
 	}
 }
 
+// at returns the i-th oldest buffered frame; the caller holds the mutex
+func (buf *InterfaceMessageBuffer) at(i int) *bufferedFrame {
+	return &buf.frames[(buf.start+i)%len(buf.frames)]
+}
+
 // AddMessage adds a new message to the buffer
 func (buf *InterfaceMessageBuffer) AddMessage(msg CanMessageLog) {
+	frame := bufferedFrame{
+		ID:            msg.ID,
+		Timestamp:     msg.Timestamp,
+		Direction:     msg.Direction,
+		Source:        msg.Source,
+		FD:            msg.FD,
+		DroppedBefore: msg.DroppedBefore,
+	}
+	frame.Length = uint8(copy(frame.Data[:], msg.Data))
+	buf.addFrame(&frame)
+}
+
+// addFrame stores a copy of frame, assigns its sequence number and evicts the oldest when full
+func (buf *InterfaceMessageBuffer) addFrame(frame *bufferedFrame) {
 	buf.mutex.Lock()
 	defer buf.mutex.Unlock()
 
 	buf.totalReceived++
+	if frame.Direction == "RX" {
+		buf.lastRxTime = frame.Timestamp
+		buf.rxFrames++
+	}
+
+	// Assign sequence number (never reset, so clients can page across clears)
+	buf.lastSeq++
+	frame.Seq = buf.lastSeq
+	buf.idCounts.add(frame)
+
+	if buf.maxSize <= 0 || buf.discard {
+		return
+	}
+
+	// A repeat of the newest entry only refreshes it; it stays last, so Seq order is kept
+	if buf.collapse && buf.count > 0 {
+		if last := buf.at(buf.count - 1); last.sameFrame(frame) && frame.DroppedBefore == 0 {
+			last.RepeatCount++
+			last.Timestamp = frame.Timestamp
+			last.Seq = frame.Seq
+			return
+		}
+	}
+
+	buf.push(frame)
+}
+
+// push stores a copy of frame as the newest entry, overwriting the oldest once the ring is full.
+// The caller holds the mutex.
+func (buf *InterfaceMessageBuffer) push(frame *bufferedFrame) {
+	if buf.count < buf.maxSize {
+		*buf.at(buf.count) = *frame
+		buf.count++
+		return
+	}
+	buf.frames[buf.start] = *frame
+	buf.start = (buf.start + 1) % len(buf.frames)
+}
+
+// Restore appends saved messages, oldest first, keeping their sequence numbers, timestamps and
+// repeat counts. Frames received afterwards are numbered after the highest restored Seq.
+// Restored messages do not count as received.
+func (buf *InterfaceMessageBuffer) Restore(messages []CanMessageLog) {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
 
-	// Add message to buffer
-	buf.messages = append(buf.messages, msg)
+	for _, msg := range messages {
+		buf.lastSeq = max(buf.lastSeq, msg.Seq)
+		if buf.maxSize <= 0 || buf.discard {
+			continue
+		}
+		frame := bufferedFrame{
+			ID:            msg.ID,
+			FD:            msg.FD,
+			Timestamp:     msg.Timestamp,
+			Direction:     msg.Direction,
+			Source:        msg.Source,
+			Seq:           msg.Seq,
+			DroppedBefore: msg.DroppedBefore,
+			RepeatCount:   msg.RepeatCount,
+		}
+		frame.Length = uint8(copy(frame.Data[:], msg.Data))
+		buf.push(&frame)
+	}
+}
 
-	// Maintain buffer size limit
-	if len(buf.messages) > buf.maxSize {
-		// Remove oldest message
-		buf.messages = buf.messages[1:]
+// copyRange returns copies of the buffered frames in [from, to); the caller holds the mutex
+func (buf *InterfaceMessageBuffer) copyRange(from, to int) []CanMessageLog {
+	result := make([]CanMessageLog, 0, to-from)
+	for i := from; i < to; i++ {
+		result = append(result, buf.at(i).toLog(buf.interfaceName))
 	}
+	return result
 }
 
 // GetMessages returns a copy of all messages
@@ -63,10 +218,7 @@ func (buf *InterfaceMessageBuffer) GetMessages() []CanMessageLog {
 	buf.mutex.RLock()
 	defer buf.mutex.RUnlock()
 
-	// Return a copy to avoid race conditions
-	result := make([]CanMessageLog, len(buf.messages))
-	copy(result, buf.messages)
-	return result
+	return buf.copyRange(0, buf.count)
 }
 
 // GetRecentMessages returns the last N messages
@@ -78,17 +230,88 @@ func (buf *InterfaceMessageBuffer) GetRecentMessages(count int) []CanMessageLog
 		return []CanMessageLog{}
 	}
 
-	if count >= len(buf.messages) {
+	if count >= buf.count {
 		// Return all messages
-		result := make([]CanMessageLog, len(buf.messages))
-		copy(result, buf.messages)
-		return result
+		return buf.copyRange(0, buf.count)
 	}
 
 	// Return last N messages
-	start := len(buf.messages) - count
-	result := make([]CanMessageLog, count)
-	copy(result, buf.messages[start:])
+	return buf.copyRange(buf.count-count, buf.count)
+}
+
+// QueryOptions filters buffered messages. Nil or zero fields are ignored.
+type QueryOptions struct {
+	ID    *uint32   // Exact ID match
+	IDMin *uint32   // Inclusive lower bound on ID
+	IDMax *uint32   // Inclusive upper bound on ID
+	Since time.Time // Inclusive lower bound on timestamp
+	Until time.Time // Inclusive upper bound on timestamp
+
+	Filter *FilterSpec // ID ranges and frame type, shared with subscriptions and the frame socket
+
+	AfterSeq uint64 // Only messages with a greater sequence number (cursor paging)
+	Offset   int    // Number of matching messages to skip
+	Limit    int    // Maximum number of messages to return (0 means no limit)
+}
+
+// QueryResult holds one page of matching messages
+type QueryResult struct {
+	Messages []CanMessageLog
+	Matched  int // Number of messages matching the filters, before paging
+	Total    int // Number of messages in the buffer
+}
+
+// Matches reports whether a message satisfies the query options
+func (opts QueryOptions) Matches(msg *CanMessageLog) bool {
+	return opts.matches(msg.ID, msg.Timestamp, msg.Seq)
+}
+
+// matches applies the query filters to the fields they look at
+func (opts QueryOptions) matches(id uint32, timestamp time.Time, seq uint64) bool {
+	if opts.ID != nil && id != *opts.ID {
+		return false
+	}
+	if opts.IDMin != nil && id < *opts.IDMin {
+		return false
+	}
+	if opts.IDMax != nil && id > *opts.IDMax {
+		return false
+	}
+	if !opts.Since.IsZero() && timestamp.Before(opts.Since) {
+		return false
+	}
+	if !opts.Until.IsZero() && timestamp.After(opts.Until) {
+		return false
+	}
+	if !opts.Filter.Matches(id) {
+		return false
+	}
+	if seq <= opts.AfterSeq {
+		return false
+	}
+	return true
+}
+
+// Query returns a page of copies of the messages matching opts.
+// Filtering happens under the read lock so only the returned page is copied.
+func (buf *InterfaceMessageBuffer) Query(opts QueryOptions) QueryResult {
+	buf.mutex.RLock()
+	defer buf.mutex.RUnlock()
+
+	result := QueryResult{
+		Messages: make([]CanMessageLog, 0),
+		Total:    buf.count,
+	}
+	for i := 0; i < buf.count; i++ {
+		frame := buf.at(i)
+		if !opts.matches(frame.ID, frame.Timestamp, frame.Seq) {
+			continue
+		}
+		if result.Matched >= opts.Offset && (opts.Limit <= 0 || len(result.Messages) < opts.Limit) {
+			result.Messages = append(result.Messages, frame.toLog(buf.interfaceName))
+		}
+		result.Matched++
+	}
 	return result
 }
 
@@ -98,11 +321,18 @@ func (buf *InterfaceMessageBuffer) GetStatistics() map[string]interface{} {
 	defer buf.mutex.RUnlock()
 
 	return map[string]interface{}{
-		"interface":     buf.interfaceName,
-		"totalReceived": buf.totalReceived,
-		"bufferedCount": len(buf.messages),
-		"maxBufferSize": buf.maxSize,
-		"bufferUsage":   float64(len(buf.messages)) / float64(buf.maxSize) * 100,
+		"interface":       buf.interfaceName,
+		"totalReceived":   buf.totalReceived,
+		"bufferedCount":   buf.count,
+		"maxBufferSize":   buf.maxSize,
+		"bufferUsage":     float64(buf.count) / float64(buf.maxSize) * 100,
+		"droppedCount":    buf.droppedCount,
+		"malformedFrames": buf.malformed,
+		"errorFrames":     buf.errorFrames.count(),
+		"logging":         !buf.discard,
+		"readErrors":      buf.readErrors,
+		"lastError":       buf.lastError,
+		"lastErrorTime":   buf.lastErrorTime,
 	}
 }
 
@@ -111,65 +341,740 @@ func (buf *InterfaceMessageBuffer) Clear() {
 	buf.mutex.Lock()
 	defer buf.mutex.Unlock()
 
-	buf.messages = buf.messages[:0] // Clear slice but keep capacity
+	buf.start, buf.count = 0, 0 // Keep the ring storage
 	buf.totalReceived = 0
+	buf.droppedCount = 0
+	buf.malformed = 0
+	buf.errorFrames.reset()
+	buf.idCounts.reset()
+}
+
+// RemoveID drops the buffered frames with the given ID, keeping the order of the rest,
+// forgets the ID's histogram entry and returns the number of frames removed
+func (buf *InterfaceMessageBuffer) RemoveID(id uint32) int {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	buf.idCounts.remove(id)
+	if buf.discard {
+		return 0
+	}
+
+	// Rebuild the ring from the frames that stay, oldest first
+	frames := make([]bufferedFrame, len(buf.frames))
+	kept := 0
+	for i := 0; i < buf.count; i++ {
+		if frame := buf.at(i); frame.ID != id {
+			frames[kept] = *frame
+			kept++
+		}
+	}
+	removed := buf.count - kept
+	buf.frames = frames
+	buf.start, buf.count = 0, kept
+	return removed
+}
+
+// GetIDCounts returns the per-ID frame counts and the frames whose ID was not tracked
+// because maxTrackedIDs distinct IDs had already been seen
+func (buf *InterfaceMessageBuffer) GetIDCounts() ([]CanIDStats, uint64) {
+	buf.mutex.RLock()
+	defer buf.mutex.RUnlock()
+
+	return buf.idCounts.snapshot(), buf.idCounts.untracked
+}
+
+// SetMaxSize changes the buffer capacity, discarding the oldest messages if it shrinks
+func (buf *InterfaceMessageBuffer) SetMaxSize(maxSize int) {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	buf.maxSize = maxSize
+	if buf.discard {
+		return // Storage is allocated when logging is enabled again
+	}
+
+	// Re-pack the newest frames into a ring of the new size
+	keep := min(buf.count, maxSize)
+	frames := make([]bufferedFrame, maxSize)
+	for i := 0; i < keep; i++ {
+		frames[i] = *buf.at(buf.count - keep + i)
+	}
+	buf.frames = frames
+	buf.start, buf.count = 0, keep
+}
+
+// SetLogging turns storing of frames on or off. While off, the buffered frames and their
+// storage are released and received frames only update the counters.
+func (buf *InterfaceMessageBuffer) SetLogging(enabled bool) {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	if buf.discard == !enabled {
+		return
+	}
+	buf.discard = !enabled
+	buf.start, buf.count = 0, 0
+	if enabled {
+		buf.frames = make([]bufferedFrame, buf.maxSize)
+	} else {
+		buf.frames = nil
+	}
+}
+
+// SetCollapseDuplicates turns folding of identical consecutive frames on or off
+func (buf *InterfaceMessageBuffer) SetCollapseDuplicates(collapse bool) {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	buf.collapse = collapse
+}
+
+// RecordMalformed counts a read that could not be parsed as a CAN frame and returns the new total
+func (buf *InterfaceMessageBuffer) RecordMalformed() uint64 {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	buf.malformed++
+	return buf.malformed
+}
+
+// RecordReadError keeps a failed read of the socket as the interface's last error
+func (buf *InterfaceMessageBuffer) RecordReadError(err error) {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	buf.readErrors++
+	buf.lastError = err.Error()
+	buf.lastErrorTime = time.Now()
+}
+
+// isTransientReadError reports whether a failed read is expected to succeed when retried;
+// those are not kept as an interface's last error
+func isTransientReadError(err error) bool {
+	return errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) || errors.Is(err, unix.ETIMEDOUT)
+}
+
+// RecordDropped adds frames reported as dropped by the kernel
+func (buf *InterfaceMessageBuffer) RecordDropped(count uint32) {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	buf.droppedCount += uint64(count)
 }
 
 // CanMessageListener manages listening to CAN messages on multiple interfaces
 type CanMessageListener struct {
-	buffers      map[string]*InterfaceMessageBuffer
-	buffersMutex sync.RWMutex
-	listeners    map[string]*interfaceListener
-	maxMessages  int
-	logger       Logger
-	ctx          context.Context
-	cancel       context.CancelFunc
+	buffers       map[string]*InterfaceMessageBuffer
+	buffersMutex  sync.RWMutex
+	listeners     map[string]*interfaceListener
+	listenRefs    map[string]*listenRefs // Handles held per interface; see ListenHandle
+	maxMessages   int
+	maxListeners  int                    // Running listeners allowed at once; 0 is unlimited
+	collapse      bool                   // Fold identical consecutive frames in new and existing buffers
+	rxBufferSize  int                    // Requested SO_RCVBUF in bytes; 0 keeps the kernel default
+	loggingOff    map[string]bool        // Interfaces whose frames are counted but not buffered
+	errorMask     uint32                 // CAN_RAW_ERR_FILTER for sockets opened from now on; 0 receives no error frames
+	stateProvider InterfaceStateProvider // Without one, interfaces are assumed to be up
+	logger        Logger
+	errorLog      *sampledLogger // Repeating per-interface errors, logged first in full and then summarized
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	subscriptions map[uint64]*frameSubscription
+	subsMutex     sync.Mutex
+	nextSubID     uint64
+
+	pendingTX map[string][]pendingTransmit // Sources of frames sent by this service, awaiting their loopback
+	txMutex   sync.Mutex
+}
+
+// ErrListenerLimit is returned by StartListening when -max-listeners listeners are already running
+var ErrListenerLimit = errors.New("listener limit reached")
+
+// listenUpRecheckInterval is how often a listener waiting for its interface checks whether it came up
+const listenUpRecheckInterval = 2 * time.Second
+
+// pendingTransmit remembers who sent a frame until the listener sees it looped back
+type pendingTransmit struct {
+	id     uint32
+	length uint8
+	data   [8]byte
+	source string
+	sentAt time.Time
+}
+
+// Bounds on pendingTransmit entries; a frame whose loopback never shows up must not leak
+const (
+	maxPendingTransmits = 256
+	pendingTransmitTTL  = time.Second
+)
+
+// defaultSubscriptionBuffer is the channel capacity for Subscribe
+const defaultSubscriptionBuffer = 256
+
+// frameSubscription delivers received frames passing its filters on an interface
+type frameSubscription struct {
+	id            uint64
+	interfaceName string
+	filter        *FilterSpec // Software filter applied at fan-out; nil matches every frame
+	ch            chan CanMessageLog
+	once          bool // Remove after the first delivered frame
+	rxOnly        bool // Skip frames sent from this host, so a request never matches itself
+	delivered     uint64
+	dropped       uint64 // Frames discarded because the subscriber was not keeping up
+}
+
+// SubscriptionStats describes one active subscription
+type SubscriptionStats struct {
+	ID        uint64      `json:"id"`
+	Interface string      `json:"interface"`
+	Filter    *FilterSpec `json:"filter,omitempty"`
+	Delivered uint64      `json:"delivered"`
+	Dropped   uint64      `json:"dropped"`
 }
 
 // interfaceListener manages listening for a single interface
 type interfaceListener struct {
 	interfaceName string
 	socket        int
-	isRunning     bool
-	stopChan      chan bool
+	isRunning     atomic.Bool   // Written by the listen goroutine, read under buffersMutex
+	stopChan      chan struct{} // Closed to signal the listen goroutine to exit
+	stopOnce      sync.Once
+	wakeFd        int // eventfd that wakes the goroutine out of poll when stopping
+	fdMutex       sync.Mutex
+	fdsClosed     bool // socket and wakeFd are closed by the goroutine on exit
 	buffer        *InterfaceMessageBuffer
 	logger        Logger
+	lastOverflow  uint32      // Last cumulative SO_RXQ_OVFL value seen on the socket
+	rxBufferBytes int         // Actual SO_RCVBUF reported by the kernel
+	waitingForUp  atomic.Bool // The interface was down at start; the socket is opened once it is up
+}
+
+// NewCanMessageListener creates a new CAN message listener
+func NewCanMessageListener(maxMessages int, logger Logger) *CanMessageListener {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &CanMessageListener{
+		buffers:       make(map[string]*InterfaceMessageBuffer),
+		listeners:     make(map[string]*interfaceListener),
+		listenRefs:    make(map[string]*listenRefs),
+		loggingOff:    make(map[string]bool),
+		maxMessages:   maxMessages,
+		logger:        logger,
+		errorLog:      newSampledLogger(logger, repeatLogInterval),
+		ctx:           ctx,
+		cancel:        cancel,
+		subscriptions: make(map[uint64]*frameSubscription),
+		pendingTX:     make(map[string][]pendingTransmit),
+	}
+}
+
+// SubscribeOnce registers a one-shot subscription for the next frame with the given ID on an interface.
+// The returned channel receives at most one message; cancel must be called to release the
+// subscription if no frame arrives.
+func (cml *CanMessageListener) SubscribeOnce(interfaceName string, id uint32) (<-chan CanMessageLog, func()) {
+	return cml.subscribe(interfaceName, exactIDFilter(id), 1, true, true)
+}
+
+// SubscribeID registers a subscription for every frame with the given ID on an interface.
+// Frames are dropped when the channel's buffer is full; cancel releases the subscription.
+func (cml *CanMessageListener) SubscribeID(interfaceName string, id uint32, bufferSize int) (<-chan CanMessageLog, func()) {
+	return cml.subscribe(interfaceName, exactIDFilter(id), bufferSize, false, true)
+}
+
+// SubscribeRX registers a subscription for frames received from the bus on an interface passing
+// the filter; frames sent from this host are skipped. Frames are dropped when the channel's buffer
+// is full; cancel releases the subscription.
+func (cml *CanMessageListener) SubscribeRX(interfaceName string, filter *FilterSpec, bufferSize int) (<-chan CanMessageLog, func()) {
+	return cml.subscribe(interfaceName, filter, bufferSize, false, true)
+}
+
+// Subscribe registers a subscription for frames on an interface passing the filter
+// (all frames when filter is nil). An empty interface name subscribes to every interface. Any number of subscribers can watch the same interface;
+// a slow subscriber has frames dropped and counted against it instead of blocking the others.
+// The returned func unsubscribes and closes the channel.
+func (cml *CanMessageListener) Subscribe(interfaceName string, filter *FilterSpec) (<-chan CanMessageLog, func()) {
+	return cml.subscribe(interfaceName, filter, defaultSubscriptionBuffer, false, false)
+}
+
+// subscribe registers a frame subscription and returns its channel and cancel func
+func (cml *CanMessageListener) subscribe(interfaceName string, filter *FilterSpec, bufferSize int, once, rxOnly bool) (<-chan CanMessageLog, func()) {
+	if filter.IsEmpty() {
+		filter = nil
+	} else {
+		filter = &FilterSpec{ // Private copy, the caller may reuse theirs
+			Include:      slices.Clone(filter.Include),
+			Exclude:      slices.Clone(filter.Exclude),
+			ExtendedOnly: filter.ExtendedOnly,
+			StandardOnly: filter.StandardOnly,
+		}
+	}
+	sub := &frameSubscription{
+		interfaceName: interfaceName,
+		filter:        filter,
+		ch:            make(chan CanMessageLog, bufferSize),
+		once:          once,
+		rxOnly:        rxOnly,
+	}
+
+	cml.subsMutex.Lock()
+	cml.nextSubID++
+	sub.id = cml.nextSubID
+	cml.subscriptions[sub.id] = sub
+	cml.subsMutex.Unlock()
+
+	cancel := func() {
+		cml.subsMutex.Lock()
+		defer cml.subsMutex.Unlock()
+		if _, exists := cml.subscriptions[sub.id]; exists {
+			delete(cml.subscriptions, sub.id)
+			close(sub.ch)
+		}
+	}
+
+	return sub.ch, cancel
+}
+
+// dispatch hands a received frame to matching subscriptions without blocking.
+// A CanMessageLog is only built when some subscription matches.
+func (cml *CanMessageListener) dispatch(interfaceName string, frame *bufferedFrame) {
+	cml.subsMutex.Lock()
+	defer cml.subsMutex.Unlock()
+
+	for subID, sub := range cml.subscriptions {
+		if (sub.interfaceName != "" && sub.interfaceName != interfaceName) || !sub.filter.Matches(frame.ID) {
+			continue
+		}
+		if sub.rxOnly && frame.Direction == "TX" {
+			continue
+		}
+		select {
+		case sub.ch <- frame.toLog(interfaceName):
+			sub.delivered++
+		default:
+			sub.dropped++
+			if sub.dropped == 1 {
+				cml.logger.Printf("⚠️ Subscriber %d on %s is not keeping up, dropping frames", subID, interfaceName)
+			}
+		}
+		if sub.once {
+			delete(cml.subscriptions, subID)
+		}
+	}
+}
+
+// GetSubscriptions returns delivery statistics for the active subscriptions, ordered by ID
+func (cml *CanMessageListener) GetSubscriptions() []SubscriptionStats {
+	cml.subsMutex.Lock()
+	defer cml.subsMutex.Unlock()
+
+	result := make([]SubscriptionStats, 0, len(cml.subscriptions))
+	for _, sub := range cml.subscriptions {
+		result = append(result, SubscriptionStats{
+			ID:        sub.id,
+			Interface: sub.interfaceName,
+			Filter:    sub.filter,
+			Delivered: sub.delivered,
+			Dropped:   sub.dropped,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// NoteTransmit records the source of a frame about to be sent, so the listener can tag
+// the looped-back copy. It does nothing unless the interface is being listened to.
+func (cml *CanMessageListener) NoteTransmit(interfaceName string, id uint32, data []byte, source string) {
+	if source == "" || !cml.IsListening(interfaceName) {
+		return
+	}
+
+	entry := pendingTransmit{id: id, source: source, sentAt: time.Now()}
+	entry.length = uint8(copy(entry.data[:], data))
+
+	cml.txMutex.Lock()
+	defer cml.txMutex.Unlock()
+
+	pending := append(cml.pendingTX[interfaceName], entry)
+	if len(pending) > maxPendingTransmits {
+		pending = pending[len(pending)-maxPendingTransmits:]
+	}
+	cml.pendingTX[interfaceName] = pending
+}
+
+// takeTransmitSource returns and forgets the source noted for a looped-back frame.
+// Entries older than pendingTransmitTTL are discarded along the way.
+func (cml *CanMessageListener) takeTransmitSource(interfaceName string, frame *bufferedFrame) string {
+	cml.txMutex.Lock()
+	defer cml.txMutex.Unlock()
+
+	pending := cml.pendingTX[interfaceName]
+	if len(pending) == 0 {
+		return ""
+	}
+
+	// Drop expired entries from the front; frames loop back in send order
+	cutoff := frame.Timestamp.Add(-pendingTransmitTTL)
+	expired := 0
+	for expired < len(pending) && pending[expired].sentAt.Before(cutoff) {
+		expired++
+	}
+	pending = pending[expired:]
+
+	source := ""
+	for i := range pending {
+		entry := &pending[i]
+		if entry.id == frame.ID && entry.length == frame.Length && entry.data == [8]byte(frame.Data[:8]) {
+			source = entry.source
+			pending = append(pending[:i], pending[i+1:]...)
+			break
+		}
+	}
+	cml.pendingTX[interfaceName] = pending
+	return source
+}
+
+// ListenHandle is one holder's reference to the listener of an interface. Components sharing an
+// interface each hold a handle; listening stops when the last one is released.
+type ListenHandle struct {
+	listener      *CanMessageListener
+	interfaceName string
+	refs          *listenRefs
+	releaseOnce   sync.Once
+	released      bool // Guarded by the listener's buffersMutex
+}
+
+// listenRefs counts the handles held on an interface's listener
+type listenRefs struct {
+	count int
+}
+
+// StartListening starts listening on a specific CAN interface, or joins the running listener,
+// and returns a handle that keeps it running until released.
+// An existing message buffer is reused, so history survives a stop/start;
+// call ClearMessages first for a fresh buffer.
+func (cml *CanMessageListener) StartListening(interfaceName string) (*ListenHandle, error) {
+	if err := cml.startListening(interfaceName); err != nil {
+		return nil, err
+	}
+
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+
+	refs, exists := cml.listenRefs[interfaceName]
+	if !exists {
+		refs = &listenRefs{}
+		cml.listenRefs[interfaceName] = refs
+	}
+	refs.count++
+	return &ListenHandle{listener: cml, interfaceName: interfaceName, refs: refs}, nil
+}
+
+// Release drops this holder's reference and stops listening if it was the last one.
+// It is a no-op after the first call, or once the listener was stopped with StopListening.
+func (h *ListenHandle) Release() error {
+	var err error
+	h.releaseOnce.Do(func() {
+		cml := h.listener
+		cml.buffersMutex.Lock()
+		h.released = true
+		if cml.listenRefs[h.interfaceName] != h.refs {
+			cml.buffersMutex.Unlock()
+			return // Stopped for everyone in the meantime
+		}
+		h.refs.count--
+		if remaining := h.refs.count; remaining > 0 {
+			cml.buffersMutex.Unlock()
+			cml.logger.Printf("👥 Listener on %s released; %d holder(s) remain", h.interfaceName, remaining)
+			return
+		}
+		cml.buffersMutex.Unlock()
+
+		err = cml.StopListening(h.interfaceName)
+	})
+	return err
+}
+
+// Active reports whether the handle still holds the listener: it was not released, and the
+// listener was not stopped for every holder with StopListening
+func (h *ListenHandle) Active() bool {
+	h.listener.buffersMutex.RLock()
+	defer h.listener.buffersMutex.RUnlock()
+	return !h.released && h.listener.listenRefs[h.interfaceName] == h.refs
+}
+
+// ListenHandles keeps at most one handle per interface for one holder, such as the service and
+// its API, so repeated starts don't pile up references and a stop releases only this holder's
+type ListenHandles struct {
+	listener *CanMessageListener
+	handles  map[string]*ListenHandle
+	mu       sync.Mutex
+}
+
+// NewListenHandles creates an empty handle set on listener
+func NewListenHandles(listener *CanMessageListener) *ListenHandles {
+	return &ListenHandles{
+		listener: listener,
+		handles:  make(map[string]*ListenHandle),
+	}
+}
+
+// Acquire starts listening on an interface, or joins its running listener, unless this holder
+// already has an active handle on it
+func (lh *ListenHandles) Acquire(interfaceName string) error {
+	lh.mu.Lock()
+	defer lh.mu.Unlock()
+
+	if handle, exists := lh.handles[interfaceName]; exists && handle.Active() {
+		return nil
+	}
+	handle, err := lh.listener.StartListening(interfaceName)
+	if err != nil {
+		return err
+	}
+	lh.handles[interfaceName] = handle
+	return nil
+}
+
+// Release drops this holder's handle on an interface; listening only stops if no other holder
+// remains. released is false if the holder had no active handle.
+func (lh *ListenHandles) Release(interfaceName string) (released bool, err error) {
+	lh.mu.Lock()
+	handle, exists := lh.handles[interfaceName]
+	delete(lh.handles, interfaceName)
+	lh.mu.Unlock()
+
+	if !exists || !handle.Active() {
+		return false, nil
+	}
+	return true, handle.Release()
+}
+
+// GetListenerHolders returns how many handles are held on the listener of an interface
+func (cml *CanMessageListener) GetListenerHolders(interfaceName string) int {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	if refs, exists := cml.listenRefs[interfaceName]; exists {
+		return refs.count
+	}
+	return 0
 }
 
-// NewCanMessageListener creates a new CAN message listener
-func NewCanMessageListener(maxMessages int, logger Logger) *CanMessageListener {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &CanMessageListener{
-		buffers:     make(map[string]*InterfaceMessageBuffer),
-		listeners:   make(map[string]*interfaceListener),
-		maxMessages: maxMessages,
-		logger:      logger,
-		ctx:         ctx,
-		cancel:      cancel,
-	}
-}
+// startListening opens a socket and starts the listen goroutine unless one is already running
+func (cml *CanMessageListener) startListening(interfaceName string) error {
+	if err := validateInterfaceName(interfaceName); err != nil {
+		return err
+	}
+
+	// Check if already listening
+	if cml.IsListening(interfaceName) {
+		cml.logger.Printf("📡 Already listening on %s", interfaceName)
+		return nil
+	}
+
+	// Refuse before opening a socket; checked again below once the socket is ready
+	cml.buffersMutex.RLock()
+	err := cml.checkListenerLimit()
+	cml.buffersMutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	cml.logger.Printf("📡 Starting CAN message listener for %s", interfaceName)
+
+	// Some kernels accept a bind to a down interface but never deliver frames on that
+	// socket, so a down interface gets a listener that binds once the interface is up
+	socket, rxBufferBytes := -1, 0
+	waiting := cml.isInterfaceDown(interfaceName)
+	if waiting {
+		cml.logger.Printf("⏳ %s is down; the listener will bind once it comes up", interfaceName)
+	} else {
+		// Socket setup can block, so it happens outside buffersMutex
+		socket, rxBufferBytes, err = cml.openListenSocket(interfaceName)
+		if err != nil {
+			return err
+		}
+	}
+
+	wakeFd, err := unix.Eventfd(0, unix.EFD_CLOEXEC|unix.EFD_NONBLOCK)
+	if err != nil {
+		if socket >= 0 {
+			unix.Close(socket)
+		}
+		return fmt.Errorf("failed to create wake-up eventfd: %w", err)
+	}
+
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+
+	// Another caller may have started a listener while the socket was being set up
+	if listener, exists := cml.listeners[interfaceName]; exists && listener.isRunning.Load() {
+		if socket >= 0 {
+			unix.Close(socket)
+		}
+		unix.Close(wakeFd)
+		cml.logger.Printf("📡 Already listening on %s", interfaceName)
+		return nil
+	}
+	if err := cml.checkListenerLimit(); err != nil {
+		if socket >= 0 {
+			unix.Close(socket)
+		}
+		unix.Close(wakeFd)
+		return err
+	}
+
+	// Reuse the message buffer from a previous listener, if any
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		buffer = NewInterfaceMessageBuffer(interfaceName, cml.maxMessages)
+		buffer.SetCollapseDuplicates(cml.collapse)
+		buffer.SetLogging(!cml.loggingOff[interfaceName])
+		cml.buffers[interfaceName] = buffer
+	}
+
+	// Create listener
+	listener := &interfaceListener{
+		interfaceName: interfaceName,
+		socket:        socket,
+		rxBufferBytes: rxBufferBytes,
+		stopChan:      make(chan struct{}),
+		wakeFd:        wakeFd,
+		buffer:        buffer,
+		logger:        cml.logger,
+	}
+
+	listener.waitingForUp.Store(waiting)
+	cml.listeners[interfaceName] = listener
+
+	// Mark running before the goroutine starts so a concurrent StartListening can't start a second one
+	listener.isRunning.Store(true)
+
+	// Start listening goroutine
+	go cml.listenOnInterface(listener)
+
+	cml.logger.Printf("✅ Started listening on %s", interfaceName)
+	return nil
+}
+
+// runningListeners counts the listeners that are running; the caller holds buffersMutex
+func (cml *CanMessageListener) runningListeners() int {
+	running := 0
+	for _, listener := range cml.listeners {
+		if listener.isRunning.Load() {
+			running++
+		}
+	}
+	return running
+}
+
+// checkListenerLimit fails with ErrListenerLimit if no further listener may start; the caller holds buffersMutex
+func (cml *CanMessageListener) checkListenerLimit() error {
+	if cml.maxListeners <= 0 {
+		return nil
+	}
+	if running := cml.runningListeners(); running >= cml.maxListeners {
+		return fmt.Errorf("%w: %d of %d listeners are running; stop one or raise -max-listeners", ErrListenerLimit, running, cml.maxListeners)
+	}
+	return nil
+}
+
+// SetMaxListeners limits how many listeners may run at once (0 is unlimited). Listeners
+// already running above a lowered limit keep running.
+func (cml *CanMessageListener) SetMaxListeners(maxListeners int) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+	cml.maxListeners = maxListeners
+}
+
+// GetListenerCount returns how many listeners are running and the limit (0 is unlimited)
+func (cml *CanMessageListener) GetListenerCount() (running, maxListeners int) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+	return cml.runningListeners(), cml.maxListeners
+}
+
+// isInterfaceDown reports whether the interface is known to be administratively down.
+// Without a state provider, or when the state can't be read, it is assumed to be up.
+func (cml *CanMessageListener) isInterfaceDown(interfaceName string) bool {
+	cml.buffersMutex.RLock()
+	stateProvider := cml.stateProvider
+	cml.buffersMutex.RUnlock()
+
+	if stateProvider == nil {
+		return false
+	}
+	state, err := stateProvider.GetInterfaceState(interfaceName)
+	return err == nil && !state.IsUp
+}
+
+// interfaceSupportsFD reports whether the interface's MTU allows CAN FD frames.
+// Without a state provider, or when the state can't be read, it is assumed to be classic CAN.
+func (cml *CanMessageListener) interfaceSupportsFD(interfaceName string) bool {
+	cml.buffersMutex.RLock()
+	stateProvider := cml.stateProvider
+	cml.buffersMutex.RUnlock()
+
+	if stateProvider == nil {
+		return false
+	}
+	state, err := stateProvider.GetInterfaceState(interfaceName)
+	return err == nil && state.SupportsFD()
+}
+
+// waitForInterfaceUp blocks until the listener's interface is up and its socket is bound.
+// It returns false if the listener was stopped first.
+func (cml *CanMessageListener) waitForInterfaceUp(listener *interfaceListener) bool {
+	fds := []unix.PollFd{{Fd: int32(listener.wakeFd), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-listener.stopChan:
+			return false
+		case <-cml.ctx.Done():
+			return false
+		default:
+		}
+
+		// Sleep on the eventfd so stop() still wakes us right away
+		n, err := unix.Poll(fds, int(listenUpRecheckInterval/time.Millisecond))
+		if err != nil && err != unix.EINTR {
+			cml.logger.Printf("❌ Poll error on %s: %v", listener.interfaceName, err)
+			return false
+		}
+		if n != 0 || cml.isInterfaceDown(listener.interfaceName) {
+			continue
+		}
+
+		socket, rxBufferBytes, err := cml.openListenSocket(listener.interfaceName)
+		if err != nil {
+			cml.logger.Printf("❌ %s is up but binding failed, retrying: %v", listener.interfaceName, err)
+			continue
+		}
 
-// StartListening starts listening on a specific CAN interface
-func (cml *CanMessageListener) StartListening(interfaceName string) error {
-	cml.buffersMutex.Lock()
-	defer cml.buffersMutex.Unlock()
+		cml.buffersMutex.Lock()
+		listener.socket = socket
+		listener.rxBufferBytes = rxBufferBytes
+		cml.buffersMutex.Unlock()
+		listener.waitingForUp.Store(false)
 
-	// Check if already listening
-	if listener, exists := cml.listeners[interfaceName]; exists && listener.isRunning {
-		cml.logger.Printf("📡 Already listening on %s", interfaceName)
-		return nil
+		cml.logger.Printf("✅ %s is up, listener bound", listener.interfaceName)
+		return true
 	}
+}
 
-	cml.logger.Printf("📡 Starting CAN message listener for %s", interfaceName)
-
-	// Create message buffer
-	buffer := NewInterfaceMessageBuffer(interfaceName, cml.maxMessages)
-	cml.buffers[interfaceName] = buffer
+// openListenSocket creates a raw CAN socket bound to the interface.
+// It also returns the socket's actual receive buffer size.
+func (cml *CanMessageListener) openListenSocket(interfaceName string) (int, int, error) {
+	// Snapshot the socket settings; they may be changed by a reload while the socket is set up
+	cml.buffersMutex.RLock()
+	rxBufferSize, errorMask := cml.rxBufferSize, cml.errorMask
+	cml.buffersMutex.RUnlock()
 
 	// Create socket for listening
 	socket, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
 	if err != nil {
-		return fmt.Errorf("failed to create listening socket: %w", err)
+		return -1, 0, fmt.Errorf("failed to create listening socket: %w", err)
 	}
 
 	// Get interface index
@@ -183,62 +1088,97 @@ func (cml *CanMessageListener) StartListening(interfaceName string) error {
 	)
 	if errno != 0 {
 		unix.Close(socket)
-		return fmt.Errorf("failed to get interface index: %v", errno)
+		return -1, 0, fmt.Errorf("failed to get interface index: %v", errno)
 	}
 
-	// Bind socket to interface
-	addr := &unix.SockaddrCAN{Ifindex: int(ifr.Index)}
-	if err := unix.Bind(socket, addr); err != nil {
-		unix.Close(socket)
-		return fmt.Errorf("failed to bind listening socket: %w", err)
+	// Ask the kernel to report receive queue overflows so dropped frames can be counted
+	if err := unix.SetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_RXQ_OVFL, 1); err != nil {
+		cml.logger.Printf("⚠️ Warning: failed to enable drop counter on %s: %v", interfaceName, err)
 	}
 
-	// Create listener
-	listener := &interfaceListener{
-		interfaceName: interfaceName,
-		socket:        socket,
-		isRunning:     false,
-		stopChan:      make(chan bool, 1),
-		buffer:        buffer,
-		logger:        cml.logger,
+	// Size the kernel receive buffer; the kernel doubles the request and caps it at net.core.rmem_max
+	if rxBufferSize > 0 {
+		if err := unix.SetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_RCVBUF, rxBufferSize); err != nil {
+			cml.logger.Printf("⚠️ Warning: failed to set receive buffer on %s: %v", interfaceName, err)
+		}
+	}
+	rxBufferBytes, err := unix.GetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_RCVBUF)
+	if err != nil {
+		cml.logger.Printf("⚠️ Warning: failed to read receive buffer size on %s: %v", interfaceName, err)
+	} else if rxBufferSize > 0 {
+		cml.logger.Printf("📦 %s receive buffer: requested %d bytes, kernel granted %d bytes", interfaceName, rxBufferSize, rxBufferBytes)
 	}
 
-	cml.listeners[interfaceName] = listener
+	// Opt in to error frames for the requested classes
+	if errorMask != 0 {
+		if err := unix.SetsockoptInt(socket, unix.SOL_CAN_RAW, unix.CAN_RAW_ERR_FILTER, int(errorMask)); err != nil {
+			cml.logger.Printf("⚠️ Warning: failed to enable error frames on %s: %v", interfaceName, err)
+		}
+	}
 
-	// Start listening goroutine
-	go cml.listenOnInterface(listener)
+	// The kernel only delivers CAN FD frames to sockets that opt in, so FD-capable links get FD reads
+	if cml.interfaceSupportsFD(interfaceName) {
+		if err := unix.SetsockoptInt(socket, unix.SOL_CAN_RAW, unix.CAN_RAW_FD_FRAMES, 1); err != nil {
+			cml.logger.Printf("⚠️ Warning: failed to enable CAN FD frames on %s: %v", interfaceName, err)
+		}
+	}
 
-	cml.logger.Printf("✅ Started listening on %s", interfaceName)
-	return nil
+	// Bind socket to interface
+	addr := &unix.SockaddrCAN{Ifindex: int(ifr.Index)}
+	if err := unix.Bind(socket, addr); err != nil {
+		unix.Close(socket)
+		return -1, 0, fmt.Errorf("failed to bind listening socket: %w", err)
+	}
+
+	return socket, rxBufferBytes, nil
 }
 
-// StopListening stops listening on a specific interface
+// StopListening stops listening on a specific interface for every holder, e.g. when the
+// interface is torn down; outstanding handles become no-ops. The message buffer is kept.
+// Components sharing an interface should release their handle instead.
 func (cml *CanMessageListener) StopListening(interfaceName string) error {
 	cml.buffersMutex.Lock()
-	defer cml.buffersMutex.Unlock()
-
 	listener, exists := cml.listeners[interfaceName]
+	if exists {
+		delete(cml.listeners, interfaceName)
+	}
+	delete(cml.listenRefs, interfaceName)
+	cml.buffersMutex.Unlock()
+
 	if !exists {
 		return fmt.Errorf("not listening on interface %s", interfaceName)
 	}
 
 	cml.logger.Printf("🛑 Stopping listener for %s", interfaceName)
+	cml.closeListener(listener)
 
-	// Signal stop
-	if listener.isRunning {
-		listener.stopChan <- true
-	}
+	cml.logger.Printf("✅ Stopped listening on %s", interfaceName)
+	return nil
+}
 
-	// Close socket
-	if err := unix.Close(listener.socket); err != nil {
-		cml.logger.Printf("⚠️ Warning: failed to close listening socket for %s: %v", interfaceName, err)
+// RestartListening replaces the listener of an interface with a fresh socket, keeping the message buffer.
+// A socket bound before the interface was removed and re-created stays silent, so this is needed after recovery.
+// Interfaces nobody was listening on are left alone; restarted is false for them.
+func (cml *CanMessageListener) RestartListening(interfaceName string) (restarted bool, err error) {
+	cml.buffersMutex.Lock()
+	listener, exists := cml.listeners[interfaceName]
+	if exists {
+		delete(cml.listeners, interfaceName)
 	}
+	cml.buffersMutex.Unlock()
 
-	// Remove from listeners map
-	delete(cml.listeners, interfaceName)
+	if !exists {
+		return false, nil
+	}
+	cml.closeListener(listener)
+	return true, cml.startListening(interfaceName) // Holders keep their handles
+}
 
-	cml.logger.Printf("✅ Stopped listening on %s", interfaceName)
-	return nil
+// closeListener signals the listen goroutine to exit; the goroutine closes its socket.
+// The listener must already be removed from cml.listeners.
+func (cml *CanMessageListener) closeListener(listener *interfaceListener) {
+	// Signal stop; never blocks, even if the goroutine has already exited
+	listener.stop()
 }
 
 func bytesToHexArray(data []byte) []string {
@@ -251,14 +1191,29 @@ func bytesToHexArray(data []byte) []string {
 
 // listenOnInterface performs the actual message listening for an interface
 func (cml *CanMessageListener) listenOnInterface(listener *interfaceListener) {
-	listener.isRunning = true
-	defer func() {
-		listener.isRunning = false
-	}()
+	defer listener.isRunning.Store(false)
+	defer listener.closeFds()
 
 	cml.logger.Printf("👂 Listening thread started for %s", listener.interfaceName)
 
-	buffer := make([]byte, 16) // Size of CAN frame
+	if listener.waitingForUp.Load() && !cml.waitForInterfaceUp(listener) {
+		cml.logger.Printf("🛑 Stopped %s listener while waiting for the interface", listener.interfaceName)
+		return
+	}
+
+	// Read buffers and the recvmsg header are set up once and reused for every frame
+	buffer := make([]byte, CANFD_MTU) // Large enough for either frame size
+	oob := make([]byte, unix.CmsgSpace(4))
+	iov := unix.Iovec{Base: &buffer[0]}
+	iov.SetLen(len(buffer))
+	hdr := unix.Msghdr{Iov: &iov, Control: &oob[0]}
+	hdr.SetIovlen(1)
+	var frame bufferedFrame
+	readFailing := false // Read errors were logged; the next good read reports the suppressed count
+	fds := []unix.PollFd{
+		{Fd: int32(listener.socket), Events: unix.POLLIN},
+		{Fd: int32(listener.wakeFd), Events: unix.POLLIN},
+	}
 
 	for {
 		select {
@@ -269,56 +1224,180 @@ func (cml *CanMessageListener) listenOnInterface(listener *interfaceListener) {
 			cml.logger.Printf("🛑 Context cancelled for %s", listener.interfaceName)
 			return
 		default:
-			// Set read timeout to avoid blocking indefinitely
-			tv := unix.Timeval{Sec: 1, Usec: 0}
-			if err := unix.SetsockoptTimeval(listener.socket, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
-				cml.logger.Printf("⚠️ Failed to set socket timeout for %s: %v", listener.interfaceName, err)
+			// Block until a frame arrives or stop() wakes us through the eventfd
+			if _, err := unix.Poll(fds, -1); err != nil {
+				if err == unix.EINTR {
+					continue
+				}
+				listener.buffer.RecordReadError(fmt.Errorf("poll: %w", err))
+				cml.logger.Printf("❌ Poll error on %s: %v", listener.interfaceName, err)
+				return
+			}
+			if fds[1].Revents != 0 {
+				continue // Woken by stop(); the select above exits
+			}
+			if fds[0].Revents&unix.POLLNVAL != 0 {
+				listener.buffer.RecordReadError(errors.New("listening socket is no longer valid"))
+				cml.logger.Printf("❌ Listening socket for %s is no longer valid", listener.interfaceName)
+				return
 			}
 
-			// Try to read CAN frame
-			n, err := unix.Read(listener.socket, buffer)
+			// Read the CAN frame along with the overflow counter
+			n, oobn, flags, err := listener.recvFrame(&hdr, len(oob))
 			if err != nil {
-				// Check if it's a timeout (expected) or real error
-				if errno, ok := err.(unix.Errno); ok && errno == unix.EAGAIN {
-					continue // Timeout, continue listening
+				if isTransientReadError(err) {
+					continue // Spurious wake-up or interrupted read
 				}
-				cml.logger.Printf("❌ Read error on %s: %v", listener.interfaceName, err)
+				listener.buffer.RecordReadError(err)
+				cml.errorLog.Printf("read:"+listener.interfaceName, "❌ Read error on %s: %v", listener.interfaceName, err)
+				readFailing = true
 				continue
 			}
+			if readFailing {
+				cml.errorLog.Resolve("read:"+listener.interfaceName, fmt.Sprintf("✅ Reads on %s recovered", listener.interfaceName))
+				readFailing = false
+			}
 
-			if n >= 16 { // Minimum CAN frame size
-				// Parse CAN frame
-				frame := (*CanFrame)(unsafe.Pointer(&buffer[0]))
-
-				// Create message log entry
-				data := make([]byte, frame.Length)
-				copy(data, frame.Data[:frame.Length])
+			dropped := listener.readDropped(oob[:oobn])
+			if dropped > 0 {
+				listener.buffer.RecordDropped(dropped)
+				cml.logger.Printf("⚠️ %s kernel dropped %d frame(s)", listener.interfaceName, dropped)
+			}
 
-				msg := CanMessageLog{
-					Interface: listener.interfaceName,
-					ID:        frame.ID,
-					Data:      data,
-					Length:    frame.Length,
-					Timestamp: time.Now(),
-					Direction: "RX",
+			// The read length tells classic and FD frames apart; anything else is malformed
+			switch n {
+			case CAN_MTU:
+				raw := (*CanFrame)(unsafe.Pointer(&buffer[0]))
+				frame = bufferedFrame{ID: raw.ID, Length: min(raw.Length, uint8(len(raw.Data)))}
+				copy(frame.Data[:], raw.Data[:frame.Length])
+			case CANFD_MTU:
+				raw := (*CanFDFrame)(unsafe.Pointer(&buffer[0]))
+				frame = bufferedFrame{ID: raw.ID, Length: min(raw.Length, uint8(len(raw.Data))), FD: true}
+				copy(frame.Data[:], raw.Data[:frame.Length])
+			default:
+				if count := listener.buffer.RecordMalformed(); count == 1 || count%100 == 0 {
+					cml.logger.Printf("⚠️ %s read %d bytes, expected %d (CAN) or %d (CAN FD); %d malformed read(s) so far",
+						listener.interfaceName, n, CAN_MTU, CANFD_MTU, count)
+				}
+				continue
+			}
+			frame.Timestamp = time.Now()
 
-					HEX_ID:   fmt.Sprintf("%08x", frame.ID),
-					HEX_Data: bytesToHexArray(data),
+			// Error frames are decoded into their own log instead of the message buffer
+			if frame.ID&unix.CAN_ERR_FLAG != 0 {
+				entry := decodeErrorFrame(listener.interfaceName, &frame)
+				if total := listener.buffer.errorFrames.add(entry); total == 1 || total%100 == 0 {
+					cml.logger.Printf("⚠️ %s error frame %v (%d so far)", listener.interfaceName, entry.Classes, total)
 				}
+				continue
+			}
+
+			frame.Direction = "RX"
+			if flags&unix.MSG_DONTROUTE != 0 {
+				// The kernel flags frames looped back from a socket on this host
+				frame.Direction = "TX"
+				frame.Source = cml.takeTransmitSource(listener.interfaceName, &frame)
+			}
+			frame.DroppedBefore = dropped
 
-				// Add to buffer
-				listener.buffer.AddMessage(msg)
+			// Add to buffer
+			listener.buffer.addFrame(&frame)
+			cml.dispatch(listener.interfaceName, &frame)
 
-				// Log received message (with rate limiting to avoid spam)
-				if listener.buffer.totalReceived%100 == 1 || listener.buffer.totalReceived <= 10 {
-					cml.logger.Printf("📨 %s RX: ID=0x%X, Data=[% X], Length=%d",
-						listener.interfaceName, msg.ID, msg.Data, msg.Length)
-				}
+			// Log received message (with rate limiting to avoid spam)
+			if listener.buffer.totalReceived%100 == 1 || listener.buffer.totalReceived <= 10 {
+				cml.logger.Printf("📨 %s RX: ID=0x%X, Data=[% X], Length=%d",
+					listener.interfaceName, frame.ID, frame.Data[:frame.Length], frame.Length)
 			}
 		}
 	}
 }
 
+// stop signals the listen goroutine to exit and wakes it from poll. Safe to call more than once.
+func (listener *interfaceListener) stop() {
+	listener.stopOnce.Do(func() {
+		close(listener.stopChan)
+
+		listener.fdMutex.Lock()
+		defer listener.fdMutex.Unlock()
+		if !listener.fdsClosed {
+			var one [8]byte
+			one[0] = 1 // eventfd counters are host-endian uint64; 1 is non-zero either way
+			unix.Write(listener.wakeFd, one[:])
+		}
+	})
+}
+
+// closeFds closes the socket and wake-up eventfd once the goroutine is done with them
+func (listener *interfaceListener) closeFds() {
+	listener.fdMutex.Lock()
+	defer listener.fdMutex.Unlock()
+
+	if listener.fdsClosed {
+		return
+	}
+	listener.fdsClosed = true
+
+	// The socket is -1 if the listener was stopped while waiting for its interface to come up
+	if listener.socket >= 0 {
+		if err := unix.Close(listener.socket); err != nil {
+			listener.logger.Printf("⚠️ Warning: failed to close listening socket for %s: %v", listener.interfaceName, err)
+		}
+	}
+	unix.Close(listener.wakeFd)
+}
+
+// recvFrame reads one frame and its control data into the buffers referenced by hdr.
+// unix.Recvmsg would allocate a Sockaddr for every frame, which the listener never uses.
+func (listener *interfaceListener) recvFrame(hdr *unix.Msghdr, oobLen int) (n, oobn, flags int, err error) {
+	hdr.SetControllen(oobLen)
+	hdr.Flags = 0
+	r, _, errno := unix.Syscall(unix.SYS_RECVMSG, uintptr(listener.socket), uintptr(unsafe.Pointer(hdr)), unix.MSG_DONTWAIT)
+	if errno != 0 {
+		return 0, 0, 0, errno
+	}
+	return int(r), int(hdr.Controllen), int(hdr.Flags), nil
+}
+
+// readDropped parses the SO_RXQ_OVFL control message and returns frames dropped since the last read.
+// The control messages are walked in place rather than with unix.ParseSocketControlMessage to avoid allocating.
+func (listener *interfaceListener) readDropped(oob []byte) uint32 {
+	dataOffset := unix.CmsgLen(0)
+	for len(oob) >= unix.SizeofCmsghdr {
+		header := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0]))
+		cmsgLen := int(header.Len)
+		if cmsgLen < dataOffset || cmsgLen > len(oob) {
+			return 0
+		}
+
+		if header.Level == unix.SOL_SOCKET && header.Type == unix.SO_RXQ_OVFL && cmsgLen-dataOffset >= 4 {
+			total := *(*uint32)(unsafe.Pointer(&oob[dataOffset]))
+			dropped := total - listener.lastOverflow // wraps correctly on uint32 overflow
+			listener.lastOverflow = total
+			return dropped
+		}
+
+		oob = oob[min(unix.CmsgSpace(cmsgLen-dataOffset), len(oob)):]
+	}
+	return 0
+}
+
+// RestoreMessages loads saved messages into the buffer of an interface, creating the buffer
+// if it does not exist yet, so a listener started later continues after them
+func (cml *CanMessageListener) RestoreMessages(interfaceName string, messages []CanMessageLog) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		buffer = NewInterfaceMessageBuffer(interfaceName, cml.maxMessages)
+		buffer.SetCollapseDuplicates(cml.collapse)
+		buffer.SetLogging(!cml.loggingOff[interfaceName])
+		cml.buffers[interfaceName] = buffer
+	}
+	buffer.Restore(messages)
+}
+
 // GetMessages returns messages for a specific interface
 func (cml *CanMessageListener) GetMessages(interfaceName string) ([]CanMessageLog, error) {
 	cml.buffersMutex.RLock()
@@ -332,6 +1411,34 @@ func (cml *CanMessageListener) GetMessages(interfaceName string) ([]CanMessageLo
 	return buffer.GetMessages(), nil
 }
 
+// QueryMessages returns a page of messages for a specific interface matching opts
+func (cml *CanMessageListener) QueryMessages(interfaceName string, opts QueryOptions) (QueryResult, error) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return QueryResult{}, fmt.Errorf("no message buffer for interface %s", interfaceName)
+	}
+
+	return buffer.Query(opts), nil
+}
+
+// SearchMessages runs the same query against every interface buffer. Paging applies per
+// interface, and interfaces without a matching message are left out.
+func (cml *CanMessageListener) SearchMessages(opts QueryOptions) map[string]QueryResult {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	results := make(map[string]QueryResult)
+	for ifName, buffer := range cml.buffers {
+		if result := buffer.Query(opts); result.Matched > 0 {
+			results[ifName] = result
+		}
+	}
+	return results
+}
+
 // GetRecentMessages returns the last N messages for a specific interface
 func (cml *CanMessageListener) GetRecentMessages(interfaceName string, count int) ([]CanMessageLog, error) {
 	cml.buffersMutex.RLock()
@@ -345,6 +1452,35 @@ func (cml *CanMessageListener) GetRecentMessages(interfaceName string, count int
 	return buffer.GetRecentMessages(count), nil
 }
 
+// GetErrorFrames returns the last limit error frames of an interface (0 means all kept) and the lifetime total
+func (cml *CanMessageListener) GetErrorFrames(interfaceName string, limit int) ([]CanErrorLog, uint64, error) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return nil, 0, fmt.Errorf("no message buffer for interface %s", interfaceName)
+	}
+
+	entries, total := buffer.errorFrames.recent(limit)
+	return entries, total, nil
+}
+
+// GetIDCounts returns the frame count, rate and latest payload of every ID seen on an interface,
+// and the frames left uncounted once maxTrackedIDs IDs were tracked
+func (cml *CanMessageListener) GetIDCounts(interfaceName string) ([]CanIDStats, uint64, error) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return nil, 0, fmt.Errorf("no message buffer for interface %s", interfaceName)
+	}
+
+	ids, untracked := buffer.GetIDCounts()
+	return ids, untracked, nil
+}
+
 // GetAllMessages returns messages for all interfaces
 func (cml *CanMessageListener) GetAllMessages() map[string][]CanMessageLog {
 	cml.buffersMutex.RLock()
@@ -369,6 +1505,42 @@ func (cml *CanMessageListener) GetStatistics() map[string]interface{} {
 	return result
 }
 
+// SetMaxMessages changes the per-interface buffer size for existing and future buffers
+func (cml *CanMessageListener) SetMaxMessages(maxMessages int) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+
+	cml.maxMessages = maxMessages
+	for _, buffer := range cml.buffers {
+		buffer.SetMaxSize(maxMessages)
+	}
+}
+
+// SetCollapseDuplicates turns duplicate folding on or off for existing and future buffers
+func (cml *CanMessageListener) SetCollapseDuplicates(collapse bool) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+
+	cml.collapse = collapse
+	for _, buffer := range cml.buffers {
+		buffer.SetCollapseDuplicates(collapse)
+	}
+}
+
+// GetTotals returns the number of frames received and currently buffered across all interfaces
+func (cml *CanMessageListener) GetTotals() (totalReceived uint64, totalBuffered int) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	for _, buffer := range cml.buffers {
+		buffer.mutex.RLock()
+		totalReceived += buffer.totalReceived
+		totalBuffered += buffer.count
+		buffer.mutex.RUnlock()
+	}
+	return totalReceived, totalBuffered
+}
+
 // GetInterfaceStatistics returns statistics for a specific interface
 func (cml *CanMessageListener) GetInterfaceStatistics(interfaceName string) (map[string]interface{}, error) {
 	cml.buffersMutex.RLock()
@@ -397,6 +1569,21 @@ func (cml *CanMessageListener) ClearMessages(interfaceName string) error {
 	return nil
 }
 
+// ClearMessagesByID removes the buffered frames with one ID from an interface and returns how many were removed
+func (cml *CanMessageListener) ClearMessagesByID(interfaceName string, id uint32) (int, error) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return 0, fmt.Errorf("no message buffer for interface %s", interfaceName)
+	}
+
+	removed := buffer.RemoveID(id)
+	cml.logger.Printf("🧹 Removed %d frame(s) with ID 0x%X from the %s buffer", removed, id, interfaceName)
+	return removed, nil
+}
+
 // ClearAllMessages clears message buffers for all interfaces
 func (cml *CanMessageListener) ClearAllMessages() {
 	cml.buffersMutex.RLock()
@@ -408,13 +1595,152 @@ func (cml *CanMessageListener) ClearAllMessages() {
 	}
 }
 
+// SetLogging turns buffering of received frames on or off for an interface. The socket keeps
+// being drained either way, so disabling it only saves the memory of the message buffer.
+func (cml *CanMessageListener) SetLogging(interfaceName string, enabled bool) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+
+	if enabled {
+		delete(cml.loggingOff, interfaceName)
+		cml.logger.Printf("📝 Message logging enabled on %s", interfaceName)
+	} else {
+		cml.loggingOff[interfaceName] = true
+		cml.logger.Printf("📝 Message logging disabled on %s; frames are counted but not buffered", interfaceName)
+	}
+	if buffer, exists := cml.buffers[interfaceName]; exists {
+		buffer.SetLogging(enabled)
+	}
+}
+
+// IsLogging reports whether received frames are buffered for an interface
+func (cml *CanMessageListener) IsLogging(interfaceName string) bool {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+	return !cml.loggingOff[interfaceName]
+}
+
+// SetStateProvider sets where listeners look up whether an interface is up before binding
+func (cml *CanMessageListener) SetStateProvider(stateProvider InterfaceStateProvider) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+	cml.stateProvider = stateProvider
+}
+
+// SetErrorMask sets the CAN_RAW_ERR_FILTER mask for sockets opened after this call
+func (cml *CanMessageListener) SetErrorMask(mask uint32) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+
+	cml.errorMask = mask
+}
+
+// GetErrorMask returns the CAN_RAW_ERR_FILTER mask used for new listening sockets
+func (cml *CanMessageListener) GetErrorMask() uint32 {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+	return cml.errorMask
+}
+
+// SetReceiveBufferSize sets the SO_RCVBUF requested for sockets opened after this call
+func (cml *CanMessageListener) SetReceiveBufferSize(bytes int) {
+	cml.buffersMutex.Lock()
+	defer cml.buffersMutex.Unlock()
+	cml.rxBufferSize = bytes
+}
+
+// GetReceiveBufferSize returns the actual SO_RCVBUF of an interface's listening socket
+func (cml *CanMessageListener) GetReceiveBufferSize(interfaceName string) (int, bool) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	listener, exists := cml.listeners[interfaceName]
+	if !exists {
+		return 0, false
+	}
+	return listener.rxBufferBytes, true
+}
+
+// GetResourceCounts returns the number of listeners, the file descriptors they hold
+// open and the number of live subscriptions, for spotting leaks
+func (cml *CanMessageListener) GetResourceCounts() (listeners, openFDs, subscriptions int) {
+	cml.buffersMutex.RLock()
+	for _, listener := range cml.listeners {
+		listeners++
+		listener.fdMutex.Lock()
+		if !listener.fdsClosed {
+			openFDs += 2 // CAN socket and wake-up eventfd
+		}
+		listener.fdMutex.Unlock()
+	}
+	cml.buffersMutex.RUnlock()
+
+	cml.subsMutex.Lock()
+	subscriptions = len(cml.subscriptions)
+	cml.subsMutex.Unlock()
+	return listeners, openFDs, subscriptions
+}
+
+// IsWaitingForInterface reports whether a listener is running but still waiting for its interface
+// to come up before binding
+func (cml *CanMessageListener) IsWaitingForInterface(interfaceName string) bool {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	listener, exists := cml.listeners[interfaceName]
+	return exists && listener.isRunning.Load() && listener.waitingForUp.Load()
+}
+
 // IsListening checks if currently listening on an interface
 func (cml *CanMessageListener) IsListening(interfaceName string) bool {
 	cml.buffersMutex.RLock()
 	defer cml.buffersMutex.RUnlock()
 
 	listener, exists := cml.listeners[interfaceName]
-	return exists && listener.isRunning
+	return exists && listener.isRunning.Load()
+}
+
+// GetLastReceiveTime returns when the newest frame from the bus arrived on an interface (zero if none has)
+func (cml *CanMessageListener) GetLastReceiveTime(interfaceName string) time.Time {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return time.Time{}
+	}
+	buffer.mutex.RLock()
+	defer buffer.mutex.RUnlock()
+	return buffer.lastRxTime
+}
+
+// GetLastError returns the last real read error on an interface and when it happened,
+// or an empty string if reads have not failed since its buffer was created
+func (cml *CanMessageListener) GetLastError(interfaceName string) (string, time.Time) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return "", time.Time{}
+	}
+	buffer.mutex.RLock()
+	defer buffer.mutex.RUnlock()
+	return buffer.lastError, buffer.lastErrorTime
+}
+
+// GetRxFrameCount returns how many frames have arrived from the bus on an interface since its buffer was created
+func (cml *CanMessageListener) GetRxFrameCount(interfaceName string) (uint64, bool) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return 0, false
+	}
+	buffer.mutex.RLock()
+	defer buffer.mutex.RUnlock()
+	return buffer.rxFrames, true
 }
 
 // GetListeningInterfaces returns list of interfaces currently being listened to
@@ -424,7 +1750,7 @@ func (cml *CanMessageListener) GetListeningInterfaces() []string {
 
 	var interfaces []string
 	for ifName, listener := range cml.listeners {
-		if listener.isRunning {
+		if listener.isRunning.Load() {
 			interfaces = append(interfaces, ifName)
 		}
 	}
@@ -438,44 +1764,17 @@ func (cml *CanMessageListener) Shutdown() error {
 	// Cancel context
 	cml.cancel()
 
-	// Stop all listeners
+	// Detach all listeners, then close them without holding the lock
 	cml.buffersMutex.Lock()
-	defer cml.buffersMutex.Unlock()
+	listeners := cml.listeners
+	cml.listeners = make(map[string]*interfaceListener)
+	clear(cml.listenRefs)
+	cml.buffersMutex.Unlock()
 
-	var errors []string
-	for ifName := range cml.listeners {
-		if err := cml.stopListeningUnsafe(ifName); err != nil {
-			errors = append(errors, fmt.Sprintf("%s: %v", ifName, err))
-		}
-	}
-
-	if len(errors) > 0 {
-		return fmt.Errorf("errors during shutdown: %v", errors)
+	for _, listener := range listeners {
+		cml.closeListener(listener)
 	}
 
 	cml.logger.Printf("✅ CAN message listener shutdown complete")
 	return nil
 }
-
-// stopListeningUnsafe stops listening without acquiring mutex (internal use)
-func (cml *CanMessageListener) stopListeningUnsafe(interfaceName string) error {
-	listener, exists := cml.listeners[interfaceName]
-	if !exists {
-		return fmt.Errorf("not listening on interface %s", interfaceName)
-	}
-
-	// Signal stop
-	if listener.isRunning {
-		listener.stopChan <- true
-	}
-
-	// Close socket
-	if err := unix.Close(listener.socket); err != nil {
-		cml.logger.Printf("⚠️ Warning: failed to close listening socket for %s: %v", interfaceName, err)
-	}
-
-	// Remove from listeners map
-	delete(cml.listeners, interfaceName)
-
-	return nil
-}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// discardLogger drops every log line
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+// downStateProvider reports every interface as down, so listeners wait for it to come up
+// instead of opening a CAN socket
+type downStateProvider struct{}
+
+func (downStateProvider) GetInterfaceState(ifName string) (*InterfaceState, error) {
+	return &InterfaceState{Name: ifName, State: "DOWN"}, nil
+}
+
+// newTestListener returns a listener whose interfaces never come up, shut down when the test ends
+func newTestListener(t *testing.T) *CanMessageListener {
+	t.Helper()
+	cml := NewCanMessageListener(100, discardLogger{})
+	cml.SetStateProvider(downStateProvider{})
+	t.Cleanup(func() { cml.Shutdown() })
+	return cml
+}
+
+func TestRecoveryRestartsExistingListener(t *testing.T) {
+	cml := newTestListener(t)
+	if err := cml.startListening("can0"); err != nil {
+		t.Fatalf("startListening: %v", err)
+	}
+	cml.buffersMutex.RLock()
+	before := cml.listeners["can0"]
+	cml.buffersMutex.RUnlock()
+
+	w := NewWatchdog(nil, DefaultWatchdogConfig(), discardLogger{})
+	w.SetMessageListener(cml)
+	w.restartListening("can0")
+
+	if !cml.IsListening("can0") {
+		t.Fatal("can0 is not listening after recovery")
+	}
+	cml.buffersMutex.RLock()
+	after := cml.listeners["can0"]
+	cml.buffersMutex.RUnlock()
+	if after == before {
+		t.Fatal("recovery kept the old listener instead of opening a new one")
+	}
+}
+
+func TestRecoveryDoesNotStartMissingListener(t *testing.T) {
+	cml := newTestListener(t)
+
+	w := NewWatchdog(nil, DefaultWatchdogConfig(), discardLogger{})
+	w.SetMessageListener(cml)
+	w.restartListening("can1")
+
+	if cml.IsListening("can1") {
+		t.Fatal("recovery started a listener on an interface nobody listened on")
+	}
+	if running, _ := cml.GetListenerCount(); running != 0 {
+		t.Fatalf("running listeners = %d, want 0", running)
+	}
+}
+
+func TestRestartListeningAfterStop(t *testing.T) {
+	cml := newTestListener(t)
+	if err := cml.startListening("can0"); err != nil {
+		t.Fatalf("startListening: %v", err)
+	}
+	if err := cml.StopListening("can0"); err != nil {
+		t.Fatalf("StopListening: %v", err)
+	}
+
+	restarted, err := cml.RestartListening("can0")
+	if err != nil || restarted {
+		t.Fatalf("RestartListening = %v, %v; want false, nil", restarted, err)
+	}
+	if cml.IsListening("can0") {
+		t.Fatal("a stopped listener was started again")
+	}
+}
+
+func TestListenHandlesReleaseKeepsOtherHolders(t *testing.T) {
+	cml := newTestListener(t)
+	bridge, err := cml.StartListening("can0")
+	if err != nil {
+		t.Fatalf("StartListening: %v", err)
+	}
+	handles := NewListenHandles(cml)
+	for range 2 {
+		if err := handles.Acquire("can0"); err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+	}
+	if holders := cml.GetListenerHolders("can0"); holders != 2 {
+		t.Fatalf("holders = %d, want 2 after acquiring twice", holders)
+	}
+
+	if released, err := handles.Release("can0"); err != nil || !released {
+		t.Fatalf("Release = %v, %v; want true, nil", released, err)
+	}
+	if !cml.IsListening("can0") || cml.GetListenerHolders("can0") != 1 {
+		t.Fatal("releasing the API handle stopped the bridge's listener")
+	}
+	if released, _ := handles.Release("can0"); released {
+		t.Fatal("released a handle that was not held")
+	}
+
+	if err := bridge.Release(); err != nil {
+		t.Fatalf("bridge Release: %v", err)
+	}
+	if cml.IsListening("can0") {
+		t.Fatal("can0 is still listening after the last holder released")
+	}
+}
+
+// Run with -race: concurrent starts, stops and status reads must not race on isRunning
+func TestListenerStartStopRace(t *testing.T) {
+	cml := newTestListener(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				cml.startListening("can0")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				cml.StopListening("can0")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				cml.IsListening("can0")
+				cml.GetListenerCount()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if running, _ := cml.GetListenerCount(); running > 1 {
+		t.Fatalf("running listeners = %d, want at most 1", running)
+	}
+	if err := cml.startListening("can0"); err != nil {
+		t.Fatalf("startListening: %v", err)
+	}
+	if !cml.IsListening("can0") {
+		t.Fatal("can0 is not listening after the final start")
+	}
+}
+
+// overflowControl builds the SO_RXQ_OVFL control message recvmsg returns with a frame
+func overflowControl(total uint32) []byte {
+	oob := make([]byte, unix.CmsgSpace(4))
+	header := (*unix.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	header.Level = unix.SOL_SOCKET
+	header.Type = unix.SO_RXQ_OVFL
+	header.SetLen(unix.CmsgLen(4))
+	*(*uint32)(unsafe.Pointer(&oob[unix.CmsgLen(0)])) = total
+	return oob
+}
+
+// newReceivePath returns a listener with a full ring and a subscription that does not match,
+// ready to take frames the way listenOnInterface does
+func newReceivePath(tb testing.TB) (*CanMessageListener, *interfaceListener) {
+	cml := NewCanMessageListener(64, discardLogger{})
+	tb.Cleanup(func() { cml.Shutdown() })
+	_, cancel := cml.SubscribeOnce("can0", 0x7FF)
+	tb.Cleanup(cancel)
+
+	listener := &interfaceListener{interfaceName: "can0", buffer: NewInterfaceMessageBuffer("can0", 64)}
+	for i := 0; i < 128; i++ {
+		receiveFrame(cml, listener, nil, uint32(i%8))
+	}
+	return cml, listener
+}
+
+// receiveFrame is the per-frame part of listenOnInterface after the socket read
+func receiveFrame(cml *CanMessageListener, listener *interfaceListener, oob []byte, id uint32) {
+	frame := bufferedFrame{ID: id, Length: 8, Timestamp: time.Now(), Direction: "RX"}
+	frame.Data[0] = byte(id)
+	frame.DroppedBefore = listener.readDropped(oob)
+	listener.buffer.addFrame(&frame)
+	cml.dispatch(listener.interfaceName, &frame)
+}
+
+func TestReceivePathDoesNotAllocate(t *testing.T) {
+	cml, listener := newReceivePath(t)
+	oob := overflowControl(0)
+
+	var id uint32
+	allocs := testing.AllocsPerRun(1000, func() {
+		id = (id + 1) % 8
+		receiveFrame(cml, listener, oob, id)
+	})
+	if allocs != 0 {
+		t.Fatalf("receiving a frame allocated %v times, want 0", allocs)
+	}
+}
+
+func TestReadDropped(t *testing.T) {
+	listener := &interfaceListener{}
+	if dropped := listener.readDropped(overflowControl(5)); dropped != 5 {
+		t.Fatalf("first read dropped = %d, want 5", dropped)
+	}
+	if dropped := listener.readDropped(overflowControl(7)); dropped != 2 {
+		t.Fatalf("second read dropped = %d, want 2", dropped)
+	}
+	if dropped := listener.readDropped(nil); dropped != 0 {
+		t.Fatalf("read without control message dropped = %d, want 0", dropped)
+	}
+
+	// The kernel counter is a uint32 and wraps
+	listener.lastOverflow = ^uint32(0)
+	if dropped := listener.readDropped(overflowControl(1)); dropped != 2 {
+		t.Fatalf("dropped across wraparound = %d, want 2", dropped)
+	}
+}
+
+func BenchmarkReceiveFrame(b *testing.B) {
+	cml, listener := newReceivePath(b)
+	oob := overflowControl(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		receiveFrame(cml, listener, oob, uint32(i%8))
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -15,17 +23,28 @@ import (
 
 // Service represents the main CAN communication service
 type Service struct {
-	config           *Config
-	configProvider   ConfigProvider
+	configProvider   *DefaultConfigProvider // Holds the configuration in effect; see currentConfig
 	setupManager     *InterfaceSetupManager
 	interfaceManager *InterfaceManager
 	messageSender    *MessageSender
 	messageListener  *CanMessageListener
+	listenHandles    *ListenHandles // The service's and API's hold on listeners; bridges hold their own
 	watchdog         *Watchdog
+	alertNotifier    *AlertNotifier
+	influxWriter     *InfluxWriter
+	frameSocket      *FrameSocketPublisher
+	generator        *FrameGenerator
+	bridger          *Bridger
+	finder           *Finder
 	monitor          *Monitor
 	apiHandler       *APIHandler
 	server           *http.Server
+	pprofServer      *http.Server
 	logger           Logger
+	configMu         sync.Mutex // Serializes reloads and configuration imports
+	httpStarted      atomic.Bool
+	lastSelfTest     *SelfTestReport
+	selfTestMu       sync.Mutex
 }
 
 // NewService creates a new CAN communication service
@@ -35,8 +54,15 @@ func NewService() *Service {
 	}
 }
 
-// Initialize initializes all service components
-func (s *Service) Initialize() error {
+// currentConfig returns the configuration in effect. Reloads replace it instead of modifying
+// it, so the result can be read without locking but must not be changed.
+func (s *Service) currentConfig() *Config {
+	return s.configProvider.Config()
+}
+
+// Initialize initializes all service components. Cancelling ctx aborts interface setup and
+// initialization; Stop then cleans up whatever was already created.
+func (s *Service) Initialize(ctx context.Context) error {
 	// Parse configuration
 	configParser := NewConfigParser()
 	config, err := configParser.ParseConfig()
@@ -49,13 +75,25 @@ func (s *Service) Initialize() error {
 		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 
-	s.config = config
 	s.configProvider = NewDefaultConfigProvider(config)
 
 	s.logger.Printf("🚀 Starting CAN Communication Service")
 	s.logger.Printf("📋 Configuration:")
 	s.logger.Printf("   - CAN Ports: %v", config.CanPorts)
-	s.logger.Printf("   - Server Port: %s", config.Port)
+	s.logger.Printf("   - Server Address: %s", net.JoinHostPort(config.ListenAddr, config.Port))
+	for ifName, ifConfig := range config.Interfaces {
+		s.logger.Printf("   - %s: bitrate=%d, sample-point=%s, restart-ms=%d",
+			ifName, ifConfig.Bitrate, ifConfig.SamplePoint, ifConfig.RestartMs)
+	}
+	if config.AsyncSend {
+		s.logger.Printf("   - Async Send: enabled (queue size %d)", config.SendQueueSize)
+	}
+	if len(config.TrustedProxies) > 0 {
+		s.logger.Printf("   - Trusted Proxies: %v", config.TrustedProxies)
+	}
+	for _, line := range config.SourceSummary() {
+		s.logger.Printf("   - Set by %s", line)
+	}
 
 	// Initialize components
 	if err := s.initializeComponents(); err != nil {
@@ -63,17 +101,31 @@ func (s *Service) Initialize() error {
 	}
 
 	// Setup CAN interfaces (new step)
-	if err := s.setupCanInterfaces(); err != nil {
+	if config.NoSetup {
+		s.logger.Printf("⏭️ Interface setup disabled (-no-setup); using interfaces as configured by the OS")
+		s.skipStartupSetup("interface setup disabled (-no-setup)")
+	} else if err := s.setupCanInterfaces(ctx); err != nil {
 		s.logger.Printf("Warning: CAN interface setup issues: %v", err)
 		// We continue even if some interfaces failed to setup
 	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("startup aborted during interface setup: %w", ctx.Err())
+	}
 
 	// Initialize CAN interfaces
-	if err := s.interfaceManager.InitializeAll(); err != nil {
+	if err := s.interfaceManager.InitializeAll(ctx); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("startup aborted during interface initialization: %w", ctx.Err())
+		}
 		s.logger.Printf("Warning: %v", err)
 		// We continue even if some interfaces failed
 	}
 
+	// Reload the buffers saved by the previous run before listeners add to them
+	if config.RestoreSnapshot {
+		s.restoreSnapshot()
+	}
+
 	// Start message listening for all active interfaces
 	if err := s.startMessageListening(); err != nil {
 		s.logger.Printf("Warning: message listening issues: %v", err)
@@ -88,12 +140,22 @@ func (s *Service) Initialize() error {
 
 // initializeComponents initializes all service components
 func (s *Service) initializeComponents() error {
+	config := s.currentConfig()
+
 	// Create command executor for interface setup
 	commandExecutor := NewSystemCommandExecutor()
 
 	// Create interface setup manager
-	setupConfig := DefaultInterfaceSetupConfig()
+	setupConfig := config.SetupConfig()
 	s.setupManager = NewInterfaceSetupManager(setupConfig, commandExecutor, s.logger)
+	s.setupManager.SetInterfaceConfigs(config.Interfaces)
+	s.setupManager.SetAllowVirtual(config.AllowVirtual)
+	s.setupManager.SetNoSetup(config.NoSetup)
+	s.setupManager.SetDryRun(config.DryRun)
+	s.setupManager.SetStateCacheTTL(config.StateCacheTTL)
+	if config.DryRun {
+		s.logger.Printf("🧪 Dry-run: ip commands that change interfaces are logged, not run")
+	}
 
 	// Validate setup configuration
 	if err := s.setupManager.ValidateSetupConfig(); err != nil {
@@ -105,20 +167,51 @@ func (s *Service) initializeComponents() error {
 
 	// Create interface manager
 	s.interfaceManager = NewInterfaceManager(s.configProvider, socketProvider, s.logger)
+	s.interfaceManager.SetStateProvider(s.setupManager)
 
 	// Create message sender
 	s.messageSender = NewMessageSender(s.interfaceManager, s.configProvider, socketProvider, s.logger)
 
 	// Create message listener (new component)
-	maxMessages := 100 // Configure maximum messages per interface
-	s.messageListener = NewCanMessageListener(maxMessages, s.logger)
+	s.messageListener = NewCanMessageListener(config.MaxMessages, s.logger)
+	s.messageListener.SetMaxListeners(config.MaxListeners)
+	s.messageListener.SetReceiveBufferSize(config.RxBufferBytes)
+	s.messageListener.SetCollapseDuplicates(config.CollapseDuplicates)
+	s.messageListener.SetErrorMask(config.ErrorMask)
+	s.messageListener.SetStateProvider(s.setupManager)
+	s.listenHandles = NewListenHandles(s.messageListener)
 
 	// Create watchdog
-	watchdogConfig := DefaultWatchdogConfig()
+	watchdogConfig := config.WatchdogConfig()
 	s.watchdog = NewWatchdog(s.interfaceManager, watchdogConfig, s.logger)
 
+	// Create alert notifier if a webhook is configured
+	if config.AlertWebhookURL != "" {
+		s.alertNotifier = NewAlertNotifier(config.AlertWebhookURL, config.AlertCooldown, s.logger)
+		s.watchdog.SetAlertNotifier(s.alertNotifier)
+	}
+
+	// Create node finder, started later if enabled
+	s.finder = NewFinder(config.SetupFinderInterval, config.FinderBroadcastAddr)
+	s.finder.SetNetworkInterface(config.FinderInterface)
+	s.finder.SetIdentity(config.DeviceIdentity())
+
 	// Create monitor
 	s.monitor = NewMonitor(s.interfaceManager, s.watchdog, s.configProvider)
+	s.monitor.SetMessageListener(s.messageListener)
+	s.monitor.SetFinder(s.finder)
+	s.messageSender.SetMessageListener(s.messageListener)
+	s.watchdog.SetMessageListener(s.messageListener)
+
+	// Share the transition history between the monitor, watchdog and setup manager
+	s.watchdog.SetHistory(s.monitor.History())
+	s.setupManager.SetHistory(s.monitor.History())
+
+	// Create InfluxDB writer if a server is configured
+	if config.InfluxURL != "" {
+		s.influxWriter = NewInfluxWriter(config.InfluxURL, config.InfluxBucket, config.InfluxOrg, config.InfluxToken,
+			config.InfluxInterval, s.monitor, s.messageListener, s.logger)
+	}
 
 	// Create API handler with setup manager and message listener
 	s.apiHandler = NewAPIHandlerWithSetupAndListener(
@@ -128,12 +221,28 @@ func (s *Service) initializeComponents() error {
 		s.messageListener,
 		s.logger,
 	)
+	s.apiHandler.SetListenHandles(s.listenHandles)
+
+	// Synthetic traffic generator for load testing
+	s.generator = NewFrameGenerator(s.messageSender, s.logger)
+	s.apiHandler.SetFrameGenerator(s.generator)
+
+	// Forwarding between interfaces, fed by the listener
+	s.bridger = NewBridger(s.messageSender, s.messageListener, s.logger)
+	s.apiHandler.SetBridger(s.bridger)
+	s.apiHandler.SetConfigStore(s)
+	s.apiHandler.SetSelfTester(s)
+	s.apiHandler.SetFinder(s.finder)
+	s.apiHandler.SetWatchdog(s.watchdog)
+	s.apiHandler.SetDebug(config.Debug)
 
 	return nil
 }
 
 // setupCanInterfaces sets up all configured CAN interfaces
-func (s *Service) setupCanInterfaces() error {
+func (s *Service) setupCanInterfaces(ctx context.Context) error {
+	config := s.currentConfig()
+
 	s.logger.Printf("🔧 Setting up CAN interfaces...")
 
 	// Get available interfaces first
@@ -146,17 +255,29 @@ func (s *Service) setupCanInterfaces() error {
 
 	var setupErrors []string
 	successCount := 0
+	results := make(map[string]StartupSetupResult, len(config.CanPorts))
+	defer func() { s.monitor.SetStartupSetup(results) }()
 
-	for _, ifName := range s.config.CanPorts {
+	for _, ifName := range config.CanPorts {
+		if ctx.Err() != nil {
+			results[ifName] = StartupSetupResult{Status: StartupSetupSkipped, Reason: "startup aborted", Time: time.Now()}
+			continue
+		}
 		s.logger.Printf("🔧 Setting up interface %s...", ifName)
 
-		err := s.setupManager.SetupInterfaceWithRetry(ifName)
+		outcome, err := s.setupManager.SetupInterfaceWithRetry(ctx, ifName)
 		if err != nil {
+			results[ifName] = StartupSetupResult{Status: StartupSetupFailed, Reason: err.Error(), Time: time.Now()}
 			setupErrors = append(setupErrors, fmt.Sprintf("%s: %v", ifName, err))
 			s.logger.Printf("❌ Failed to setup %s: %v", ifName, err)
 		} else {
+			results[ifName] = StartupSetupResult{Status: StartupSetupSuccess, Reason: outcome.Reason, Warning: outcome.Warning, Time: time.Now()}
 			successCount++
-			s.logger.Printf("✅ Successfully set up %s", ifName)
+			if outcome.Warning != "" {
+				s.logger.Printf("⚠️ Set up %s (%s), but %s", ifName, outcome.Reason, outcome.Warning)
+			} else {
+				s.logger.Printf("✅ Successfully set up %s (%s)", ifName, outcome.Reason)
+			}
 
 			// Verify interface state
 			if state, err := s.setupManager.GetInterfaceState(ifName); err == nil {
@@ -170,7 +291,7 @@ func (s *Service) setupCanInterfaces() error {
 		return fmt.Errorf("failed to setup any CAN interfaces: %v", setupErrors)
 	}
 
-	s.logger.Printf("🎯 Successfully set up %d/%d CAN interfaces", successCount, len(s.config.CanPorts))
+	s.logger.Printf("🎯 Successfully set up %d/%d CAN interfaces", successCount, len(config.CanPorts))
 
 	if len(setupErrors) > 0 {
 		return fmt.Errorf("partial setup failure: %v", setupErrors)
@@ -179,6 +300,17 @@ func (s *Service) setupCanInterfaces() error {
 	return nil
 }
 
+// skipStartupSetup records every configured port as skipped at startup
+func (s *Service) skipStartupSetup(reason string) {
+	config := s.currentConfig()
+
+	results := make(map[string]StartupSetupResult, len(config.CanPorts))
+	for _, ifName := range config.CanPorts {
+		results[ifName] = StartupSetupResult{Status: StartupSetupSkipped, Reason: reason, Time: time.Now()}
+	}
+	s.monitor.SetStartupSetup(results)
+}
+
 // startMessageListening starts message listening for all active interfaces
 func (s *Service) startMessageListening() error {
 	s.logger.Printf("👂 Starting message listening for active interfaces...")
@@ -192,7 +324,7 @@ func (s *Service) startMessageListening() error {
 	for ifName := range activeInterfaces {
 		s.logger.Printf("👂 Starting listener for %s...", ifName)
 
-		err := s.messageListener.StartListening(ifName)
+		err := s.listenHandles.Acquire(ifName)
 		if err != nil {
 			listeningErrors = append(listeningErrors, fmt.Sprintf("%s: %v", ifName, err))
 			s.logger.Printf("❌ Failed to start listening on %s: %v", ifName, err)
@@ -203,14 +335,14 @@ func (s *Service) startMessageListening() error {
 	}
 
 	// Also try to start listening on configured ports that might become active later
-	for _, ifName := range s.config.CanPorts {
+	for _, ifName := range s.currentConfig().CanPorts {
 		// Skip if already handled above
 		if _, exists := activeInterfaces[ifName]; exists {
 			continue
 		}
 
 		s.logger.Printf("👂 Attempting to start listener for configured interface %s...", ifName)
-		err := s.messageListener.StartListening(ifName)
+		err := s.listenHandles.Acquire(ifName)
 		if err != nil {
 			s.logger.Printf("⚠️ Warning: could not start listening on %s (interface may not be ready): %v", ifName, err)
 		} else {
@@ -230,46 +362,75 @@ func (s *Service) startMessageListening() error {
 
 // setupHTTPServer configures the HTTP server
 func (s *Service) setupHTTPServer() {
+	config := s.currentConfig()
+
 	// Set to production mode
 	gin.SetMode(gin.ReleaseMode)
 
 	// Create Gin engine with custom middleware
 	r := gin.New()
+	// Only trust forwarding headers from configured proxies, so ClientIP cannot be spoofed
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		s.logger.Printf("⚠️ Ignoring trusted proxies %v: %v", config.TrustedProxies, err)
+	}
+	r.Use(RequestIDMiddleware())
 	r.Use(RecoveryMiddleware(s.logger))
 	r.Use(LoggingMiddleware(s.logger))
 	r.Use(CORSMiddleware())
+	r.Use(BodyLimitMiddleware(config.HTTPMaxBodyBytes))
 
 	// Setup API routes
 	s.apiHandler.SetupRoutes(r)
 
 	// Create HTTP server with timeouts
-	serverAddr := ":" + s.config.Port
+	serverAddr := net.JoinHostPort(config.ListenAddr, config.Port)
 	s.server = &http.Server{
 		Addr:         serverAddr,
 		Handler:      r,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		ReadTimeout:  config.HTTPReadTimeout,
+		WriteTimeout: config.HTTPWriteTimeout,
+		IdleTimeout:  config.HTTPIdleTimeout,
 	}
 
-	s.logger.Printf("🌐 CAN Communication Service will run at http://localhost%s", serverAddr)
+	s.logger.Printf("🌐 CAN Communication Service will run at http://%s", serverAddr)
 }
 
 // Start starts the service
 func (s *Service) Start(ctx context.Context) error {
+	config := s.currentConfig()
+
+	// Start alert delivery before the watchdog can raise events
+	if s.alertNotifier != nil {
+		s.alertNotifier.Start()
+	}
+
+	if s.influxWriter != nil {
+		s.influxWriter.Start()
+	}
+
 	// Start watchdog
-	if s.config.EnableHealthCheck {
+	if config.EnableHealthCheck {
 		if err := s.watchdog.Start(ctx); err != nil {
 			return fmt.Errorf("failed to start watchdog: %w", err)
 		}
 	}
 
-	// Start Node Finder in a separate goroutine
-	if s.config.EnableFinder {
-		go NodeFinder(s.config.SetupFinderInterval)
+	// Start the local frame stream
+	if config.FrameSocket != "" {
+		s.frameSocket = NewFrameSocketPublisher(config.FrameSocket, config.FrameSocketFormat, s.messageListener, s.logger)
+		if err := s.frameSocket.Start(); err != nil {
+			s.frameSocket = nil
+			return fmt.Errorf("failed to start frame socket: %w", err)
+		}
+	}
+
+	// Start Node Finder in a separate goroutine; it can also be toggled at runtime
+	if config.EnableFinder {
+		s.finder.Start()
 	}
 
 	// Start HTTP server in a goroutine
+	s.httpStarted.Store(true)
 	go func() {
 		s.logger.Printf("🌐 Starting HTTP server on %s", s.server.Addr)
 		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -277,6 +438,17 @@ func (s *Service) Start(ctx context.Context) error {
 		}
 	}()
 
+	// Start the profiling server on its own address
+	if config.PprofAddr != "" {
+		s.pprofServer = newPprofServer(config.PprofAddr)
+		go func() {
+			s.logger.Printf("🔬 Starting pprof server on http://%s/debug/pprof/", s.pprofServer.Addr)
+			if err := s.pprofServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Printf("❌ pprof server error: %v", err)
+			}
+		}()
+	}
+
 	s.logger.Printf("✅ CAN Communication Service started successfully")
 	s.logger.Printf("📡 Message listening active on: %v", s.messageListener.GetListeningInterfaces())
 	return nil
@@ -286,7 +458,25 @@ func (s *Service) Start(ctx context.Context) error {
 func (s *Service) Stop(ctx context.Context) error {
 	s.logger.Printf("🛑 Stopping CAN Communication Service...")
 
-	// Stop message listening first
+	// Stop generated traffic before anything it sends through is torn down
+	if s.generator != nil {
+		s.generator.StopAll()
+	}
+	if s.bridger != nil {
+		s.bridger.StopAll()
+	}
+
+	// Stop broadcasting
+	if s.finder != nil {
+		s.finder.Stop()
+	}
+
+	// Save the buffers while their listeners are still attached
+	if s.messageListener != nil && s.currentConfig().SnapshotOnExit != "" {
+		s.writeSnapshot(ctx)
+	}
+
+	// Stop message listening
 	if s.messageListener != nil {
 		s.logger.Printf("🛑 Stopping message listener...")
 		if err := s.messageListener.Shutdown(); err != nil {
@@ -294,9 +484,31 @@ func (s *Service) Stop(ctx context.Context) error {
 		}
 	}
 
+	// Disconnect frame socket clients and remove the socket file
+	if s.frameSocket != nil {
+		s.frameSocket.Stop()
+	}
+
+	// Drain queued outgoing messages before sockets are closed
+	if s.messageSender != nil {
+		s.messageSender.Shutdown()
+	}
+
 	// Stop watchdog
-	if err := s.watchdog.Stop(); err != nil {
-		s.logger.Printf("Warning: failed to stop watchdog: %v", err)
+	if s.watchdog != nil {
+		if err := s.watchdog.Stop(); err != nil {
+			s.logger.Printf("Warning: failed to stop watchdog: %v", err)
+		}
+	}
+
+	// Flush pending alerts, dropping what is left once ctx expires
+	if s.alertNotifier != nil {
+		s.alertNotifier.Stop(ctx)
+	}
+
+	// Write the last InfluxDB points
+	if s.influxWriter != nil {
+		s.influxWriter.Stop()
 	}
 
 	// Stop HTTP server
@@ -305,6 +517,11 @@ func (s *Service) Stop(ctx context.Context) error {
 			s.logger.Printf("Warning: HTTP server shutdown error: %v", err)
 		}
 	}
+	if s.pprofServer != nil {
+		if err := s.pprofServer.Shutdown(ctx); err != nil {
+			s.logger.Printf("Warning: pprof server shutdown error: %v", err)
+		}
+	}
 
 	// Cleanup CAN interfaces
 	if s.interfaceManager != nil {
@@ -312,7 +529,7 @@ func (s *Service) Stop(ctx context.Context) error {
 	}
 
 	// Teardown CAN interfaces (new step)
-	if s.setupManager != nil {
+	if s.setupManager != nil && !s.currentConfig().NoSetup {
 		s.teardownCanInterfaces()
 	}
 
@@ -324,7 +541,7 @@ func (s *Service) Stop(ctx context.Context) error {
 func (s *Service) teardownCanInterfaces() {
 	s.logger.Printf("🔽 Tearing down CAN interfaces...")
 
-	for _, ifName := range s.config.CanPorts {
+	for _, ifName := range s.currentConfig().CanPorts {
 		if err := s.setupManager.TeardownInterface(ifName); err != nil {
 			s.logger.Printf("⚠️ Warning: failed to teardown %s: %v", ifName, err)
 		}
@@ -333,6 +550,322 @@ func (s *Service) teardownCanInterfaces() {
 	s.logger.Printf("✅ CAN interfaces teardown complete")
 }
 
+// Reload re-parses the configuration and applies reloadable settings without restarting.
+// Interfaces whose setup parameters changed are reconfigured; others are left alone.
+func (s *Service) Reload() error {
+	newConfig, err := NewConfigParser().ParseConfig()
+	if err != nil {
+		return fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	if _, err := s.applyConfig(newConfig); err != nil {
+		return err
+	}
+
+	s.logger.Printf("✅ Configuration reloaded")
+	return nil
+}
+
+// ExportConfig returns the effective runtime configuration as a file document
+func (s *Service) ExportConfig() *FileConfig {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	return s.effectiveConfig().FileConfig()
+}
+
+// effectiveConfig returns the current configuration with the global setup parameters
+// taken from the setup manager, which /api/setup/config changes at runtime. Caller holds s.configMu.
+func (s *Service) effectiveConfig() *Config {
+	config := *s.currentConfig()
+	setupConfig := s.setupManager.GetSetupConfig()
+	config.Bitrate = setupConfig.Bitrate
+	config.SamplePoint = setupConfig.SamplePoint
+	config.RestartMs = setupConfig.RestartMs
+	config.SetupRetry = setupConfig.RetryAttempts
+	config.SetupDelay = setupConfig.RetryDelay
+	return &config
+}
+
+// ImportConfig applies a YAML or JSON configuration document on top of the effective
+// configuration. Keys missing from the document keep their current values, and flags and
+// environment variables are not consulted. Nothing is applied unless the whole result
+// validates. It returns the changed settings that were ignored because they need a restart.
+func (s *Service) ImportConfig(data []byte) ([]string, error) {
+	config := s.currentConfig()
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	fc := s.effectiveConfig().FileConfig()
+	// Credentials are not exported, so keep them unless the document sets them
+	fc.AlertWebhookURL = valuePtr(config.AlertWebhookURL)
+	fc.InfluxToken = valuePtr(config.InfluxToken)
+	if err := DecodeFileConfig(data, fc); err != nil {
+		return nil, fmt.Errorf("invalid configuration document: %w", err)
+	}
+
+	newConfig, err := NewConfigParser().ParseFileConfig(fc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	restartRequired, err := s.applyConfig(newConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Printf("✅ Configuration imported")
+	return restartRequired, nil
+}
+
+// applyConfig validates newConfig and, only if all of it is valid, applies the reloadable
+// settings to the running components. Changes that need a restart are logged and returned.
+// Caller holds s.configMu.
+func (s *Service) applyConfig(newConfig *Config) ([]string, error) {
+	if err := NewConfigParser().ValidateConfig(newConfig); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	setupConfig := s.setupManager.GetSetupConfig()
+	newSetupConfig := newConfig.SetupConfig()
+	setupConfig.Bitrate = newSetupConfig.Bitrate
+	setupConfig.SamplePoint = newSetupConfig.SamplePoint
+	setupConfig.RestartMs = newSetupConfig.RestartMs
+	setupConfig.RetryAttempts = newSetupConfig.RetryAttempts
+	setupConfig.RetryDelay = newSetupConfig.RetryDelay
+	if err := validateSetupConfig(setupConfig); err != nil {
+		return nil, fmt.Errorf("invalid setup configuration: %w", err)
+	}
+
+	oldConfig := *s.currentConfig()
+	next := oldConfig // Published whole once every reloadable setting is applied
+
+	// Settings that need a restart
+	var restartRequired []string
+	if !slices.Equal(oldConfig.CanPorts, newConfig.CanPorts) {
+		restartRequired = append(restartRequired, fmt.Sprintf("CAN ports (%v → %v)", oldConfig.CanPorts, newConfig.CanPorts))
+	}
+	if oldConfig.Port != newConfig.Port || oldConfig.ListenAddr != newConfig.ListenAddr {
+		restartRequired = append(restartRequired, fmt.Sprintf("server address (%s → %s)",
+			net.JoinHostPort(oldConfig.ListenAddr, oldConfig.Port), net.JoinHostPort(newConfig.ListenAddr, newConfig.Port)))
+	}
+	if oldConfig.HTTPReadTimeout != newConfig.HTTPReadTimeout || oldConfig.HTTPWriteTimeout != newConfig.HTTPWriteTimeout ||
+		oldConfig.HTTPIdleTimeout != newConfig.HTTPIdleTimeout || oldConfig.HTTPMaxBodyBytes != newConfig.HTTPMaxBodyBytes {
+		restartRequired = append(restartRequired, "HTTP timeouts and body limit")
+	}
+	if oldConfig.AutoSetup != newConfig.AutoSetup {
+		restartRequired = append(restartRequired, "auto-setup")
+	}
+	if oldConfig.AllowVirtual != newConfig.AllowVirtual || oldConfig.NoSetup != newConfig.NoSetup || oldConfig.DryRun != newConfig.DryRun {
+		restartRequired = append(restartRequired, "allow-virtual, no-setup and dry-run")
+	}
+	if oldConfig.EnableHealthCheck != newConfig.EnableHealthCheck {
+		restartRequired = append(restartRequired, "enable-healthcheck")
+	}
+	if !slices.Equal(oldConfig.TrustedProxies, newConfig.TrustedProxies) {
+		restartRequired = append(restartRequired, "trusted-proxies")
+	}
+	if oldConfig.Debug != newConfig.Debug || oldConfig.PprofAddr != newConfig.PprofAddr {
+		restartRequired = append(restartRequired, "debug and pprof settings")
+	}
+	if oldConfig.AsyncSend != newConfig.AsyncSend || oldConfig.SendQueueSize != newConfig.SendQueueSize {
+		restartRequired = append(restartRequired, "async send settings")
+	}
+	if oldConfig.FrameSocket != newConfig.FrameSocket || oldConfig.FrameSocketFormat != newConfig.FrameSocketFormat {
+		restartRequired = append(restartRequired, "frame socket settings")
+	}
+	if oldConfig.AlertWebhookURL != newConfig.AlertWebhookURL || oldConfig.AlertCooldown != newConfig.AlertCooldown {
+		restartRequired = append(restartRequired, "alert webhook settings")
+	}
+	if oldConfig.InfluxURL != newConfig.InfluxURL || oldConfig.InfluxBucket != newConfig.InfluxBucket ||
+		oldConfig.InfluxOrg != newConfig.InfluxOrg || oldConfig.InfluxToken != newConfig.InfluxToken ||
+		oldConfig.InfluxInterval != newConfig.InfluxInterval {
+		restartRequired = append(restartRequired, "InfluxDB settings")
+	}
+	for _, setting := range restartRequired {
+		s.logger.Printf("⚠️ Ignoring change to %s: restart required", setting)
+	}
+
+	// Buffer sizes
+	if oldConfig.MaxMessages != newConfig.MaxMessages {
+		s.logger.Printf("🔁 max-messages: %d → %d", oldConfig.MaxMessages, newConfig.MaxMessages)
+		next.MaxMessages = newConfig.MaxMessages
+		s.messageListener.SetMaxMessages(newConfig.MaxMessages)
+	}
+	if oldConfig.MaxListeners != newConfig.MaxListeners {
+		s.logger.Printf("🔁 max-listeners: %d → %d", oldConfig.MaxListeners, newConfig.MaxListeners)
+		next.MaxListeners = newConfig.MaxListeners
+		s.messageListener.SetMaxListeners(newConfig.MaxListeners)
+	}
+	if oldConfig.RxBufferBytes != newConfig.RxBufferBytes {
+		s.logger.Printf("🔁 rx-buffer-bytes: %d → %d (applies to listeners started from now on)", oldConfig.RxBufferBytes, newConfig.RxBufferBytes)
+		next.RxBufferBytes = newConfig.RxBufferBytes
+		s.messageListener.SetReceiveBufferSize(newConfig.RxBufferBytes)
+	}
+	if oldConfig.ErrorMask != newConfig.ErrorMask {
+		s.logger.Printf("🔁 error-mask: 0x%X → 0x%X (applies to listeners started from now on)", oldConfig.ErrorMask, newConfig.ErrorMask)
+		next.ErrorMask = newConfig.ErrorMask
+		s.messageListener.SetErrorMask(newConfig.ErrorMask)
+	}
+	if oldConfig.CollapseDuplicates != newConfig.CollapseDuplicates {
+		s.logger.Printf("🔁 collapse-duplicates: %t → %t", oldConfig.CollapseDuplicates, newConfig.CollapseDuplicates)
+		next.CollapseDuplicates = newConfig.CollapseDuplicates
+		s.messageListener.SetCollapseDuplicates(newConfig.CollapseDuplicates)
+	}
+	if oldConfig.SendRetries != newConfig.SendRetries || oldConfig.SendRetryBackoff != newConfig.SendRetryBackoff {
+		s.logger.Printf("🔁 send retries: %d → %d, backoff %v → %v",
+			oldConfig.SendRetries, newConfig.SendRetries, oldConfig.SendRetryBackoff, newConfig.SendRetryBackoff)
+		next.SendRetries = newConfig.SendRetries
+		next.SendRetryBackoff = newConfig.SendRetryBackoff
+	}
+	if oldConfig.TxPadToDLC8 != newConfig.TxPadToDLC8 || oldConfig.TxPadByte != newConfig.TxPadByte {
+		s.logger.Printf("🔁 tx padding: %t → %t, fill 0x%02X → 0x%02X",
+			oldConfig.TxPadToDLC8, newConfig.TxPadToDLC8, oldConfig.TxPadByte, newConfig.TxPadByte)
+		next.TxPadToDLC8 = newConfig.TxPadToDLC8
+		next.TxPadByte = newConfig.TxPadByte
+	}
+	if oldConfig.StateCacheTTL != newConfig.StateCacheTTL {
+		s.logger.Printf("🔁 state-cache-ttl: %v → %v", oldConfig.StateCacheTTL, newConfig.StateCacheTTL)
+		next.StateCacheTTL = newConfig.StateCacheTTL
+		s.setupManager.SetStateCacheTTL(newConfig.StateCacheTTL)
+	}
+	if oldConfig.DefaultInterface != newConfig.DefaultInterface {
+		// A default among ports added by this change only works once they are, after a restart
+		if newConfig.DefaultInterface != "" && !slices.Contains(oldConfig.CanPorts, newConfig.DefaultInterface) {
+			s.logger.Printf("⚠️ Ignoring change to default interface %s: restart required", newConfig.DefaultInterface)
+			restartRequired = append(restartRequired, "default interface")
+		} else {
+			s.logger.Printf("🔁 default-interface: %q → %q", oldConfig.DefaultInterface, newConfig.DefaultInterface)
+			next.DefaultInterface = newConfig.DefaultInterface
+		}
+	}
+	if oldConfig.SnapshotOnExit != newConfig.SnapshotOnExit {
+		s.logger.Printf("🔁 snapshot-on-exit: %q → %q", oldConfig.SnapshotOnExit, newConfig.SnapshotOnExit)
+		next.SnapshotOnExit = newConfig.SnapshotOnExit
+	}
+	next.RestoreSnapshot = newConfig.RestoreSnapshot // Only read at startup
+	if oldConfig.RxStaleAfter != newConfig.RxStaleAfter {
+		s.logger.Printf("🔁 rx-stale-after: %v → %v", oldConfig.RxStaleAfter, newConfig.RxStaleAfter)
+		next.RxStaleAfter = newConfig.RxStaleAfter
+	}
+	if oldConfig.LatencyWindow != newConfig.LatencyWindow {
+		s.logger.Printf("🔁 latency-window: %d → %d (applies to interfaces opened from now on)", oldConfig.LatencyWindow, newConfig.LatencyWindow)
+		next.LatencyWindow = newConfig.LatencyWindow
+	}
+
+	// Finder interval
+	if oldConfig.SetupFinderInterval != newConfig.SetupFinderInterval {
+		s.logger.Printf("🔁 finder-interval: %v → %v", oldConfig.SetupFinderInterval, newConfig.SetupFinderInterval)
+		next.SetupFinderInterval = newConfig.SetupFinderInterval
+		if s.finder != nil {
+			s.finder.SetInterval(newConfig.SetupFinderInterval)
+		}
+	}
+	if oldConfig.FinderBroadcastAddr != newConfig.FinderBroadcastAddr {
+		s.logger.Printf("🔁 finder-broadcast-addr: %s → %s", oldConfig.FinderBroadcastAddr, newConfig.FinderBroadcastAddr)
+		next.FinderBroadcastAddr = newConfig.FinderBroadcastAddr
+		if s.finder != nil {
+			s.finder.SetBroadcastAddr(newConfig.FinderBroadcastAddr)
+		}
+	}
+	if oldConfig.FinderInterface != newConfig.FinderInterface {
+		s.logger.Printf("🔁 finder-interface: %q → %q", oldConfig.FinderInterface, newConfig.FinderInterface)
+		next.FinderInterface = newConfig.FinderInterface
+		if s.finder != nil {
+			s.finder.SetNetworkInterface(newConfig.FinderInterface)
+		}
+	}
+	if oldConfig.DeviceID != newConfig.DeviceID || oldConfig.DeviceName != newConfig.DeviceName || oldConfig.DeviceModel != newConfig.DeviceModel ||
+		oldConfig.DeviceLocation != newConfig.DeviceLocation || !slices.Equal(oldConfig.DeviceTags, newConfig.DeviceTags) {
+		s.logger.Printf("🔁 device identity: %s (%s) → %s (%s)", oldConfig.DeviceName, oldConfig.DeviceModel, newConfig.DeviceName, newConfig.DeviceModel)
+		next.DeviceID = newConfig.DeviceID
+		next.DeviceName = newConfig.DeviceName
+		next.DeviceModel = newConfig.DeviceModel
+		next.DeviceLocation = newConfig.DeviceLocation
+		next.DeviceTags = newConfig.DeviceTags
+		if s.finder != nil {
+			s.finder.SetIdentity(newConfig.DeviceIdentity())
+		}
+	}
+	if oldConfig.EnableFinder != newConfig.EnableFinder {
+		s.logger.Printf("🔁 enable-finder: %v → %v", oldConfig.EnableFinder, newConfig.EnableFinder)
+		next.EnableFinder = newConfig.EnableFinder
+		if s.finder != nil {
+			if newConfig.EnableFinder {
+				s.finder.Start()
+			} else {
+				s.finder.Stop()
+			}
+		}
+	}
+
+	// Watchdog settings
+	if oldConfig.WatchdogInterval != newConfig.WatchdogInterval || oldConfig.WatchdogMaxRecovery != newConfig.WatchdogMaxRecovery {
+		s.logger.Printf("🔁 watchdog: interval %v → %v, max recovery %d → %d",
+			oldConfig.WatchdogInterval, newConfig.WatchdogInterval, oldConfig.WatchdogMaxRecovery, newConfig.WatchdogMaxRecovery)
+		next.WatchdogInterval = newConfig.WatchdogInterval
+		next.WatchdogMaxRecovery = newConfig.WatchdogMaxRecovery
+		s.watchdog.UpdateConfig(next.WatchdogConfig())
+	}
+	if !slices.Equal(oldConfig.WatchdogExclude, newConfig.WatchdogExclude) {
+		s.logger.Printf("🔁 watchdog-exclude: %v → %v", oldConfig.WatchdogExclude, newConfig.WatchdogExclude)
+		next.WatchdogExclude = newConfig.WatchdogExclude
+		s.watchdog.UpdateConfig(next.WatchdogConfig())
+	}
+	if oldConfig.MinRxRate != newConfig.MinRxRate || !maps.Equal(oldConfig.MinRxRates, newConfig.MinRxRates) {
+		s.logger.Printf("🔁 min-rx-rate: %d %v → %d %v", oldConfig.MinRxRate, oldConfig.MinRxRates, newConfig.MinRxRate, newConfig.MinRxRates)
+		next.MinRxRate = newConfig.MinRxRate
+		next.MinRxRates = newConfig.MinRxRates
+		s.watchdog.UpdateConfig(next.WatchdogConfig())
+	}
+	if oldConfig.ActiveHealthProbe != newConfig.ActiveHealthProbe {
+		s.logger.Printf("🔁 active-health-probe: %t → %t", oldConfig.ActiveHealthProbe, newConfig.ActiveHealthProbe)
+		next.ActiveHealthProbe = newConfig.ActiveHealthProbe
+	}
+	if oldConfig.HealthProbeID != newConfig.HealthProbeID || !bytes.Equal(oldConfig.HealthProbeData, newConfig.HealthProbeData) {
+		s.logger.Printf("🔁 health probe frame: 0x%X [% X] → 0x%X [% X]",
+			oldConfig.HealthProbeID, oldConfig.HealthProbeData, newConfig.HealthProbeID, newConfig.HealthProbeData)
+		next.HealthProbeID = newConfig.HealthProbeID
+		next.HealthProbeData = newConfig.HealthProbeData
+	}
+
+	// Interface setup parameters: compare effective per-interface configs before applying
+	before := s.setupManager.GetInterfaceConfigs(oldConfig.CanPorts)
+
+	next.Bitrate = newConfig.Bitrate
+	next.SamplePoint = newConfig.SamplePoint
+	next.RestartMs = newConfig.RestartMs
+	next.SetupRetry = newConfig.SetupRetry
+	next.SetupDelay = newConfig.SetupDelay
+	next.Interfaces = newConfig.Interfaces
+	s.configProvider.SetConfig(&next)
+
+	if err := s.setupManager.UpdateSetupConfig(setupConfig); err != nil {
+		return nil, fmt.Errorf("invalid setup configuration: %w", err)
+	}
+	s.setupManager.SetInterfaceConfigs(newConfig.Interfaces)
+
+	after := s.setupManager.GetInterfaceConfigs(oldConfig.CanPorts)
+	for _, ifName := range oldConfig.CanPorts {
+		was, now := before[ifName], after[ifName]
+		if was.Bitrate == now.Bitrate && was.SamplePoint == now.SamplePoint && was.RestartMs == now.RestartMs {
+			continue
+		}
+
+		s.logger.Printf("🔁 %s: bitrate %d → %d, sample-point %s → %s, restart-ms %d → %d; reconfiguring",
+			ifName, was.Bitrate, now.Bitrate, was.SamplePoint, now.SamplePoint, was.RestartMs, now.RestartMs)
+		if _, err := s.setupManager.SetupInterfaceWithRetry(context.Background(), ifName); err != nil {
+			s.logger.Printf("❌ Failed to reconfigure %s: %v", ifName, err)
+		}
+	}
+
+	return restartRequired, nil
+}
+
 // GetStatus returns current service status
 func (s *Service) GetStatus() map[string]interface{} {
 	if s.monitor == nil {
@@ -350,7 +883,7 @@ func (s *Service) GetStatus() map[string]interface{} {
 
 		// Get interface states
 		interfaceStates := make(map[string]interface{})
-		for _, ifName := range s.config.CanPorts {
+		for _, ifName := range s.currentConfig().CanPorts {
 			if state, err := s.setupManager.GetInterfaceState(ifName); err == nil {
 				interfaceStates[ifName] = state
 			} else {
@@ -374,22 +907,18 @@ func (s *Service) GetStatus() map[string]interface{} {
 		"uptime":           systemStatus.SystemUptime.String(),
 		"activeInterfaces": systemStatus.ActiveInterfaces,
 		"watchdogRunning":  systemStatus.WatchdogStatus.Running,
+		"startupSetup":     systemStatus.StartupSetup,
+		"finder":           systemStatus.Finder,
 		"setup":            setupStatus,
 		"messageListener":  messageListenerStatus,
 	}
 }
 
-// RestartInterfaceWithListening restarts an interface and its message listening
+// RestartInterfaceWithListening restarts an interface and its message listening.
+// Captured messages are preserved across the restart, and every holder keeps its handle.
 func (s *Service) RestartInterfaceWithListening(ifName string) error {
 	s.logger.Printf("🔄 Restarting interface %s with message listening...", ifName)
 
-	// Stop listening first
-	if s.messageListener != nil {
-		if err := s.messageListener.StopListening(ifName); err != nil {
-			s.logger.Printf("⚠️ Warning: failed to stop listening on %s: %v", ifName, err)
-		}
-	}
-
 	// Reset the interface
 	if err := s.setupManager.ResetInterface(ifName); err != nil {
 		return fmt.Errorf("failed to reset interface %s: %w", ifName, err)
@@ -398,9 +927,13 @@ func (s *Service) RestartInterfaceWithListening(ifName string) error {
 	// Wait a moment for interface to stabilize
 	time.Sleep(1 * time.Second)
 
-	// Restart listening
+	// Rebind the listener to the reset link, or start one if nobody was listening
 	if s.messageListener != nil {
-		if err := s.messageListener.StartListening(ifName); err != nil {
+		restarted, err := s.messageListener.RestartListening(ifName)
+		if err == nil && !restarted {
+			err = s.listenHandles.Acquire(ifName)
+		}
+		if err != nil {
 			s.logger.Printf("⚠️ Warning: failed to restart listening on %s: %v", ifName, err)
 			return fmt.Errorf("interface reset successful but failed to restart listening: %w", err)
 		}
@@ -420,20 +953,7 @@ func (s *Service) GetMessageSummary() map[string]interface{} {
 
 	allStats := s.messageListener.GetStatistics()
 	listeningInterfaces := s.messageListener.GetListeningInterfaces()
-
-	totalReceived := uint64(0)
-	totalBuffered := 0
-
-	for _, stats := range allStats {
-		if statsMap, ok := stats.(map[string]interface{}); ok {
-			if totalRx, ok := statsMap["totalReceived"].(uint64); ok {
-				totalReceived += totalRx
-			}
-			if buffered, ok := statsMap["bufferedCount"].(int); ok {
-				totalBuffered += buffered
-			}
-		}
-	}
+	totalReceived, totalBuffered := s.messageListener.GetTotals()
 
 	return map[string]interface{}{
 		"listeningInterfaceCount": len(listeningInterfaces),
@@ -452,17 +972,69 @@ func main() {
 		return
 	}
 
+	// One-shot subcommands bypass the service and its HTTP server entirely
+	if code, ok := runSubcommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
 	// Create service
 	service := NewService()
 
+	// Create context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle signals from the start, so a shutdown during a slow interface setup is not ignored;
+	// SIGHUP reloads configuration once the service is running
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	startupCtx, abortStartup := context.WithCancel(ctx)
+	startupDone := make(chan struct{})
+	watcherDone := make(chan struct{})
+	go func() {
+		defer close(watcherDone)
+		for {
+			select {
+			case sig := <-sigChan:
+				if sig == syscall.SIGHUP {
+					log.Println("Ignoring reload signal during startup")
+					continue
+				}
+				log.Println("Shutdown signal received during startup")
+				abortStartup()
+				return
+			case <-startupDone:
+				return
+			}
+		}
+	}()
+
 	// Initialize service
-	if err := service.Initialize(); err != nil {
+	err := service.Initialize(startupCtx)
+	close(startupDone)
+	<-watcherDone
+	if startupCtx.Err() != nil {
+		log.Printf("Startup aborted: %v", err)
+
+		// Release the sockets and links created so far
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer shutdownCancel()
+		if err := service.Stop(shutdownCtx); err != nil {
+			log.Printf("Error during shutdown: %v", err)
+		}
+		return
+	}
+	abortStartup()
+	if errors.Is(err, flag.ErrHelp) {
+		return // -h or -help after other flags; the flag set has printed the defaults
+	}
+	if err != nil {
 		log.Fatalf("Failed to initialize service: %v", err)
 	}
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// One consolidated verdict on whether the host can run the service as configured
+	service.SelfTest()
 
 	// Start service
 	if err := service.Start(ctx); err != nil {
@@ -481,12 +1053,17 @@ func main() {
 		}
 	}
 
-	// Wait for interrupt signal for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// Block until signal received
-	<-sigChan
+	// Block until a shutdown signal is received; SIGHUP reloads configuration
+	for sig := range sigChan {
+		if sig == syscall.SIGHUP {
+			log.Println("Reload signal received")
+			if err := service.Reload(); err != nil {
+				log.Printf("Configuration reload failed: %v", err)
+			}
+			continue
+		}
+		break
+	}
 	log.Println("Shutdown signal received")
 
 	// Create shutdown context with timeout
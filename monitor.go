@@ -2,38 +2,76 @@ package main
 
 import (
 	"fmt"
+	"maps"
+	"sort"
 	"time"
 )
 
 // SystemStatus represents overall system status
 type SystemStatus struct {
-	Interfaces          map[string]InterfaceStatus `json:"interfaces"`
-	ActiveInterfaces    int                        `json:"activeInterfaces"`
-	ConfiguredPorts     []string                   `json:"configuredPorts"`
-	AvailableInterfaces []string                   `json:"availableInterfaces"`
-	WatchdogStatus      WatchdogStatus             `json:"watchdogStatus"`
-	SystemUptime        time.Duration              `json:"systemUptime"`
-	Timestamp           time.Time                  `json:"timestamp"`
+	Interfaces          map[string]InterfaceStatus    `json:"interfaces"`
+	ActiveInterfaces    int                           `json:"activeInterfaces"`
+	ConfiguredPorts     []string                      `json:"configuredPorts"`
+	AvailableInterfaces []string                      `json:"availableInterfaces"`
+	WatchdogStatus      WatchdogStatus                `json:"watchdogStatus"`
+	SystemUptime        time.Duration                 `json:"systemUptime"`
+	SetupSkipped        bool                          `json:"setupSkipped"` // Interface setup disabled with -no-setup
+	StartupSetup        map[string]StartupSetupResult `json:"startupSetup"` // Outcome of setting up each configured port at boot
+	Finder              FinderStatus                  `json:"finder"`
+	Version             VersionInfo                   `json:"version"`
+	Timestamp           time.Time                     `json:"timestamp"`
+}
+
+// Startup setup results
+const (
+	StartupSetupSuccess = "success"
+	StartupSetupFailed  = "failed"
+	StartupSetupSkipped = "skipped"
+)
+
+// StartupSetupResult records how setting up an interface went when the service started
+type StartupSetupResult struct {
+	Status  string    `json:"status"`            // success, failed or skipped
+	Reason  string    `json:"reason,omitempty"`  // Setup outcome, error, or why it was skipped
+	Warning string    `json:"warning,omitempty"` // Set up, but the bus looked wrong afterwards
+	Time    time.Time `json:"time"`
 }
 
 // InterfaceStatus represents the status of a single interface
 type InterfaceStatus struct {
-	Name          string       `json:"name"`
-	Active        bool         `json:"active"`
-	Uptime        string       `json:"uptime"`
-	TotalSent     uint64       `json:"totalSent"`
-	TotalErrors   uint64       `json:"totalErrors"`
-	SuccessRate   string       `json:"successRate"`
-	LastSendTime  time.Time    `json:"lastSendTime"`
-	LastErrorTime time.Time    `json:"lastErrorTime"`
-	LastErrorMsg  string       `json:"lastErrorMsg"`
-	AvgLatency    string       `json:"avgLatency"`
-	Health        HealthStatus `json:"health"`
+	Name          string            `json:"name"`
+	Active        bool              `json:"active"`
+	Uptime        string            `json:"uptime"`
+	TotalSent     uint64            `json:"totalSent"`
+	TotalErrors   uint64            `json:"totalErrors"`
+	SuccessRate   string            `json:"successRate"`
+	LastSendTime  time.Time         `json:"lastSendTime"`
+	LastErrorTime time.Time         `json:"lastErrorTime"`
+	LastErrorMsg  string            `json:"lastErrorMsg"`
+	AvgLatency    string            `json:"avgLatency"`
+	P50Latency    string            `json:"p50Latency"` // Percentiles over the recent latency window
+	P95Latency    string            `json:"p95Latency"`
+	P99Latency    string            `json:"p99Latency"`
+	MaxLatency    string            `json:"maxLatency"`
+	QueueDepth    int               `json:"queueDepth"`
+	QueueDrops    uint64            `json:"queueDrops"`
+	TxQueueFull   uint64            `json:"txQueueFull"` // Sends given up under TX queue backpressure, not counted as errors
+	TxRetries     uint64            `json:"txRetries"`
+	ErrorsByErrno map[string]uint64 `json:"errorsByErrno,omitempty"` // Failed sends per errno, e.g. {"ENETDOWN": 3}
+	Health        HealthStatus      `json:"health"`
+
+	// Receive liveness: false when the listener is not running, nothing arrived within -rx-stale-after
+	// or fewer than -min-rx-rate frames arrived in the last watchdog check
+	ReceiveHealthy  bool      `json:"receiveHealthy"`
+	LastReceiveTime time.Time `json:"lastReceiveTime"`
+
+	// Counters read from /sys/class/net; nil when the interface does not exist
+	KernelStats *KernelStats `json:"kernelStats,omitempty"`
 }
 
 // HealthStatus represents health information
 type HealthStatus struct {
-	Status       string    `json:"status"` // "healthy", "warning", "critical"
+	Status       string    `json:"status"` // "healthy", "warning", "silent" (below -min-rx-rate), "critical"
 	LastCheck    time.Time `json:"lastCheck"`
 	ChecksPassed int       `json:"checksPassed"`
 	ChecksFailed int       `json:"checksFailed"`
@@ -41,11 +79,31 @@ type HealthStatus struct {
 
 // WatchdogStatus represents watchdog status
 type WatchdogStatus struct {
-	Running          bool           `json:"running"`
-	CheckInterval    time.Duration  `json:"checkInterval"`
-	RecoveryEnabled  bool           `json:"recoveryEnabled"`
-	RecoveryAttempts map[string]int `json:"recoveryAttempts"`
-	LastCheck        time.Time      `json:"lastCheck"`
+	Running           bool           `json:"running"`
+	CheckInterval     time.Duration  `json:"checkInterval"`
+	RecoveryEnabled   bool           `json:"recoveryEnabled"`
+	RecoveryAttempts  map[string]int `json:"recoveryAttempts"`
+	LastCheck         time.Time      `json:"lastCheck"`
+	LastCheckDuration string         `json:"lastCheckDuration"`
+	InterfacesChecked int            `json:"interfacesChecked"`
+	Excluded          []string       `json:"excluded"` // Interfaces health checked but never recovered
+}
+
+// ServiceSummary is a top-line rollup across all interfaces
+type ServiceSummary struct {
+	TotalSent            uint64    `json:"totalSent"`
+	TotalSendErrors      uint64    `json:"totalSendErrors"`
+	TotalReceived        uint64    `json:"totalReceived"`
+	TotalBuffered        int       `json:"totalBuffered"`
+	ErrorRate            float64   `json:"errorRate"`           // Percentage of send attempts that failed
+	ThroughputPerSecond  float64   `json:"throughputPerSecond"` // Frames sent and received per second since start
+	ActiveInterfaces     int       `json:"activeInterfaces"`
+	ConfiguredInterfaces int       `json:"configuredInterfaces"`
+	ListeningInterfaces  []string  `json:"listeningInterfaces"`
+	ListeningCount       int       `json:"listeningCount"`
+	OverallHealth        string    `json:"overallHealth"`
+	SystemUptime         string    `json:"systemUptime"`
+	Timestamp            time.Time `json:"timestamp"`
 }
 
 // Monitor handles system monitoring and status reporting
@@ -53,8 +111,12 @@ type Monitor struct {
 	interfaceManager *InterfaceManager
 	watchdog         *Watchdog
 	configProvider   ConfigProvider
+	messageListener  *CanMessageListener
 	startTime        time.Time
 	healthChecks     map[string]*HealthTracker
+	history          *InterfaceHistory
+	startupSetup     map[string]StartupSetupResult
+	finder           *Finder
 }
 
 // HealthTracker tracks health check results for an interface
@@ -72,9 +134,44 @@ func NewMonitor(interfaceManager *InterfaceManager, watchdog *Watchdog, configPr
 		configProvider:   configProvider,
 		startTime:        time.Now(),
 		healthChecks:     make(map[string]*HealthTracker),
+		history:          NewInterfaceHistory(maxHistoryPerInterface),
+		startupSetup:     make(map[string]StartupSetupResult),
 	}
 }
 
+// History returns the interface transition history, shared with the watchdog and setup manager
+func (m *Monitor) History() *InterfaceHistory {
+	return m.history
+}
+
+// GetInterfaceHistory returns the last limit up/down transitions of an interface (0 means all kept)
+func (m *Monitor) GetInterfaceHistory(ifName string, limit int) InterfaceHistorySummary {
+	return m.history.Get(ifName, limit)
+}
+
+// SetMessageListener sets the listener used for receive-side aggregates
+func (m *Monitor) SetMessageListener(messageListener *CanMessageListener) {
+	m.messageListener = messageListener
+}
+
+// SetFinder sets the node finder whose state is reported in the system status
+func (m *Monitor) SetFinder(finder *Finder) {
+	m.finder = finder
+}
+
+// getFinderStatus returns the finder state; without a finder it reports disabled
+func (m *Monitor) getFinderStatus() FinderStatus {
+	if m.finder == nil {
+		return FinderStatus{}
+	}
+	return m.finder.GetStatus()
+}
+
+// SetStartupSetup records the per-interface outcome of setup at startup
+func (m *Monitor) SetStartupSetup(results map[string]StartupSetupResult) {
+	m.startupSetup = maps.Clone(results)
+}
+
 // GetSystemStatus returns complete system status
 func (m *Monitor) GetSystemStatus() SystemStatus {
 	interfaces := m.getInterfaceStatuses()
@@ -86,6 +183,10 @@ func (m *Monitor) GetSystemStatus() SystemStatus {
 		AvailableInterfaces: m.getAvailableInterfaces(),
 		WatchdogStatus:      m.getWatchdogStatus(),
 		SystemUptime:        time.Since(m.startTime),
+		SetupSkipped:        m.configProvider.GetNoSetup(),
+		StartupSetup:        m.startupSetup,
+		Finder:              m.getFinderStatus(),
+		Version:             GetVersionInfo(),
 		Timestamp:           time.Now(),
 	}
 }
@@ -98,6 +199,14 @@ func (m *Monitor) getInterfaceStatuses() map[string]InterfaceStatus {
 	for name, canIf := range interfaces {
 		stats := canIf.GetStats()
 		health := m.checkInterfaceHealth(name)
+		receiveHealthy, lastReceive := m.checkReceiveHealth(name, stats.Uptime)
+		health.Status = downgradeForReceive(health.Status, receiveHealthy, m.isListening(name))
+		if m.watchdog.IsSilent(name) {
+			receiveHealthy = false
+			if health.Status != "critical" {
+				health.Status = "silent"
+			}
+		}
 
 		result[name] = InterfaceStatus{
 			Name:          name,
@@ -110,7 +219,20 @@ func (m *Monitor) getInterfaceStatuses() map[string]InterfaceStatus {
 			LastErrorTime: stats.LastErrorTime,
 			LastErrorMsg:  stats.LastErrorMsg,
 			AvgLatency:    stats.AvgLatency.String(),
+			P50Latency:    stats.P50Latency.String(),
+			P95Latency:    stats.P95Latency.String(),
+			P99Latency:    stats.P99Latency.String(),
+			MaxLatency:    stats.MaxLatency.String(),
+			QueueDepth:    stats.QueueDepth,
+			QueueDrops:    stats.QueueDrops,
+			TxQueueFull:   stats.TxQueueFull,
+			TxRetries:     stats.TxRetries,
+			ErrorsByErrno: stats.ErrorsByErrno,
 			Health:        health,
+
+			ReceiveHealthy:  receiveHealthy,
+			LastReceiveTime: lastReceive,
+			KernelStats:     m.readKernelStats(name),
 		}
 	}
 
@@ -124,6 +246,7 @@ func (m *Monitor) getInterfaceStatuses() map[string]InterfaceStatus {
 					Status:    "critical",
 					LastCheck: time.Now(),
 				},
+				KernelStats: m.readKernelStats(port),
 			}
 		}
 	}
@@ -161,6 +284,49 @@ func (m *Monitor) checkInterfaceHealth(ifName string) HealthStatus {
 	}
 }
 
+// isListening reports whether the listener is running on an interface; without a listener there is nothing to expect
+func (m *Monitor) isListening(ifName string) bool {
+	return m.messageListener == nil || m.messageListener.IsListening(ifName)
+}
+
+// checkReceiveHealth reports whether an interface is receiving as expected and when it last received.
+// It is unhealthy when its listener is not running, or when -rx-stale-after is set and nothing
+// arrived within that window (counted from when the interface opened if nothing ever arrived).
+func (m *Monitor) checkReceiveHealth(ifName string, uptime time.Duration) (bool, time.Time) {
+	if m.messageListener == nil {
+		return true, time.Time{}
+	}
+
+	lastReceive := m.messageListener.GetLastReceiveTime(ifName)
+	if !m.messageListener.IsListening(ifName) {
+		return false, lastReceive
+	}
+
+	staleAfter := m.configProvider.GetRxStaleAfter()
+	if staleAfter <= 0 {
+		return true, lastReceive
+	}
+	if lastReceive.IsZero() {
+		return uptime < staleAfter, lastReceive
+	}
+	return time.Since(lastReceive) < staleAfter, lastReceive
+}
+
+// downgradeForReceive lowers a health status for an unhealthy receive path:
+// a stopped listener is critical, a silent one at most a warning
+func downgradeForReceive(status string, receiveHealthy, listening bool) string {
+	switch {
+	case receiveHealthy:
+		return status
+	case !listening:
+		return "critical"
+	case status == "healthy" || status == "unknown":
+		return "warning"
+	default:
+		return status
+	}
+}
+
 // determineHealthStatus determines health status based on check history
 func (m *Monitor) determineHealthStatus(tracker *HealthTracker) string {
 	total := tracker.ChecksPassed + tracker.ChecksFailed
@@ -185,19 +351,42 @@ func (m *Monitor) getWatchdogStatus() WatchdogStatus {
 	config := m.watchdog.GetConfig()
 
 	return WatchdogStatus{
-		Running:          m.watchdog.IsRunning(),
-		CheckInterval:    config.CheckInterval,
-		RecoveryEnabled:  config.RecoveryEnabled,
-		RecoveryAttempts: m.watchdog.GetRecoveryStatus(),
-		LastCheck:        time.Now(), // This could be enhanced to track actual last check
+		Running:           m.watchdog.IsRunning(),
+		CheckInterval:     config.CheckInterval,
+		RecoveryEnabled:   config.RecoveryEnabled,
+		RecoveryAttempts:  m.watchdog.GetRecoveryStatus(),
+		LastCheck:         m.watchdog.LastCheckTime(),
+		LastCheckDuration: m.watchdog.LastCheckDuration().String(),
+		InterfacesChecked: m.watchdog.LastCheckInterfaceCount(),
+		Excluded:          m.watchdog.ExcludedInterfaces(),
 	}
 }
 
+// GetConfiguredPorts returns the configured CAN port names
+func (m *Monitor) GetConfiguredPorts() []string {
+	return m.configProvider.GetCanPorts()
+}
+
 // getAvailableInterfaces returns list of available interface names
 func (m *Monitor) getAvailableInterfaces() []string {
 	return m.configProvider.GetCanPorts()
 }
 
+// readKernelStats reads the kernel counters of an interface, or returns nil if it does not exist.
+// Where the driver has no can_state attribute the state parsed from ip (cached) is used instead.
+func (m *Monitor) readKernelStats(ifName string) *KernelStats {
+	stats, err := ReadKernelStats(ifName)
+	if err != nil {
+		return nil
+	}
+	if stats.CanState == "" {
+		if state, err := m.interfaceManager.GetInterfaceState(ifName); err == nil {
+			stats.CanState = state.CanState
+		}
+	}
+	return stats
+}
+
 // GetInterfaceStatus returns status for a specific interface
 func (m *Monitor) GetInterfaceStatus(ifName string) (InterfaceStatus, error) {
 	statuses := m.getInterfaceStatuses()
@@ -208,13 +397,12 @@ func (m *Monitor) GetInterfaceStatus(ifName string) (InterfaceStatus, error) {
 	return status, nil
 }
 
-// GetHealthSummary returns a summary of system health
-func (m *Monitor) GetHealthSummary() map[string]interface{} {
-	status := m.GetSystemStatus()
-
+// healthDistribution counts interfaces by health status and derives the overall health
+func healthDistribution(status SystemStatus) (map[string]int, string) {
 	healthySummary := map[string]int{
 		"healthy":  0,
 		"warning":  0,
+		"silent":   0,
 		"critical": 0,
 		"unknown":  0,
 	}
@@ -226,11 +414,63 @@ func (m *Monitor) GetHealthSummary() map[string]interface{} {
 	overallHealth := "healthy"
 	if healthySummary["critical"] > 0 {
 		overallHealth = "critical"
-	} else if healthySummary["warning"] > 0 {
+	} else if healthySummary["warning"] > 0 || healthySummary["silent"] > 0 {
 		overallHealth = "warning"
 	}
 
+	return healthySummary, overallHealth
+}
+
+// GetSummary aggregates send, receive and health data across all interfaces
+func (m *Monitor) GetSummary() ServiceSummary {
+	status := m.GetSystemStatus()
+	_, overallHealth := healthDistribution(status)
+
+	summary := ServiceSummary{
+		ActiveInterfaces:     status.ActiveInterfaces,
+		ConfiguredInterfaces: len(status.ConfiguredPorts),
+		OverallHealth:        overallHealth,
+		SystemUptime:         status.SystemUptime.String(),
+		Timestamp:            status.Timestamp,
+	}
+
+	for _, ifStatus := range status.Interfaces {
+		summary.TotalSent += ifStatus.TotalSent
+		summary.TotalSendErrors += ifStatus.TotalErrors
+	}
+
+	if m.messageListener != nil {
+		summary.TotalReceived, summary.TotalBuffered = m.messageListener.GetTotals()
+		summary.ListeningInterfaces = m.messageListener.GetListeningInterfaces()
+		summary.ListeningCount = len(summary.ListeningInterfaces)
+	}
+
+	if attempts := summary.TotalSent + summary.TotalSendErrors; attempts > 0 {
+		summary.ErrorRate = 100 * float64(summary.TotalSendErrors) / float64(attempts)
+	}
+
+	if seconds := status.SystemUptime.Seconds(); seconds > 0 {
+		summary.ThroughputPerSecond = float64(summary.TotalSent+summary.TotalReceived) / seconds
+	}
+
+	return summary
+}
+
+// GetHealthSummary returns a summary of system health
+func (m *Monitor) GetHealthSummary() map[string]interface{} {
+	status := m.GetSystemStatus()
+	healthySummary, overallHealth := healthDistribution(status)
+
+	receiveUnhealthy := []string{}
+	for name, ifStatus := range status.Interfaces {
+		if ifStatus.Active && !ifStatus.ReceiveHealthy {
+			receiveUnhealthy = append(receiveUnhealthy, name)
+		}
+	}
+	sort.Strings(receiveUnhealthy)
+
 	return map[string]interface{}{
+		"receiveUnhealthy":   receiveUnhealthy,
 		"overallHealth":      overallHealth,
 		"totalInterfaces":    len(status.Interfaces),
 		"activeInterfaces":   status.ActiveInterfaces,
@@ -240,6 +480,29 @@ func (m *Monitor) GetHealthSummary() map[string]interface{} {
 	}
 }
 
+// ResetInterfaceMetrics zeroes the send-side metrics of an active interface
+func (m *Monitor) ResetInterfaceMetrics(ifName string, resetStartTime bool) error {
+	canIf, exists := m.interfaceManager.GetInterface(ifName)
+	if !exists {
+		return fmt.Errorf("interface %s not found", ifName)
+	}
+	canIf.ResetMetrics(resetStartTime)
+	return nil
+}
+
+// ResetAllInterfaceMetrics zeroes the send-side metrics of every active interface
+// and returns the sorted names of the interfaces that were reset
+func (m *Monitor) ResetAllInterfaceMetrics(resetStartTime bool) []string {
+	interfaces := m.interfaceManager.GetAllInterfaces()
+	names := make([]string, 0, len(interfaces))
+	for name, canIf := range interfaces {
+		canIf.ResetMetrics(resetStartTime)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ResetHealthTracking resets health tracking for an interface
 func (m *Monitor) ResetHealthTracking(ifName string) {
 	delete(m.healthChecks, ifName)
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiOperation documents one route in the OpenAPI spec
+type apiOperation struct {
+	Summary  string
+	Query    []apiQueryParam
+	Request  interface{}     // Zero value of the request body type; nil if the route takes no body
+	Form     []apiQueryParam // Fields accepted as a form-encoded body instead of JSON
+	Response interface{}     // Zero value of the type in the envelope's data field; nil if untyped
+}
+
+// apiQueryParam documents a query string parameter
+type apiQueryParam struct {
+	Name        string
+	Type        string // OpenAPI type: string, integer or boolean
+	Description string
+}
+
+// messageQueryParams are the filters and paging shared by the message query endpoints
+var messageQueryParams = []apiQueryParam{
+	{"id", "string", "Exact ID, hex with 0x prefix or decimal"},
+	{"idMin", "string", "Inclusive lower bound on the ID (hex)"},
+	{"idMax", "string", "Inclusive upper bound on the ID (hex)"},
+	{"include", "string", "Comma-separated IDs and ID ranges, e.g. 0x100,0x200-0x2FF"},
+	{"exclude", "string", "Comma-separated IDs and ID ranges to leave out"},
+	{"frameType", "string", "standard or extended"},
+	{"since", "string", "RFC 3339 timestamp, inclusive"},
+	{"until", "string", "RFC 3339 timestamp, inclusive"},
+	{"afterSeq", "integer", "Only messages with a greater sequence number"},
+	{"offset", "integer", "Matching messages to skip"},
+	{"limit", "integer", "Page size (default 100, max 1000)"},
+}
+
+// apiOperations documents every route, keyed by "METHOD path" as registered with gin.
+// Routes missing here still appear in the spec, with a summary derived from the handler name.
+var apiOperations = map[string]apiOperation{
+	"GET /": {Summary: "Service banner"},
+
+	"POST /api/can": {Summary: "Send a CAN frame", Request: CanMessage{}, Response: CanMessage{}, Form: []apiQueryParam{
+		{"interface", "string", "Interface name; defaults to -default-interface"},
+		{"id", "string", "CAN ID, hex with 0x prefix or decimal"},
+		{"dataHex", "string", "Payload as hex, e.g. 0102AABB"},
+	}},
+	"POST /api/can/request":              {Summary: "Send a frame and wait for the first response with a given ID", Request: CanRequest{}, Response: CanMessageLog{}},
+	"POST /api/isotp/:interface":         {Summary: "Send an ISO-TP message, optionally waiting for the response", Request: ISOTPRequest{}},
+	"POST /api/isotp/:interface/receive": {Summary: "Wait for the next ISO-TP message on rxId", Request: ISOTPReceiveRequest{}},
+	"POST /api/can/generate":             {Summary: "Start a synthetic traffic job", Request: GenerateRequest{}, Response: GeneratorJobStatus{}},
+	"GET /api/can/generate":              {Summary: "List synthetic traffic jobs"},
+	"GET /api/can/generate/:id":          {Summary: "Get a synthetic traffic job", Response: GeneratorJobStatus{}},
+	"DELETE /api/can/generate/:id":       {Summary: "Cancel a synthetic traffic job", Response: GeneratorJobStatus{}},
+	"POST /api/bridge":                   {Summary: "Forward frames received on one interface out of another", Request: BridgeRequest{}, Response: BridgeStatus{}},
+	"GET /api/bridge":                    {Summary: "List active bridges"},
+	"GET /api/bridge/:id":                {Summary: "Get a bridge and its forwarding counters", Response: BridgeStatus{}},
+	"DELETE /api/bridge/:id":             {Summary: "Remove a bridge", Response: BridgeStatus{}},
+
+	"GET /api/config/export":             {Summary: "Export the effective configuration as a config file document", Response: FileConfig{}},
+	"POST /api/config/import":            {Summary: "Apply a YAML or JSON configuration document", Request: FileConfig{}},
+	"GET /api/selftest":                  {Summary: "Get the last self-test report", Response: SelfTestReport{}},
+	"POST /api/selftest":                 {Summary: "Run the self-test again", Response: SelfTestReport{}},
+	"GET /api/finder":                    {Summary: "Get the node finder state", Response: FinderStatus{}},
+	"POST /api/finder":                   {Summary: "Start or stop the node finder and change its interval", Request: FinderRequest{}, Response: FinderStatus{}},
+	"PUT /api/watchdog/interfaces/:name": {Summary: "Include an interface in or exclude it from watchdog recovery", Request: WatchdogInterfaceRequest{}},
+	"GET /api/openapi.json":              {Summary: "This OpenAPI document (not wrapped in the response envelope)"},
+	"GET /swagger":                       {Summary: "Swagger UI for this API"},
+
+	"GET /api/status":                          {Summary: "Complete system status", Response: SystemStatus{}},
+	"GET /api/interfaces":                      {Summary: "List configured and active interfaces"},
+	"GET /api/interfaces/:name":                {Summary: "Status, link state, listener and recent errors of one interface"},
+	"GET /api/interfaces/:name/status":         {Summary: "Status of one interface", Response: InterfaceStatus{}},
+	"GET /api/interfaces/:name/history":        {Summary: "Up/down transition history of one interface"},
+	"POST /api/interfaces/:name/metrics/reset": {Summary: "Reset the counters of one interface", Query: []apiQueryParam{{"resetStartTime", "boolean", "Also restart the uptime clock"}}},
+	"POST /api/interfaces/:name/initialize":    {Summary: "Open the send socket of an interface"},
+	"POST /api/interfaces/:name/online":        {Summary: "Set up, initialize and start listening on an interface"},
+	"POST /api/interfaces/:name/shutdown":      {Summary: "Stop everything for one interface and bring the link down"},
+	"GET /api/health":                          {Summary: "Health summary of every interface"},
+	"GET /api/metrics":                         {Summary: "Send and receive metrics of every interface"},
+	"POST /api/metrics/reset":                  {Summary: "Reset the counters of every interface", Query: []apiQueryParam{{"resetStartTime", "boolean", "Also restart the uptime clocks"}}},
+	"GET /api/summary":                         {Summary: "Short service summary"},
+	"GET /api/version":                         {Summary: "Version and build information", Response: VersionInfo{}},
+	"GET /api/debug/stats":                     {Summary: "Runtime diagnostics (only with -debug)"},
+
+	"GET /api/setup/config":                   {Summary: "Get the interface setup configuration"},
+	"PUT /api/setup/config":                   {Summary: "Update the interface setup configuration", Request: SetupConfigRequest{}},
+	"GET /api/setup/available":                {Summary: "List CAN interfaces present on the host"},
+	"POST /api/setup/interfaces/:name":        {Summary: "Set up and bring up an interface", Request: SetupInterfaceRequest{}},
+	"DELETE /api/setup/interfaces/:name":      {Summary: "Bring down and tear down an interface"},
+	"POST /api/setup/interfaces/:name/reset":  {Summary: "Tear down and set up an interface again"},
+	"POST /api/setup/interfaces/:name/create": {Summary: "Create a virtual CAN interface", Query: []apiQueryParam{{"type", "string", "vcan"}}},
+	"GET /api/setup/interfaces/:name/state":   {Summary: "Link state of an interface", Response: InterfaceState{}},
+	"GET /api/setup/interfaces/:name/applied": {Summary: "Setup last applied to an interface and its drift from the live state"},
+	"POST /api/setup/interfaces/setup-all":    {Summary: "Set up all or the listed interfaces", Request: SetupAllInterfacesRequest{}},
+	"POST /api/setup/interfaces/teardown-all": {Summary: "Tear down all configured interfaces"},
+
+	"GET /api/messages/:interface":               {Summary: "Query buffered messages of an interface", Query: messageQueryParams},
+	"GET /api/messages/:interface/recent":        {Summary: "Most recent messages of an interface", Query: []apiQueryParam{{"count", "integer", "Number of messages (default 10)"}}},
+	"GET /api/messages/:interface/statistics":    {Summary: "Message statistics of an interface"},
+	"GET /api/messages/:interface/errors":        {Summary: "Recent error frames of an interface"},
+	"GET /api/messages/:interface/ids":           {Summary: "Frame count, rate and latest payload of every CAN ID seen on an interface"},
+	"DELETE /api/messages/:interface":            {Summary: "Clear the message buffer of an interface", Query: []apiQueryParam{{"id", "string", "Remove only frames with this ID, hex with 0x prefix or decimal"}}},
+	"PUT /api/messages/:interface/logging":       {Summary: "Enable or disable buffering of received frames", Request: MessageLoggingRequest{}},
+	"GET /api/messages/":                         {Summary: "All buffered messages, grouped by interface"},
+	"GET /api/messages/statistics":               {Summary: "Message statistics of every interface"},
+	"GET /api/messages/search":                   {Summary: "Search every interface buffer for matching messages", Query: messageQueryParams},
+	"DELETE /api/messages/":                      {Summary: "Clear every message buffer"},
+	"POST /api/messages/:interface/listen/start": {Summary: "Start listening on an interface", Query: []apiQueryParam{{"clear", "boolean", "Clear messages kept from a previous listener"}}},
+	"POST /api/messages/:interface/listen/stop":  {Summary: "Release the API's hold on the listener of an interface"},
+	"GET /api/messages/:interface/listen/status": {Summary: "Listener status of an interface"},
+	"GET /api/messages/listen/status":            {Summary: "Listener status of every interface"},
+}
+
+// ginPathParam matches gin path parameters such as :name
+var ginPathParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// versionedAPIPath matches the pinned /api/vN copies of the /api routes, which are not listed separately
+var versionedAPIPath = regexp.MustCompile(`^/api/v[0-9]+/`)
+
+// BuildOpenAPISpec builds an OpenAPI 3 document for the registered routes. Paths come from the
+// router, so every endpoint is listed; schemas are derived from the Go request and response types.
+func BuildOpenAPISpec(routes gin.RoutesInfo) map[string]interface{} {
+	schemas := map[string]interface{}{
+		"ApiResponse": schemaForType(reflect.TypeOf(ApiResponse{}), nil),
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	paths := make(map[string]map[string]interface{})
+	for _, route := range routes {
+		if versionedAPIPath.MatchString(route.Path) {
+			continue
+		}
+		op, documented := apiOperations[route.Method+" "+route.Path]
+		if !documented {
+			op.Summary = handlerSummary(route.Handler)
+		}
+
+		var parameters []interface{}
+		for _, match := range ginPathParam.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]interface{}{
+				"name": match[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, param := range op.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name": param.Name, "in": "query", "description": param.Description,
+				"schema": map[string]interface{}{"type": param.Type},
+			})
+		}
+
+		operation := map[string]interface{}{
+			"summary":     op.Summary,
+			"operationId": strings.TrimPrefix(handlerName(route.Handler), "handle"),
+			"tags":        []string{routeTag(route.Path)},
+			"responses":   envelopeResponses(op.Response, schemas),
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if op.Request != nil {
+			content := map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemaForType(reflect.TypeOf(op.Request), schemas)},
+			}
+			if len(op.Form) > 0 {
+				properties := make(map[string]interface{})
+				for _, field := range op.Form {
+					properties[field.Name] = map[string]interface{}{"type": field.Type, "description": field.Description}
+				}
+				content["application/x-www-form-urlencoded"] = map[string]interface{}{
+					"schema": map[string]interface{}{"type": "object", "properties": properties},
+				}
+			}
+			operation["requestBody"] = map[string]interface{}{"required": true, "content": content}
+		}
+
+		path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "CAN Bridge API",
+			"version": VERSION,
+			"description": "HTTP API of the CAN bridge. Except for this document and /swagger, responses use the ApiResponse envelope. " +
+				"Every /api path is also served under /api/" + LatestAPIVersion + ", which stays pinned to API version " + LatestAPIVersion +
+				" while /api tracks the latest stable version.",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// envelopeResponses describes the ApiResponse envelope, with data typed when the route declares it
+func envelopeResponses(data interface{}, schemas map[string]interface{}) map[string]interface{} {
+	var success interface{} = map[string]interface{}{"$ref": "#/components/schemas/ApiResponse"}
+	if data != nil {
+		success = map[string]interface{}{
+			"allOf": []interface{}{
+				success,
+				map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"data": schemaForType(reflect.TypeOf(data), schemas)},
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"200": map[string]interface{}{
+			"description": "Success",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": success}},
+		},
+		"default": map[string]interface{}{
+			"description": "Error; status is \"error\" and error describes the problem",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ApiResponse"}},
+			},
+		},
+	}
+}
+
+// schemaForType returns the JSON schema of a Go type as encoding/json marshals it. Named
+// structs are added to schemas once and referenced, which also ends recursion.
+func schemaForType(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return map[string]interface{}{"type": "integer", "format": "int64", "description": "Nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "format": "byte"} // base64
+		}
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem(), schemas)}
+	case reflect.Struct:
+		if schemas == nil || t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, exists := schemas[t.Name()]; !exists {
+			schemas[t.Name()] = map[string]interface{}{} // Placeholder for recursive types
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{} // interface{}: any value
+}
+
+// structSchema describes the exported, JSON-visible fields of a struct
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaForType(field.Type, schemas)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// handlerName extracts the method name from a gin handler name such as
+// "main.(*APIHandler).handleCanMessage-fm"
+func handlerName(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// handlerSummary turns a handler name into a summary, e.g. handleGetSetupConfig → "Get setup config"
+func handlerSummary(handler string) string {
+	name := strings.TrimPrefix(handlerName(handler), "handle")
+	var words []string
+	start := 0
+	for i := 1; i < len(name); i++ {
+		if name[i] >= 'A' && name[i] <= 'Z' {
+			words = append(words, strings.ToLower(name[start:i]))
+			start = i
+		}
+	}
+	words = append(words, strings.ToLower(name[start:]))
+	summary := strings.Join(words, " ")
+	if summary == "" {
+		return ""
+	}
+	return strings.ToUpper(summary[:1]) + summary[1:]
+}
+
+// routeTag groups routes by their first segment below /api
+func routeTag(path string) string {
+	rest, found := strings.CutPrefix(path, "/api/")
+	if !found {
+		return "root"
+	}
+	tag, _, _ := strings.Cut(rest, "/")
+	return tag
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at /api/openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>CAN Bridge API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });</script>
+</body>
+</html>
+`
+
+// handleOpenAPI serves the OpenAPI document of the routes registered on the engine
+func (h *APIHandler) handleOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, BuildOpenAPISpec(h.engine.Routes()))
+}
+
+// handleSwaggerUI serves an interactive API browser backed by the OpenAPI document
+func (h *APIHandler) handleSwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
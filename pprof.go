@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// newPprofServer returns an HTTP server exposing the standard /debug/pprof/ handlers on addr.
+// It uses its own mux so the profiling endpoints never appear on the main API.
+func newPprofServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// repeatLogInterval is how often a repeating message is logged again while it keeps occurring
+const repeatLogInterval = 10 * time.Second
+
+// sampledLogger keeps repetitive messages from flooding the log during a fault storm. The first
+// occurrence of a message key is logged in full; repeats within the interval are only counted,
+// and the next one logged after it reports how many were suppressed. Resolve ends the storm
+// with a summary of any repeats not reported yet.
+type sampledLogger struct {
+	logger   Logger
+	interval time.Duration
+	entries  map[string]*sampledEntry
+	mu       sync.Mutex
+}
+
+// sampledEntry tracks one message key
+type sampledEntry struct {
+	lastLogged time.Time
+	suppressed uint64 // Occurrences since lastLogged that were not written
+}
+
+// newSampledLogger creates a sampled logger writing to logger at most once per interval per key
+func newSampledLogger(logger Logger, interval time.Duration) *sampledLogger {
+	return &sampledLogger{
+		logger:   logger,
+		interval: interval,
+		entries:  make(map[string]*sampledEntry),
+	}
+}
+
+// Printf logs the message unless the same key was logged less than the interval ago
+func (s *sampledLogger) Printf(key, format string, v ...interface{}) {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, exists := s.entries[key]
+	if !exists {
+		entry = &sampledEntry{}
+		s.entries[key] = entry
+	}
+	if exists && now.Sub(entry.lastLogged) < s.interval {
+		entry.suppressed++
+		s.mu.Unlock()
+		return
+	}
+	suppressed := entry.suppressed
+	entry.lastLogged, entry.suppressed = now, 0
+	s.mu.Unlock()
+
+	if suppressed > 0 {
+		format += fmt.Sprintf(" (%d more suppressed since the last report)", suppressed)
+	}
+	s.logger.Printf(format, v...)
+}
+
+// Resolve forgets a key, logging how many repeats were suppressed since it was last written
+func (s *sampledLogger) Resolve(key, summary string) {
+	s.mu.Lock()
+	entry, exists := s.entries[key]
+	delete(s.entries, key)
+	s.mu.Unlock()
+
+	if exists && entry.suppressed > 0 {
+		s.logger.Printf("%s: %d more suppressed", summary, entry.suppressed)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// SelfTestCheck is the outcome of one self-test check
+type SelfTestCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SelfTestReport is the consolidated verdict of a self-test run
+type SelfTestReport struct {
+	Passed   bool            `json:"passed"`
+	Failed   int             `json:"failed"`
+	Checks   []SelfTestCheck `json:"checks"`
+	Time     time.Time       `json:"time"`
+	Duration string          `json:"duration"`
+}
+
+// SelfTest checks that the host can run the service as configured: every configured interface
+// exists, the ip command works, the process may configure interfaces, CAN sockets can be
+// created and the HTTP address can be bound. The result is logged as a table and kept for
+// GET /api/selftest.
+func (s *Service) SelfTest() SelfTestReport {
+	config := s.currentConfig()
+
+	start := time.Now()
+
+	var checks []SelfTestCheck
+	for _, ifName := range config.CanPorts {
+		checks = append(checks, checkInterfaceExists(ifName))
+	}
+	checks = append(checks,
+		checkIPCommand(),
+		checkPrivileges(config.NoSetup),
+		checkCanSocket(),
+		s.checkHTTPAddress(),
+	)
+
+	report := SelfTestReport{Passed: true, Checks: checks, Time: start}
+	for _, check := range checks {
+		if !check.Passed {
+			report.Passed = false
+			report.Failed++
+		}
+	}
+	report.Duration = time.Since(start).String()
+
+	s.logSelfTest(report)
+
+	s.selfTestMu.Lock()
+	s.lastSelfTest = &report
+	s.selfTestMu.Unlock()
+	return report
+}
+
+// LastSelfTest returns the most recent self-test report, or nil if none has run
+func (s *Service) LastSelfTest() *SelfTestReport {
+	s.selfTestMu.Lock()
+	defer s.selfTestMu.Unlock()
+	return s.lastSelfTest
+}
+
+// logSelfTest prints the report as a pass/fail table
+func (s *Service) logSelfTest(report SelfTestReport) {
+	if report.Passed {
+		s.logger.Printf("🩺 Self-test passed (%d checks)", len(report.Checks))
+	} else {
+		s.logger.Printf("🩺 Self-test failed: %d of %d checks failed", report.Failed, len(report.Checks))
+	}
+	for _, check := range report.Checks {
+		mark := "✅"
+		if !check.Passed {
+			mark = "❌"
+		}
+		s.logger.Printf("   %s %-28s %s", mark, check.Name, check.Detail)
+	}
+}
+
+// checkInterfaceExists reports whether a configured interface is present on the host
+func checkInterfaceExists(ifName string) SelfTestCheck {
+	check := SelfTestCheck{Name: fmt.Sprintf("interface %s", ifName)}
+	iface, err := net.InterfaceByName(ifName)
+	if err != nil {
+		check.Detail = "not found on this host"
+		return check
+	}
+
+	check.Passed = true
+	check.Detail = "exists"
+	if iface.Flags&net.FlagUp == 0 {
+		check.Detail = "exists (down)"
+	}
+	return check
+}
+
+// checkIPCommand reports whether the ip command used for interface state and setup runs
+func checkIPCommand() SelfTestCheck {
+	check := SelfTestCheck{Name: "ip command"}
+	path, err := exec.LookPath("ip")
+	if err != nil {
+		check.Detail = "ip not found in PATH; install iproute2"
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "-V").CombinedOutput()
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s -V failed: %v", path, err)
+		return check
+	}
+
+	check.Passed = true
+	check.Detail = strings.TrimSpace(string(output))
+	return check
+}
+
+// checkPrivileges reports whether the process may configure interfaces (root or CAP_NET_ADMIN).
+// With -no-setup interfaces are never changed, so missing privileges are not a failure.
+func checkPrivileges(noSetup bool) SelfTestCheck {
+	check := SelfTestCheck{Name: "privileges"}
+	if os.Geteuid() == 0 {
+		check.Passed = true
+		check.Detail = "running as root"
+		return check
+	}
+
+	hasNetAdmin, err := hasEffectiveCapability(unix.CAP_NET_ADMIN)
+	switch {
+	case err != nil:
+		check.Detail = fmt.Sprintf("cannot read capabilities: %v", err)
+	case hasNetAdmin:
+		check.Passed = true
+		check.Detail = "CAP_NET_ADMIN"
+	default:
+		check.Detail = "not root and no CAP_NET_ADMIN; interface setup will fail"
+	}
+	if !check.Passed && noSetup {
+		check.Passed = true
+		check.Detail += " (not needed with -no-setup)"
+	}
+	return check
+}
+
+// hasEffectiveCapability reads the effective capability set from /proc/self/status
+func hasEffectiveCapability(capability int) (bool, error) {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), "CapEff:")
+		if !found {
+			continue
+		}
+		capEff, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid CapEff %q: %w", value, err)
+		}
+		return capEff&(1<<capability) != 0, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	return false, fmt.Errorf("no CapEff line in /proc/self/status")
+}
+
+// checkCanSocket reports whether raw CAN sockets can be created (the can and can_raw modules are loaded)
+func checkCanSocket() SelfTestCheck {
+	check := SelfTestCheck{Name: "CAN sockets"}
+	fd, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
+	if err != nil {
+		check.Detail = fmt.Sprintf("cannot create a raw CAN socket: %v; is the can_raw module loaded?", err)
+		return check
+	}
+	unix.Close(fd)
+
+	check.Passed = true
+	check.Detail = "raw CAN socket created"
+	return check
+}
+
+// checkHTTPAddress reports whether the HTTP server can listen on its address. Once the
+// server is running the address is held by this service, which counts as a pass.
+func (s *Service) checkHTTPAddress() SelfTestCheck {
+	config := s.currentConfig()
+
+	addr := net.JoinHostPort(config.ListenAddr, config.Port)
+	check := SelfTestCheck{Name: "HTTP address " + addr}
+	if s.httpStarted.Load() {
+		check.Passed = true
+		check.Detail = "served by this service"
+		return check
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		check.Detail = fmt.Sprintf("cannot listen: %v", err)
+		return check
+	}
+	listener.Close()
+
+	check.Passed = true
+	check.Detail = "available"
+	return check
+}
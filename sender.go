@@ -1,17 +1,163 @@
 package main
 
 import (
+	"container/heap"
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 	"unsafe"
+
+	"golang.org/x/sys/unix"
 )
 
+// ErrSendQueueFull is returned when a frame cannot be queued because the interface's send queue is full
+var ErrSendQueueFull = errors.New("send queue is full")
+
+// ErrTxQueueFull is returned when the kernel TX queue stayed full through every retry
+var ErrTxQueueFull = errors.New("CAN TX queue is full")
+
+// ErrResponseTimeout is returned by SendAndWait when no matching response arrives in time
+var ErrResponseTimeout = errors.New("timed out waiting for response")
+
+// SendError is returned when the kernel rejects a frame. It keeps the errno, so callers can tell
+// a down interface (ENETDOWN) from a full TX queue (ENOBUFS) or an oversized frame (EMSGSIZE).
+type SendError struct {
+	Interface string
+	Errno     unix.Errno
+}
+
+func (e *SendError) Error() string {
+	return fmt.Sprintf("sending on %s failed: %v (%s)", e.Interface, e.Errno, errnoName(e.Errno))
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Errno
+}
+
+// errnoName returns the symbolic name of an errno, e.g. "ENETDOWN"
+func errnoName(errno unix.Errno) string {
+	if name := unix.ErrnoName(errno); name != "" {
+		return name
+	}
+	return fmt.Sprintf("errno %d", int(errno))
+}
+
+// sendErrorClass classifies a failed send for metrics: the errno name, or "other" when the
+// error did not come from the kernel
+func sendErrorClass(err error) string {
+	var errno unix.Errno
+	if errors.As(err, &errno) {
+		return errnoName(errno)
+	}
+	return "other"
+}
+
 // MessageSender handles sending CAN messages
 type MessageSender struct {
 	interfaceManager *InterfaceManager
 	configProvider   ConfigProvider
 	socketProvider   SocketProvider
 	logger           Logger
+	queues           map[string]*sendQueue
+	queuesMutex      sync.Mutex
+	queuesClosed     bool
+	wg               sync.WaitGroup
+	messageListener  *CanMessageListener
+}
+
+// sendQueue is a bounded per-interface priority queue drained by a single writer goroutine.
+// Higher Priority values are sent first; equal priorities keep enqueue order.
+type sendQueue struct {
+	interfaceName string
+	capacity      int
+	items         sendHeap
+	nextSeq       uint64
+	closed        bool
+	done          chan struct{} // Closed when the drain goroutine exits
+	mu            sync.Mutex
+	cond          *sync.Cond
+}
+
+// queuedFrame is a message waiting in a send queue
+type queuedFrame struct {
+	msg CanMessage
+	seq uint64 // Enqueue order, breaks priority ties
+}
+
+// sendHeap orders queued frames by priority, then enqueue order
+type sendHeap []queuedFrame
+
+func (h sendHeap) Len() int { return len(h) }
+func (h sendHeap) Less(i, j int) bool {
+	if h[i].msg.Priority != h[j].msg.Priority {
+		return h[i].msg.Priority > h[j].msg.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h sendHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *sendHeap) Push(x any)   { *h = append(*h, x.(queuedFrame)) }
+func (h *sendHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// newSendQueue creates a send queue holding at most capacity frames
+func newSendQueue(interfaceName string, capacity int) *sendQueue {
+	q := &sendQueue{
+		interfaceName: interfaceName,
+		capacity:      capacity,
+		done:          make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a frame without blocking; it returns false if the queue is full or closed
+func (q *sendQueue) push(msg CanMessage) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || len(q.items) >= q.capacity {
+		return false
+	}
+	heap.Push(&q.items, queuedFrame{msg: msg, seq: q.nextSeq})
+	q.nextSeq++
+	q.cond.Signal()
+	return true
+}
+
+// pop blocks until a frame is available. It returns false once the queue is closed and empty.
+func (q *sendQueue) pop() (CanMessage, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return CanMessage{}, false
+	}
+	return heap.Pop(&q.items).(queuedFrame).msg, true
+}
+
+// close stops accepting frames; queued frames are still drained
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// len returns the number of queued frames
+func (q *sendQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
 }
 
 // NewMessageSender creates a new message sender
@@ -21,42 +167,259 @@ func NewMessageSender(interfaceManager *InterfaceManager, configProvider ConfigP
 		configProvider:   configProvider,
 		socketProvider:   socketProvider,
 		logger:           logger,
+		queues:           make(map[string]*sendQueue),
+	}
+}
+
+// SetMessageListener sets the listener used to receive responses in SendAndWait
+func (ms *MessageSender) SetMessageListener(listener *CanMessageListener) {
+	ms.messageListener = listener
+}
+
+// SendAndWait sends a CAN message and waits for the first frame with responseID on the same interface.
+// The interface must be listening. The subscription is removed on timeout or once ctx is done,
+// e.g. when the HTTP client disconnects.
+func (ms *MessageSender) SendAndWait(ctx context.Context, msg CanMessage, responseID uint32, timeout time.Duration) (*CanMessageLog, error) {
+	if ms.messageListener == nil {
+		return nil, fmt.Errorf("message listener not available")
+	}
+	var err error
+	if msg.Interface, err = ms.ResolveInterfaceName(msg.Interface); err != nil {
+		return nil, err
+	}
+	if !ms.messageListener.IsListening(msg.Interface) {
+		return nil, fmt.Errorf("not listening on interface %s; start listening to receive responses", msg.Interface)
+	}
+
+	canIf, err := ms.resolveInterface(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscribe before sending so a fast reply can't be missed
+	responses, cancel := ms.messageListener.SubscribeOnce(msg.Interface, responseID)
+	defer cancel()
+
+	if err := ms.sendMessage(canIf, msg); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case response := <-responses:
+		return &response, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("no response with ID 0x%X on %s within %v: %w", responseID, msg.Interface, timeout, ErrResponseTimeout)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("stopped waiting for a response with ID 0x%X on %s: %w", responseID, msg.Interface, ctx.Err())
 	}
 }
 
 // SendCanMessage sends a raw CAN message with interface validation
 func (ms *MessageSender) SendCanMessage(msg CanMessage) error {
+	var err error
+	if msg.Interface, err = ms.ResolveInterfaceName(msg.Interface); err != nil {
+		return err
+	}
+
+	canIf, err := ms.resolveInterface(msg)
+	if err != nil {
+		return err
+	}
+
+	return ms.sendMessage(canIf, msg)
+}
+
+// IsAsync reports whether messages should be queued instead of sent inline
+func (ms *MessageSender) IsAsync() bool {
+	return ms.configProvider.GetAsyncSend()
+}
+
+// EnqueueCanMessage validates a CAN message and places it on the interface's send queue.
+// It never blocks: if the queue is full the frame is dropped and ErrSendQueueFull is returned.
+func (ms *MessageSender) EnqueueCanMessage(msg CanMessage) error {
+	var err error
+	if msg.Interface, err = ms.ResolveInterfaceName(msg.Interface); err != nil {
+		return err
+	}
+
+	canIf, err := ms.resolveInterface(msg)
+	if err != nil {
+		return err
+	}
+
+	ms.queuesMutex.Lock()
+	defer ms.queuesMutex.Unlock()
+
+	if ms.queuesClosed {
+		return fmt.Errorf("message sender is shut down")
+	}
+
+	queue, exists := ms.queues[msg.Interface]
+	if !exists {
+		queue = newSendQueue(msg.Interface, ms.configProvider.GetSendQueueSize())
+		ms.queues[msg.Interface] = queue
+
+		ms.wg.Add(1)
+		go ms.drainQueue(queue)
+	}
+
+	if !queue.push(msg) {
+		canIf.Metrics.RecordQueueDrop()
+		return fmt.Errorf("%w for %s (capacity %d)", ErrSendQueueFull, msg.Interface, queue.capacity)
+	}
+	canIf.Metrics.SetQueueDepth(queue.len())
+	return nil
+}
+
+// drainQueue is the single writer for an interface's send queue
+func (ms *MessageSender) drainQueue(queue *sendQueue) {
+	defer ms.wg.Done()
+	defer close(queue.done)
+
+	for {
+		msg, ok := queue.pop()
+		if !ok {
+			return
+		}
+
+		// Look the interface up per frame so watchdog recovery is picked up
+		canIf, ok := ms.interfaceManager.GetInterface(queue.interfaceName)
+		if !ok {
+			ms.logger.Printf("❌ %s queued message dropped: interface not initialized", queue.interfaceName)
+			continue
+		}
+
+		ms.sendMessage(canIf, msg)
+		canIf.Metrics.SetQueueDepth(queue.len())
+	}
+}
+
+// Shutdown stops accepting queued messages and waits for all queues to drain
+func (ms *MessageSender) Shutdown() {
+	ms.queuesMutex.Lock()
+	if ms.queuesClosed {
+		ms.queuesMutex.Unlock()
+		return
+	}
+	ms.queuesClosed = true
+	for _, queue := range ms.queues {
+		queue.close()
+	}
+	ms.queuesMutex.Unlock()
+
+	ms.wg.Wait()
+}
+
+// CloseQueue stops queuing for one interface and waits until the frames already queued are sent.
+// It returns how many frames were waiting; a later EnqueueCanMessage starts a new queue.
+func (ms *MessageSender) CloseQueue(ifName string) (int, bool) {
+	ms.queuesMutex.Lock()
+	queue, exists := ms.queues[ifName]
+	pending := 0
+	if exists {
+		delete(ms.queues, ifName)
+		pending = queue.len()
+		queue.close()
+	}
+	ms.queuesMutex.Unlock()
+
+	if !exists {
+		return 0, false
+	}
+
+	<-queue.done
+	return pending, true
+}
+
+// OpenInterface opens the send socket of an interface if it is not open yet.
+// It reports whether a socket was opened.
+func (ms *MessageSender) OpenInterface(ifName string) (bool, error) {
+	if _, exists := ms.interfaceManager.GetInterface(ifName); exists {
+		return false, nil
+	}
+	if err := ms.interfaceManager.InitializeSingle(ifName); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetResourceCounts returns the number of open send sockets and running send queues
+func (ms *MessageSender) GetResourceCounts() (sockets, queues int) {
+	ms.queuesMutex.Lock()
+	queues = len(ms.queues)
+	ms.queuesMutex.Unlock()
+	return ms.interfaceManager.GetInterfaceCount(), queues
+}
+
+// InterfaceExists reports whether an interface exists at the OS level, initialized or not
+func (ms *MessageSender) InterfaceExists(ifName string) bool {
+	return ms.interfaceManager.InterfaceExists(ifName)
+}
+
+// ReleaseInterface closes the send socket of an interface and forgets it
+func (ms *MessageSender) ReleaseInterface(ifName string) error {
+	return ms.interfaceManager.RemoveInterface(ifName)
+}
+
+// ResolveInterfaceName returns the interface a message is sent on: the given name, or the
+// default interface when it is empty
+func (ms *MessageSender) ResolveInterfaceName(ifName string) (string, error) {
+	if ifName != "" {
+		return ifName, nil
+	}
+	if defaultInterface := ms.configProvider.GetDefaultInterface(); defaultInterface != "" {
+		return defaultInterface, nil
+	}
+	return "", fmt.Errorf("interface name is required: several interfaces are configured %v and no -default-interface is set",
+		ms.configProvider.GetCanPorts())
+}
+
+// resolveInterface validates a message against configuration and returns its interface
+func (ms *MessageSender) resolveInterface(msg CanMessage) (*CanInterface, error) {
 	// Validate interface is configured
 	if !ms.configProvider.ValidateInterface(msg.Interface) {
-		return fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
+		return nil, fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
 			msg.Interface, ms.configProvider.GetCanPorts())
 	}
 
 	// Get interface
 	canIf, ok := ms.interfaceManager.GetInterface(msg.Interface)
 	if !ok {
-		return fmt.Errorf("CAN interface %s not initialized", msg.Interface)
+		if ms.interfaceManager.InterfaceExists(msg.Interface) {
+			return nil, fmt.Errorf("CAN interface %s not initialized (it exists on this host; POST /api/interfaces/%s/initialize to retry)",
+				msg.Interface, msg.Interface)
+		}
+		return nil, fmt.Errorf("CAN interface %s not initialized (no such interface on this host)", msg.Interface)
 	}
 
-	// Validate data length
-	if len(msg.Data) > 8 {
-		return fmt.Errorf("CAN data exceeds maximum length (8 bytes)")
+	if _, err := frameLength(msg, false); err != nil {
+		return nil, err
 	}
 
-	return ms.sendMessage(canIf, msg)
+	return canIf, nil
 }
 
 // sendMessage performs the actual message sending
 func (ms *MessageSender) sendMessage(canIf *CanInterface, msg CanMessage) error {
-	canIf.Lock()
-	defer canIf.Unlock()
-
 	startTime := time.Now()
 
-	// Prepare CAN frame
+	// Pad to DLC 8 when configured, unless the message says otherwise
+	pad, fill := ms.configProvider.GetTxPadding()
+	if msg.PadToDLC8 != nil {
+		pad = *msg.PadToDLC8
+	}
+	if pad {
+		msg = padToDLC8(msg, fill)
+	}
+
+	// Prepare CAN frame; the length was validated in resolveInterface
+	length, _ := frameLength(msg, false)
 	frame := CanFrame{
 		ID:     msg.ID,
-		Length: uint8(len(msg.Data)),
+		Length: length,
 	}
 
 	// Copy data to frame
@@ -64,19 +427,44 @@ func (ms *MessageSender) sendMessage(canIf *CanInterface, msg CanMessage) error
 		frame.Data[i] = msg.Data[i]
 	}
 
-	// Send CAN frame
+	// Let the listener tag the looped-back copy with its sender
+	if ms.messageListener != nil {
+		ms.messageListener.NoteTransmit(msg.Interface, msg.ID, frame.Data[:frame.Length], msg.Source)
+	}
+
+	// Send CAN frame, backing off while the TX queue is full
 	buf := (*[16]byte)(unsafe.Pointer(&frame))[:]
-	err := ms.socketProvider.SendTo(canIf.FD, buf, canIf.Addr)
+	retries, backoff := ms.configProvider.GetSendRetry()
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = ms.sendFrame(canIf, buf)
+		if err == nil || !isTxQueueFull(err) || attempt >= retries {
+			break
+		}
+		canIf.Metrics.RecordTxRetry()
+		// Back off without the interface lock so other senders and health probes are not held up
+		time.Sleep(backoff * time.Duration(attempt+1))
+	}
+	var errno unix.Errno
+	if errors.As(err, &errno) {
+		err = &SendError{Interface: msg.Interface, Errno: errno}
+	}
 
 	// Update metrics
-	if err == nil {
+	switch {
+	case err == nil:
 		latency := time.Since(startTime)
 		canIf.Metrics.RecordSuccess(latency)
 
 		// Log success
 		ms.logger.Printf("✅ %s message sent: ID=0x%X, Data=[% X], Length=%d, Latency=%v",
 			msg.Interface, msg.ID, msg.Data, frame.Length, latency)
-	} else {
+	case isTxQueueFull(err):
+		// Backpressure, not a fault: counted apart from send errors
+		canIf.Metrics.RecordTxQueueFull()
+		err = fmt.Errorf("%w after %d attempt(s): %w", ErrTxQueueFull, retries+1, err)
+		ms.logger.Printf("⚠️ %s message not sent: ID=0x%X, Error=%v", msg.Interface, msg.ID, err)
+	default:
 		canIf.Metrics.RecordError(err)
 
 		// Log error
@@ -86,10 +474,23 @@ func (ms *MessageSender) sendMessage(canIf *CanInterface, msg CanMessage) error
 	return err
 }
 
+// sendFrame writes one raw frame to the interface socket, serialised with other writers
+func (ms *MessageSender) sendFrame(canIf *CanInterface, buf []byte) error {
+	canIf.Lock()
+	defer canIf.Unlock()
+	return ms.socketProvider.SendTo(canIf.FD, buf, canIf.Addr)
+}
+
+// isTxQueueFull reports whether a send failed only because the interface TX queue was full
+func isTxQueueFull(err error) bool {
+	return errors.Is(err, unix.ENOBUFS) || errors.Is(err, unix.EAGAIN)
+}
+
 // ValidateMessage validates a CAN message before sending
 func (ms *MessageSender) ValidateMessage(msg CanMessage) error {
-	if msg.Interface == "" {
-		return fmt.Errorf("interface name is required")
+	var err error
+	if msg.Interface, err = ms.ResolveInterfaceName(msg.Interface); err != nil {
+		return err
 	}
 
 	if !ms.configProvider.ValidateInterface(msg.Interface) {
@@ -97,13 +498,7 @@ func (ms *MessageSender) ValidateMessage(msg CanMessage) error {
 			msg.Interface, ms.configProvider.GetCanPorts())
 	}
 
-	if len(msg.Data) == 0 {
-		return fmt.Errorf("message data cannot be empty")
-	}
-
-	if len(msg.Data) > 8 {
-		return fmt.Errorf("CAN data exceeds maximum length (8 bytes)")
-	}
-
-	return nil
+	// Only classic frames can be sent for now
+	_, err = frameLength(msg, false)
+	return err
 }
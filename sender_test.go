@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fullQueueSocket fails the first send with ENOBUFS and accepts the rest
+type fullQueueSocket struct {
+	UnixSocketProvider
+	sends int
+}
+
+func (s *fullQueueSocket) SendTo(int, []byte, *unix.SockaddrCAN) error {
+	s.sends++
+	if s.sends == 1 {
+		return unix.ENOBUFS
+	}
+	return nil
+}
+
+func TestSendBacksOffWithoutInterfaceLock(t *testing.T) {
+	socket := &fullQueueSocket{}
+	config := NewDefaultConfigProvider(&Config{SendRetries: 1, SendRetryBackoff: 200 * time.Millisecond})
+	ms := NewMessageSender(nil, config, socket, discardLogger{})
+	canIf := NewCanInterface("can0", -1, &unix.SockaddrCAN{}, 10)
+
+	done := make(chan error, 1)
+	go func() { done <- ms.sendMessage(canIf, CanMessage{Interface: "can0", ID: 0x123, Data: []byte{1}}) }()
+
+	// The first attempt fails at once; the interface must be free while the sender waits to retry
+	time.Sleep(50 * time.Millisecond)
+	locked := make(chan struct{})
+	go func() {
+		canIf.Lock()
+		canIf.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("the interface stayed locked during the send backoff")
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("sendMessage: %v", err)
+	}
+	if socket.sends != 2 {
+		t.Fatalf("sends = %d, want 2", socket.sends)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MessageSnapshot is the file written by -snapshot-on-exit and read back with -restore-snapshot
+type MessageSnapshot struct {
+	Version    string                     `json:"version"` // Service version that wrote the snapshot
+	Time       time.Time                  `json:"time"`
+	Interfaces map[string][]CanMessageLog `json:"interfaces"` // Buffered messages per interface, oldest first
+}
+
+// WriteMessageSnapshot writes a snapshot to path, replacing any previous one only once the new
+// file is complete. It gives up when ctx is done, leaving the previous snapshot in place.
+func WriteMessageSnapshot(ctx context.Context, path string, snapshot *MessageSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+		if err != nil {
+			done <- fmt.Errorf("failed to create snapshot file: %w", err)
+			return
+		}
+		defer os.Remove(tmp.Name()) // No-op after the rename
+
+		_, err = tmp.Write(data)
+		if err == nil {
+			err = tmp.Sync()
+		}
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			done <- fmt.Errorf("failed to write snapshot file: %w", err)
+			return
+		}
+		if ctx.Err() != nil {
+			done <- ctx.Err()
+			return
+		}
+		done <- os.Rename(tmp.Name(), path)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("snapshot not written before the shutdown deadline: %w", ctx.Err())
+	}
+}
+
+// LoadMessageSnapshot reads a snapshot written by WriteMessageSnapshot
+func LoadMessageSnapshot(path string) (*MessageSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &MessageSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot file %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// writeSnapshot saves the message buffer of every configured interface to -snapshot-on-exit
+func (s *Service) writeSnapshot(ctx context.Context) {
+	config := s.currentConfig()
+
+	snapshot := &MessageSnapshot{
+		Version:    VERSION,
+		Time:       time.Now(),
+		Interfaces: make(map[string][]CanMessageLog),
+	}
+	total := 0
+	for _, ifName := range config.CanPorts {
+		messages, err := s.messageListener.GetMessages(ifName)
+		if err != nil || len(messages) == 0 {
+			continue
+		}
+		snapshot.Interfaces[ifName] = messages
+		total += len(messages)
+	}
+
+	if err := WriteMessageSnapshot(ctx, config.SnapshotOnExit, snapshot); err != nil {
+		s.logger.Printf("⚠️ Failed to write message snapshot to %s: %v", config.SnapshotOnExit, err)
+		return
+	}
+	s.logger.Printf("💾 Saved %d message(s) from %d interface(s) to %s", total, len(snapshot.Interfaces), config.SnapshotOnExit)
+}
+
+// restoreSnapshot reloads the message buffers saved by the previous run. A missing file is
+// not an error: there is nothing to restore on the first start.
+func (s *Service) restoreSnapshot() {
+	config := s.currentConfig()
+
+	snapshot, err := LoadMessageSnapshot(config.SnapshotOnExit)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.logger.Printf("💾 No message snapshot at %s to restore", config.SnapshotOnExit)
+			return
+		}
+		s.logger.Printf("⚠️ Failed to restore message snapshot: %v", err)
+		return
+	}
+
+	total, restored := 0, 0
+	for ifName, messages := range snapshot.Interfaces {
+		if !s.configProvider.ValidateInterface(ifName) {
+			s.logger.Printf("💾 Skipping %d snapshot message(s) of %s: not a configured port", len(messages), ifName)
+			continue
+		}
+		s.messageListener.RestoreMessages(ifName, messages)
+		total += len(messages)
+		restored++
+	}
+	s.logger.Printf("💾 Restored %d message(s) on %d interface(s) from the snapshot taken %s",
+		total, restored, snapshot.Time.Format(time.RFC3339))
+}
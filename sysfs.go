@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysClassNet is where the kernel exposes per-interface attributes and counters
+var sysClassNet = "/sys/class/net"
+
+// KernelStats are the counters the kernel keeps for an interface, the same numbers
+// `ip -s link` and other standard Linux tools report
+type KernelStats struct {
+	RxPackets uint64 `json:"rxPackets"`
+	TxPackets uint64 `json:"txPackets"`
+	RxBytes   uint64 `json:"rxBytes"`
+	TxBytes   uint64 `json:"txBytes"`
+	RxDropped uint64 `json:"rxDropped"`
+	TxDropped uint64 `json:"txDropped"`
+	RxErrors  uint64 `json:"rxErrors"`
+	TxErrors  uint64 `json:"txErrors"`
+	CanState  string `json:"canState,omitempty"` // Controller state, e.g. ERROR-ACTIVE; empty if unknown
+}
+
+// ReadKernelStats reads the statistics of an interface from sysfs with plain file reads.
+// can_state is only exposed by some drivers; it is left empty when missing.
+func ReadKernelStats(ifName string) (*KernelStats, error) {
+	if err := validateInterfaceName(ifName); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(sysClassNet, ifName)
+	stats := &KernelStats{}
+	counters := []struct {
+		file  string
+		value *uint64
+	}{
+		{"rx_packets", &stats.RxPackets},
+		{"tx_packets", &stats.TxPackets},
+		{"rx_bytes", &stats.RxBytes},
+		{"tx_bytes", &stats.TxBytes},
+		{"rx_dropped", &stats.RxDropped},
+		{"tx_dropped", &stats.TxDropped},
+		{"rx_errors", &stats.RxErrors},
+		{"tx_errors", &stats.TxErrors},
+	}
+	for _, counter := range counters {
+		value, err := readSysfsUint(filepath.Join(dir, "statistics", counter.file))
+		if err != nil {
+			return nil, err
+		}
+		*counter.value = value
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "can_state")); err == nil {
+		stats.CanState = strings.ToUpper(strings.TrimSpace(string(data)))
+	}
+	return stats, nil
+}
+
+// readSysfsUint reads a sysfs attribute holding one unsigned decimal number
+func readSysfsUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s: %w", path, err)
+	}
+	return value, nil
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
@@ -9,6 +13,12 @@ import (
 
 const IFNAMSIZ = 16
 
+// Sizes of struct can_frame and struct canfd_frame as returned by a read on a CAN_RAW socket
+const (
+	CAN_MTU   = 16
+	CANFD_MTU = 72
+)
+
 // CAN frame structure
 type CanFrame struct {
 	ID     uint32
@@ -17,6 +27,86 @@ type CanFrame struct {
 	Data   [8]byte
 }
 
+// CAN FD frame structure
+type CanFDFrame struct {
+	ID     uint32
+	Length uint8
+	Flags  uint8
+	_      [2]byte
+	Data   [64]byte
+}
+
+// ErrInvalidDataLength is returned for payloads the requested frame type cannot carry
+var ErrInvalidDataLength = errors.New("invalid CAN data length")
+
+// canFDDataLengths are the payload sizes a CAN FD frame can carry (DLC 0-15)
+var canFDDataLengths = []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 12, 16, 20, 24, 32, 48, 64}
+
+// validateDataLength checks that a payload fits the frame type: 1 to 8 bytes for
+// classic CAN, or one of the discrete CAN FD sizes
+func validateDataLength(data []byte, fd bool) error {
+	if fd {
+		if !slices.Contains(canFDDataLengths, len(data)) {
+			return fmt.Errorf("%w: got %d bytes, CAN FD frames carry 0-8, 12, 16, 20, 24, 32, 48 or 64 bytes",
+				ErrInvalidDataLength, len(data))
+		}
+		return nil
+	}
+	if len(data) < 1 || len(data) > 8 {
+		return fmt.Errorf("%w: got %d bytes, classic CAN frames carry 1 to 8 bytes", ErrInvalidDataLength, len(data))
+	}
+	return nil
+}
+
+// padToDLC8 fills the data of a classic data frame up to 8 bytes with fill, so it is sent with
+// DLC 8. Remote frames and messages with an explicit Length below 8 are returned unchanged.
+func padToDLC8(msg CanMessage, fill byte) CanMessage {
+	if msg.ID&unix.CAN_RTR_FLAG != 0 || len(msg.Data) >= 8 || (msg.Length != 0 && msg.Length < 8) {
+		return msg
+	}
+	data := make([]byte, 8)
+	n := copy(data, msg.Data)
+	for i := n; i < len(data); i++ {
+		data[i] = fill
+	}
+	msg.Data = data
+	return msg
+}
+
+// frameLength returns the length code to send a message with: its Length when set, else the
+// number of data bytes. Length may exceed the data, which is then zero-padded, so a capture's
+// DLC can be replayed. Remote (RTR) frames carry no data; their Length is the requested DLC.
+func frameLength(msg CanMessage, fd bool) (uint8, error) {
+	if msg.ID&unix.CAN_RTR_FLAG != 0 {
+		switch {
+		case fd:
+			return 0, fmt.Errorf("%w: CAN FD has no remote frames", ErrInvalidDataLength)
+		case len(msg.Data) > 0:
+			return 0, fmt.Errorf("%w: remote frames carry no data, got %d bytes", ErrInvalidDataLength, len(msg.Data))
+		case msg.Length > 8:
+			return 0, fmt.Errorf("%w: remote frame length must be 0 to 8, got %d", ErrInvalidDataLength, msg.Length)
+		}
+		return msg.Length, nil
+	}
+
+	if err := validateDataLength(msg.Data, fd); err != nil {
+		return 0, err
+	}
+	if msg.Length == 0 {
+		return uint8(len(msg.Data)), nil
+	}
+	if int(msg.Length) < len(msg.Data) {
+		return 0, fmt.Errorf("%w: length %d is shorter than the %d data bytes", ErrInvalidDataLength, msg.Length, len(msg.Data))
+	}
+	if fd && !slices.Contains(canFDDataLengths, int(msg.Length)) {
+		return 0, fmt.Errorf("%w: length %d is not a CAN FD frame size (0-8, 12, 16, 20, 24, 32, 48 or 64)", ErrInvalidDataLength, msg.Length)
+	}
+	if !fd && msg.Length > 8 {
+		return 0, fmt.Errorf("%w: length must be 1 to 8 for classic CAN, got %d", ErrInvalidDataLength, msg.Length)
+	}
+	return msg.Length, nil
+}
+
 // ioctl interface structure
 type ifreq struct {
 	Name  [IFNAMSIZ]byte
@@ -26,18 +116,25 @@ type ifreq struct {
 
 // Request structures
 type CanMessage struct {
-	Interface string `json:"interface" binding:"required"`
-	ID        uint32 `json:"id" binding:"required"`
-	Data      []byte `json:"data" binding:"required,min=1,max=8"`
-	Length    uint8  `json:"length,omitempty"`
+	Interface string `json:"interface" form:"interface"` // Empty selects the default interface
+	ID        uint32 `json:"id" form:"-" binding:"required_without=IDText"`
+	IDText    string `json:"-" form:"id"`                          // Form posts only: the ID as hex with a "0x" prefix or decimal
+	Data      []byte `json:"data"`                                 // Length is checked by ValidateMessage
+	DataHex   string `json:"-" form:"dataHex"`                     // Form posts only: the payload as hex, e.g. "01 02 03"
+	Length    uint8  `json:"length,omitempty" form:"length"`       // DLC when it differs from len(Data); see frameLength
+	Priority  int    `json:"priority,omitempty" form:"priority"`   // Async send only: higher values leave the queue first
+	PadToDLC8 *bool  `json:"padToDlc8,omitempty" form:"padToDlc8"` // Overrides -tx-pad-to-dlc8 for this message
+	Source    string `json:"-"`                                    // Who is sending, recorded on the logged TX frame
 }
 
 // API response structure
 type ApiResponse struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Status    string      `json:"status"`
+	Message   string      `json:"message,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"requestId,omitempty"` // Echo of the X-Request-ID header
+	DryRun    bool        `json:"dryRun,omitempty"`    // Set by interface setup endpoints when running with -dry-run
 }
 
 // Metrics structure for better testing
@@ -49,15 +146,29 @@ type InterfaceMetrics struct {
 	LastErrorTime  time.Time
 	LastErrorMsg   string
 	AvgLatency     time.Duration
-	MessageLatency []time.Duration
+	MessageLatency []time.Duration // Ring of the most recent latencies, never grows past its capacity
+	QueueDepth     int
+	QueueDrops     uint64
+	TxQueueFull    uint64            // Sends given up because the kernel TX queue stayed full
+	TxRetries      uint64            // Send attempts repeated because the TX queue was full
+	ErrorsByErrno  map[string]uint64 // Failed sends per errno name, e.g. "ENETDOWN"; "other" for non-kernel errors
 	mutex          sync.RWMutex
+
+	latencyNext int           // Slot overwritten by the next sample once the ring is full
+	latencySum  time.Duration // Sum of the samples currently in the ring
 }
 
-// NewInterfaceMetrics creates a new metrics instance
-func NewInterfaceMetrics() *InterfaceMetrics {
+// defaultLatencyWindow is used when no positive window size is given
+const defaultLatencyWindow = 100
+
+// NewInterfaceMetrics creates a new metrics instance keeping up to window latency samples
+func NewInterfaceMetrics(window int) *InterfaceMetrics {
+	if window <= 0 {
+		window = defaultLatencyWindow
+	}
 	return &InterfaceMetrics{
 		StartTime:      time.Now(),
-		MessageLatency: make([]time.Duration, 0, 100),
+		MessageLatency: make([]time.Duration, 0, window),
 	}
 }
 
@@ -69,20 +180,17 @@ func (m *InterfaceMetrics) RecordSuccess(latency time.Duration) {
 	m.TotalSent++
 	m.LastSendTime = time.Now()
 
-	// Update latency tracking
-	m.MessageLatency = append(m.MessageLatency, latency)
-	if len(m.MessageLatency) > 100 {
-		m.MessageLatency = m.MessageLatency[1:]
+	// Update latency tracking, overwriting the oldest sample once the window is full
+	if len(m.MessageLatency) < cap(m.MessageLatency) {
+		m.MessageLatency = append(m.MessageLatency, latency)
+	} else {
+		m.latencySum -= m.MessageLatency[m.latencyNext]
+		m.MessageLatency[m.latencyNext] = latency
+		m.latencyNext = (m.latencyNext + 1) % len(m.MessageLatency)
 	}
+	m.latencySum += latency
 
-	// Compute average latency
-	var totalLatency time.Duration
-	for _, lat := range m.MessageLatency {
-		totalLatency += lat
-	}
-	if len(m.MessageLatency) > 0 {
-		m.AvgLatency = totalLatency / time.Duration(len(m.MessageLatency))
-	}
+	m.AvgLatency = m.latencySum / time.Duration(len(m.MessageLatency))
 }
 
 // RecordError updates metrics for failed send
@@ -93,6 +201,66 @@ func (m *InterfaceMetrics) RecordError(err error) {
 	m.TotalErrors++
 	m.LastErrorTime = time.Now()
 	m.LastErrorMsg = err.Error()
+	if m.ErrorsByErrno == nil {
+		m.ErrorsByErrno = make(map[string]uint64)
+	}
+	m.ErrorsByErrno[sendErrorClass(err)]++
+}
+
+// RecordTxQueueFull updates metrics for a send given up under TX queue backpressure
+func (m *InterfaceMetrics) RecordTxQueueFull() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.TxQueueFull++
+}
+
+// RecordTxRetry updates metrics for a send attempt repeated because the TX queue was full
+func (m *InterfaceMetrics) RecordTxRetry() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.TxRetries++
+}
+
+// SetQueueDepth records the current number of frames waiting in the send queue
+func (m *InterfaceMetrics) SetQueueDepth(depth int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.QueueDepth = depth
+}
+
+// RecordQueueDrop updates metrics for a frame rejected by a full send queue
+func (m *InterfaceMetrics) RecordQueueDrop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.QueueDrops++
+}
+
+// Reset zeroes the counters and latency history. QueueDepth is a live gauge and is kept;
+// StartTime is only moved to now when resetStartTime is set.
+func (m *InterfaceMetrics) Reset(resetStartTime bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.TotalSent = 0
+	m.TotalErrors = 0
+	m.LastSendTime = time.Time{}
+	m.LastErrorTime = time.Time{}
+	m.LastErrorMsg = ""
+	m.AvgLatency = 0
+	m.MessageLatency = m.MessageLatency[:0]
+	m.latencyNext = 0
+	m.latencySum = 0
+	m.QueueDrops = 0
+	m.TxQueueFull = 0
+	m.TxRetries = 0
+	m.ErrorsByErrno = nil
+	if resetStartTime {
+		m.StartTime = time.Now()
+	}
 }
 
 // GetStats returns a snapshot of current metrics
@@ -100,6 +268,10 @@ func (m *InterfaceMetrics) GetStats() InterfaceStats {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
+	// The window is small enough that sorting a copy on every snapshot is cheap
+	sorted := slices.Clone(m.MessageLatency)
+	slices.Sort(sorted)
+
 	return InterfaceStats{
 		TotalSent:     m.TotalSent,
 		TotalErrors:   m.TotalErrors,
@@ -108,8 +280,29 @@ func (m *InterfaceMetrics) GetStats() InterfaceStats {
 		LastErrorTime: m.LastErrorTime,
 		LastErrorMsg:  m.LastErrorMsg,
 		AvgLatency:    m.AvgLatency,
+		P50Latency:    latencyPercentile(sorted, 50),
+		P95Latency:    latencyPercentile(sorted, 95),
+		P99Latency:    latencyPercentile(sorted, 99),
+		MaxLatency:    latencyPercentile(sorted, 100),
 		Uptime:        time.Since(m.StartTime),
+		QueueDepth:    m.QueueDepth,
+		QueueDrops:    m.QueueDrops,
+		TxQueueFull:   m.TxQueueFull,
+		TxRetries:     m.TxRetries,
+		ErrorsByErrno: maps.Clone(m.ErrorsByErrno),
+	}
+}
+
+// latencyPercentile returns the nearest-rank percentile p (0-100] of an ascending latency slice
+func latencyPercentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
 	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
 }
 
 // InterfaceStats represents a snapshot of metrics
@@ -121,7 +314,16 @@ type InterfaceStats struct {
 	LastErrorTime time.Time
 	LastErrorMsg  string
 	AvgLatency    time.Duration
+	P50Latency    time.Duration
+	P95Latency    time.Duration
+	P99Latency    time.Duration
+	MaxLatency    time.Duration
 	Uptime        time.Duration
+	QueueDepth    int
+	QueueDrops    uint64
+	TxQueueFull   uint64
+	TxRetries     uint64
+	ErrorsByErrno map[string]uint64
 }
 
 // SuccessRate calculates the success rate percentage
@@ -139,15 +341,19 @@ type CanInterface struct {
 	Addr    *unix.SockaddrCAN
 	Metrics *InterfaceMetrics
 	mutex   sync.Mutex
+
+	// Error counters seen by the previous passive health check
+	lastTxErrors int
+	lastRxErrors int
 }
 
 // NewCanInterface creates a new CAN interface instance
-func NewCanInterface(name string, fd int, addr *unix.SockaddrCAN) *CanInterface {
+func NewCanInterface(name string, fd int, addr *unix.SockaddrCAN, latencyWindow int) *CanInterface {
 	return &CanInterface{
 		Name:    name,
 		FD:      fd,
 		Addr:    addr,
-		Metrics: NewInterfaceMetrics(),
+		Metrics: NewInterfaceMetrics(latencyWindow),
 	}
 }
 
@@ -161,6 +367,11 @@ func (c *CanInterface) Unlock() {
 	c.mutex.Unlock()
 }
 
+// ResetMetrics zeroes the send-side metrics of the interface, see InterfaceMetrics.Reset
+func (c *CanInterface) ResetMetrics(resetStartTime bool) {
+	c.Metrics.Reset(resetStartTime)
+}
+
 // GetStats returns interface statistics
 func (c *CanInterface) GetStats() InterfaceStats {
 	return c.Metrics.GetStats()
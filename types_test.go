@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordSuccessWraparound(t *testing.T) {
+	m := NewInterfaceMetrics(4)
+	for i := 1; i <= 10; i++ {
+		m.RecordSuccess(time.Duration(i) * time.Millisecond)
+	}
+
+	// Samples 7-10 are left; 9 and 10 overwrote slots 0 and 1
+	if len(m.MessageLatency) != 4 || cap(m.MessageLatency) != 4 {
+		t.Fatalf("ring len %d cap %d, want 4 and 4", len(m.MessageLatency), cap(m.MessageLatency))
+	}
+	if m.latencyNext != 2 {
+		t.Fatalf("latencyNext = %d, want 2", m.latencyNext)
+	}
+	if want := 34 * time.Millisecond; m.latencySum != want {
+		t.Fatalf("latencySum = %v, want %v", m.latencySum, want)
+	}
+	if want := 8500 * time.Microsecond; m.AvgLatency != want {
+		t.Fatalf("AvgLatency = %v, want %v", m.AvgLatency, want)
+	}
+
+	stats := m.GetStats()
+	if stats.P50Latency != 8*time.Millisecond || stats.MaxLatency != 10*time.Millisecond {
+		t.Fatalf("p50 %v max %v, want 8ms and 10ms", stats.P50Latency, stats.MaxLatency)
+	}
+
+	// A reset ring fills from the start again
+	m.Reset(false)
+	m.RecordSuccess(time.Millisecond)
+	if len(m.MessageLatency) != 1 || m.latencyNext != 0 || m.latencySum != time.Millisecond {
+		t.Fatalf("after reset: len %d next %d sum %v", len(m.MessageLatency), m.latencyNext, m.latencySum)
+	}
+}
+
+func TestRecordSuccessDoesNotGrow(t *testing.T) {
+	m := NewInterfaceMetrics(8)
+	for i := 0; i < 8; i++ {
+		m.RecordSuccess(time.Millisecond)
+	}
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		m.RecordSuccess(time.Millisecond)
+	})
+	if allocs != 0 {
+		t.Fatalf("RecordSuccess allocated %v times on a full ring, want 0", allocs)
+	}
+	if cap(m.MessageLatency) != 8 {
+		t.Fatalf("ring capacity = %d, want 8", cap(m.MessageLatency))
+	}
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		sorted := make([]time.Duration, len(values))
+		for i, v := range values {
+			sorted[i] = time.Duration(v) * time.Millisecond
+		}
+		return sorted
+	}
+	hundred := make([]int, 100)
+	for i := range hundred {
+		hundred[i] = i + 1
+	}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      int
+		want   time.Duration
+	}{
+		{name: "empty", sorted: nil, p: 50, want: 0},
+		{name: "single", sorted: ms(7), p: 99, want: 7 * time.Millisecond},
+		{name: "p50 of 10", sorted: ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), p: 50, want: 5 * time.Millisecond},
+		{name: "p95 of 10", sorted: ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), p: 95, want: 10 * time.Millisecond},
+		{name: "p0 is the minimum", sorted: ms(1, 2, 3), p: 0, want: time.Millisecond},
+		{name: "p50 of 100", sorted: ms(hundred...), p: 50, want: 50 * time.Millisecond},
+		{name: "p99 of 100", sorted: ms(hundred...), p: 99, want: 99 * time.Millisecond},
+		{name: "p100 is the maximum", sorted: ms(hundred...), p: 100, want: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := latencyPercentile(tt.sorted, tt.p); got != tt.want {
+				t.Fatalf("latencyPercentile(p%d) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkRecordSuccess(b *testing.B) {
+	m := NewInterfaceMetrics(defaultLatencyWindow)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		m.RecordSuccess(time.Duration(i%1000) * time.Microsecond)
+	}
+	if cap(m.MessageLatency) != defaultLatencyWindow {
+		b.Fatalf("ring capacity grew to %d", cap(m.MessageLatency))
+	}
+}
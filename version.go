@@ -1,5 +1,28 @@
 package main
 
+import "runtime"
+
+// Build information, set at build time via -ldflags "-X main.VERSION=... -X main.COMMIT=... -X main.BUILD_DATE=..."
 var (
-	VERSION = "dev"
+	VERSION    = "dev"
+	COMMIT     = "unknown"
+	BUILD_DATE = "unknown"
 )
+
+// VersionInfo describes the running build
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// GetVersionInfo returns the build information of the running binary
+func GetVersionInfo() VersionInfo {
+	return VersionInfo{
+		Version:   VERSION,
+		Commit:    COMMIT,
+		BuildDate: BUILD_DATE,
+		GoVersion: runtime.Version(),
+	}
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,6 +15,9 @@ type WatchdogConfig struct {
 	ErrorThreshold      time.Duration
 	RecoveryEnabled     bool
 	MaxRecoveryAttempts int
+	MinRxRate           int            // Frames each interface must receive per check (0 disables)
+	MinRxRates          map[string]int // Per-interface overrides of MinRxRate
+	Exclude             []string       // Interfaces that are reported on but never recovered
 }
 
 // DefaultWatchdogConfig returns default watchdog configuration
@@ -29,11 +35,23 @@ type Watchdog struct {
 	interfaceManager *InterfaceManager
 	config           WatchdogConfig
 	logger           Logger
+	downLog          *sampledLogger // Repeating "still down" messages, logged first in full and then summarized
 	running          bool
 	stopChan         chan struct{}
+	configChanged    chan struct{}
 	wg               sync.WaitGroup
 	mu               sync.RWMutex
 	recoveryAttempts map[string]int
+	lastCheck        time.Time
+	lastCheckTook    time.Duration
+	lastCheckCount   int
+	unhealthy        map[string]bool
+	alertNotifier    *AlertNotifier
+	history          *InterfaceHistory
+	messageListener  *CanMessageListener
+	rxCounts         map[string]uint64 // Receive counters seen at the previous sweep
+	silent           map[string]bool
+	monitored        map[string]bool // Set through the API; takes precedence over config.Exclude
 }
 
 // NewWatchdog creates a new watchdog
@@ -42,11 +60,38 @@ func NewWatchdog(interfaceManager *InterfaceManager, config WatchdogConfig, logg
 		interfaceManager: interfaceManager,
 		config:           config,
 		logger:           logger,
+		downLog:          newSampledLogger(logger, repeatLogInterval),
 		stopChan:         make(chan struct{}),
+		configChanged:    make(chan struct{}, 1),
 		recoveryAttempts: make(map[string]int),
+		unhealthy:        make(map[string]bool),
+		rxCounts:         make(map[string]uint64),
+		silent:           make(map[string]bool),
+		monitored:        make(map[string]bool),
 	}
 }
 
+// SetAlertNotifier sets the notifier used to report interface state transitions
+func (w *Watchdog) SetAlertNotifier(notifier *AlertNotifier) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.alertNotifier = notifier
+}
+
+// SetHistory sets the history that health transitions are recorded in
+func (w *Watchdog) SetHistory(history *InterfaceHistory) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.history = history
+}
+
+// SetMessageListener sets the listener that is restarted on interfaces the watchdog recovers
+func (w *Watchdog) SetMessageListener(listener *CanMessageListener) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messageListener = listener
+}
+
 // Start starts the watchdog monitoring
 func (w *Watchdog) Start(ctx context.Context) error {
 	w.mu.Lock()
@@ -93,7 +138,7 @@ func (w *Watchdog) IsRunning() bool {
 func (w *Watchdog) monitorLoop(ctx context.Context) {
 	defer w.wg.Done()
 
-	ticker := time.NewTicker(w.config.CheckInterval)
+	ticker := time.NewTicker(w.GetConfig().CheckInterval)
 	defer ticker.Stop()
 
 	for {
@@ -104,6 +149,8 @@ func (w *Watchdog) monitorLoop(ctx context.Context) {
 		case <-w.stopChan:
 			w.logger.Printf("🐕 Watchdog stopping due to stop signal")
 			return
+		case <-w.configChanged:
+			ticker.Reset(w.GetConfig().CheckInterval)
 		case <-ticker.C:
 			w.checkInterfaces()
 		}
@@ -112,20 +159,165 @@ func (w *Watchdog) monitorLoop(ctx context.Context) {
 
 // checkInterfaces checks all interfaces for health issues
 func (w *Watchdog) checkInterfaces() {
+	sweepStart := time.Now()
 	interfaces := w.interfaceManager.GetAllInterfaces()
+	defer func() {
+		w.mu.Lock()
+		w.lastCheck = sweepStart
+		w.lastCheckTook = time.Since(sweepStart)
+		w.lastCheckCount = len(interfaces)
+		w.mu.Unlock()
+	}()
 
 	for ifName, canIf := range interfaces {
+		w.checkReceiveRate(ifName)
 		if w.shouldCheckInterface(canIf) {
 			if !w.interfaceManager.CheckHealth(ifName) {
-				w.handleUnhealthyInterface(ifName)
+				w.markUnhealthy(ifName)
+				if w.IsMonitored(ifName) {
+					w.handleUnhealthyInterface(ifName)
+				} else {
+					w.downLog.Printf("down:"+ifName, "⚠️ %s interface appears down, but it is excluded from watchdog recovery", ifName)
+				}
 			} else {
 				// Reset recovery attempts on successful health check
+				w.markHealthy(ifName, "health check passed")
 				w.resetRecoveryAttempts(ifName)
 			}
 		}
 	}
 }
 
+// IsMonitored reports whether the watchdog recovers an interface. Excluded interfaces are
+// still health checked, so their status and alerts stay accurate.
+func (w *Watchdog) IsMonitored(ifName string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.isMonitored(ifName)
+}
+
+// isMonitored is IsMonitored for callers holding w.mu
+func (w *Watchdog) isMonitored(ifName string) bool {
+	if monitored, ok := w.monitored[ifName]; ok {
+		return monitored
+	}
+	return !slices.Contains(w.config.Exclude, ifName)
+}
+
+// SetMonitored includes an interface in or excludes it from recovery until the service restarts,
+// overriding -watchdog-exclude for that interface
+func (w *Watchdog) SetMonitored(ifName string, monitored bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.monitored[ifName] = monitored
+	if !monitored {
+		delete(w.recoveryAttempts, ifName)
+	}
+}
+
+// ExcludedInterfaces returns the interfaces excluded from recovery, sorted by name
+func (w *Watchdog) ExcludedInterfaces() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	excluded := []string{}
+	for _, ifName := range w.config.Exclude {
+		if w.isMonitored(ifName) || slices.Contains(excluded, ifName) {
+			continue
+		}
+		excluded = append(excluded, ifName)
+	}
+	for ifName, monitored := range w.monitored {
+		if !monitored && !slices.Contains(excluded, ifName) {
+			excluded = append(excluded, ifName)
+		}
+	}
+	slices.Sort(excluded)
+	return excluded
+}
+
+// minRxRateFor returns the frames an interface must receive per check (0 disables)
+func (c WatchdogConfig) minRxRateFor(ifName string) int {
+	if rate, ok := c.MinRxRates[ifName]; ok {
+		return rate
+	}
+	return c.MinRxRate
+}
+
+// checkReceiveRate flags an interface silent when fewer frames than its minimum receive rate
+// arrived since the previous sweep. Silence is a bus-side condition, so no recovery is attempted.
+func (w *Watchdog) checkReceiveRate(ifName string) {
+	w.mu.RLock()
+	listener := w.messageListener
+	minRate := w.config.minRxRateFor(ifName)
+	w.mu.RUnlock()
+
+	if listener == nil {
+		return
+	}
+	count, ok := listener.GetRxFrameCount(ifName)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+	previous, seen := w.rxCounts[ifName]
+	w.rxCounts[ifName] = count
+	wasSilent := w.silent[ifName]
+	w.mu.Unlock()
+
+	// The first sweep only records a baseline
+	if !seen || count < previous {
+		return
+	}
+	if minRate <= 0 {
+		w.setSilent(ifName, false, "minimum receive rate disabled")
+		return
+	}
+
+	received := count - previous
+	if received < uint64(minRate) {
+		if !wasSilent {
+			w.logger.Printf("🔇 %s received %d frame(s) in the last check interval, expected at least %d", ifName, received, minRate)
+		}
+		w.setSilent(ifName, true, fmt.Sprintf("received %d frame(s) in the last check interval, expected at least %d", received, minRate))
+		return
+	}
+	if wasSilent {
+		w.logger.Printf("🔊 %s is receiving again (%d frame(s) in the last check interval)", ifName, received)
+	}
+	w.setSilent(ifName, false, fmt.Sprintf("received %d frame(s) in the last check interval", received))
+}
+
+// setSilent records whether an interface is silent and alerts on transitions
+func (w *Watchdog) setSilent(ifName string, silent bool, details string) {
+	w.mu.Lock()
+	wasSilent := w.silent[ifName]
+	if silent {
+		w.silent[ifName] = true
+	} else {
+		delete(w.silent, ifName)
+	}
+	notifier := w.alertNotifier
+	w.mu.Unlock()
+
+	if wasSilent == silent || notifier == nil {
+		return
+	}
+	event := AlertEventTraffic
+	if silent {
+		event = AlertEventSilent
+	}
+	notifier.Notify(AlertEvent{Interface: ifName, Event: event, Details: details})
+}
+
+// IsSilent reports whether an interface received fewer frames than its minimum rate in the last check interval
+func (w *Watchdog) IsSilent(ifName string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.silent[ifName]
+}
+
 // shouldCheckInterface determines if an interface needs health checking
 func (w *Watchdog) shouldCheckInterface(canIf *CanInterface) bool {
 	stats := canIf.GetStats()
@@ -133,7 +325,7 @@ func (w *Watchdog) shouldCheckInterface(canIf *CanInterface) bool {
 	// Skip health check if no errors or recent successful sends after errors
 	if stats.LastErrorTime.IsZero() ||
 		stats.LastSendTime.After(stats.LastErrorTime) ||
-		time.Since(stats.LastErrorTime) >= w.config.ErrorThreshold {
+		time.Since(stats.LastErrorTime) >= w.GetConfig().ErrorThreshold {
 		return false
 	}
 
@@ -142,27 +334,110 @@ func (w *Watchdog) shouldCheckInterface(canIf *CanInterface) bool {
 
 // handleUnhealthyInterface handles an unhealthy interface
 func (w *Watchdog) handleUnhealthyInterface(ifName string) {
-	if !w.config.RecoveryEnabled {
-		w.logger.Printf("⚠️ %s interface appears down, but recovery is disabled", ifName)
+	config := w.GetConfig()
+	if !config.RecoveryEnabled {
+		w.downLog.Printf("down:"+ifName, "⚠️ %s interface appears down, but recovery is disabled", ifName)
 		return
 	}
 
 	attempts := w.getRecoveryAttempts(ifName)
-	if attempts >= w.config.MaxRecoveryAttempts {
-		w.logger.Printf("❌ %s interface recovery failed after %d attempts, giving up", ifName, attempts)
+	if attempts >= config.MaxRecoveryAttempts {
+		w.downLog.Printf("down:"+ifName, "❌ %s interface recovery failed after %d attempts, giving up", ifName, attempts)
 		return
 	}
 
 	w.logger.Printf("🔄 %s interface appears down, attempting to reinitialize (attempt %d/%d)...",
-		ifName, attempts+1, w.config.MaxRecoveryAttempts)
+		ifName, attempts+1, config.MaxRecoveryAttempts)
 
 	if err := w.recoverInterface(ifName); err != nil {
 		w.incrementRecoveryAttempts(ifName)
 		w.logger.Printf("❌ %s reinitialization failed: %v", ifName, err)
 	} else {
+		w.markHealthy(ifName, fmt.Sprintf("reinitialized after %d attempt(s)", attempts+1))
 		w.resetRecoveryAttempts(ifName)
 		w.logger.Printf("✅ %s interface successfully reinitialized", ifName)
+		w.restartListening(ifName)
+	}
+}
+
+// restartListening resumes receiving on a recovered interface; the message buffer is preserved
+func (w *Watchdog) restartListening(ifName string) {
+	w.mu.RLock()
+	listener := w.messageListener
+	w.mu.RUnlock()
+
+	if listener == nil {
+		return
+	}
+	restarted, err := listener.RestartListening(ifName)
+	if err != nil {
+		w.logger.Printf("⚠️ %s recovered but listening could not be restarted: %v", ifName, err)
+		return
+	}
+	if !restarted {
+		return
+	}
+	w.logger.Printf("👂 %s listening restarted after recovery", ifName)
+}
+
+// markUnhealthy records an unhealthy interface and alerts on the healthy→unhealthy transition
+func (w *Watchdog) markUnhealthy(ifName string) {
+	w.mu.Lock()
+	wasUnhealthy := w.unhealthy[ifName]
+	w.unhealthy[ifName] = true
+	notifier := w.alertNotifier
+	history := w.history
+	w.mu.Unlock()
+
+	if wasUnhealthy {
+		return
+	}
+	history.Record(ifName, TransitionDown, "watchdog", "health check failed")
+	if notifier == nil {
+		return
 	}
+
+	event := AlertEvent{
+		Interface:        ifName,
+		Event:            AlertEventDown,
+		Details:          "health check failed",
+		RecoveryAttempts: w.getRecoveryAttempts(ifName),
+	}
+	if state, err := w.interfaceManager.GetInterfaceState(ifName); err == nil {
+		if strings.EqualFold(state.CanState, "BUS-OFF") {
+			event.Event = AlertEventBusOff
+		}
+		event.Details = fmt.Sprintf("health check failed (state=%s, canState=%s, txErrors=%d, rxErrors=%d)",
+			state.State, state.CanState, state.TxErrors, state.RxErrors)
+	}
+	notifier.Notify(event)
+}
+
+// markHealthy records a healthy interface and alerts on the unhealthy→healthy transition
+func (w *Watchdog) markHealthy(ifName string, details string) {
+	w.mu.Lock()
+	wasUnhealthy := w.unhealthy[ifName]
+	delete(w.unhealthy, ifName)
+	notifier := w.alertNotifier
+	history := w.history
+	attempts := w.recoveryAttempts[ifName]
+	w.mu.Unlock()
+
+	if !wasUnhealthy {
+		return
+	}
+	w.downLog.Resolve("down:"+ifName, fmt.Sprintf("✅ %s is healthy again (%s)", ifName, details))
+	history.Record(ifName, TransitionUp, "watchdog", details)
+	if notifier == nil {
+		return
+	}
+
+	notifier.Notify(AlertEvent{
+		Interface:        ifName,
+		Event:            AlertEventRecovered,
+		Details:          details,
+		RecoveryAttempts: attempts,
+	})
 }
 
 // recoverInterface attempts to recover a failed interface
@@ -209,11 +484,37 @@ func (w *Watchdog) GetRecoveryStatus() map[string]int {
 	return result
 }
 
-// UpdateConfig updates watchdog configuration
+// LastCheckTime returns the start time of the most recent sweep (zero if none has run)
+func (w *Watchdog) LastCheckTime() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastCheck
+}
+
+// LastCheckDuration returns how long the most recent sweep took
+func (w *Watchdog) LastCheckDuration() time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastCheckTook
+}
+
+// LastCheckInterfaceCount returns the number of interfaces examined in the most recent sweep
+func (w *Watchdog) LastCheckInterfaceCount() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastCheckCount
+}
+
+// UpdateConfig updates watchdog configuration; a running watchdog picks up the new interval immediately
 func (w *Watchdog) UpdateConfig(config WatchdogConfig) {
 	w.mu.Lock()
-	defer w.mu.Unlock()
 	w.config = config
+	w.mu.Unlock()
+
+	select {
+	case w.configChanged <- struct{}{}:
+	default:
+	}
 }
 
 // GetConfig returns current watchdog configuration